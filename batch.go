@@ -0,0 +1,451 @@
+package lodbc
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"math/big"
+	"reflect"
+	"runtime"
+	"unsafe"
+)
+
+// Largest number of rows bound in one parameter array -- bigger batches are executed in chunks
+const maxBatchRows = 1000
+
+// Outcome of one row of a batch, from the SQL_ATTR_PARAM_STATUS_PTR array
+type BatchRowStatus int
+
+const (
+	BatchRowSuccess BatchRowStatus = iota
+	BatchRowSuccessWithInfo
+	BatchRowError
+
+	// The row was not executed, because its chunk of the batch failed before SQLExecute or an
+	// earlier chunk was cancelled, or the driver did not report its outcome
+	BatchRowUnused
+)
+
+// Result of ExecBatch
+type BatchResult struct {
+	// Rows affected by every row of the batch
+	RowsAffected int64
+
+	// Outcome of each row, in the order the rows were passed
+	Status []BatchRowStatus
+
+	// Informational messages returned while executing the batch
+	Messages []StatusRecord
+}
+
+// Returns the indexes of the rows that failed
+func (r *BatchResult) Failed() []int {
+	failed := make([]int, 0)
+	for index, status := range r.Status {
+		if status == BatchRowError {
+			failed = append(failed, index)
+		}
+	}
+	return failed
+}
+
+// Executes query once for each row of parameters, binding the rows as column-wise parameter arrays
+// so a batch of up to 1000 rows is sent with a single SQLExecute.  Every value of a column must have
+// the same type, or be nil.  When some rows fail the result is still returned, with the outcome of
+// each row in Status, together with the error reported by the driver.
+func ExecBatch(ctx context.Context, conn *sql.Conn, query string, rows [][]interface{}) (*BatchResult, error) {
+	var res *BatchResult
+	err := conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(*connection)
+		if !ok {
+			return fmt.Errorf("ExecBatch requires a lodbc connection, not %T", driverConn)
+		}
+		var err error
+		res, err = c.execBatch(ctx, query, rows)
+		return c.checkLink(err)
+	})
+	return res, err
+}
+
+// Prepares query and executes it for each row of parameters
+func (c *connection) execBatch(ctx context.Context, query string, rows [][]interface{}) (*BatchResult, error) {
+	stmt, err := c.newStatement(query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	err = stmt.prepare()
+	if err != nil {
+		return nil, err
+	}
+	return stmt.execBatch(ctx, rows)
+}
+
+// Parameter column bound as an array of values, one element per row
+type batchColumn struct {
+	cType         odbc.CDataType
+	sqlType       odbc.SQLDataType
+	columnSize    int
+	decimalDigits int
+	elementSize   int
+
+	// Buffers bound with SQLBindParameter
+	data []byte
+	ind  []odbc.SQLLEN
+}
+
+// Executes the prepared statement for each row, maxBatchRows rows at a time
+func (stmt *statement) execBatch(ctx context.Context, rows [][]interface{}) (*BatchResult, error) {
+	res := &BatchResult{Status: make([]BatchRowStatus, len(rows))}
+	for index := range res.Status {
+		res.Status[index] = BatchRowUnused
+	}
+	if len(rows) == 0 {
+		return res, nil
+	}
+
+	//Every row must have the same number of parameters
+	numParams := len(rows[0])
+	if stmt.numInput >= 0 {
+		numParams = stmt.numInput
+	}
+	for index, row := range rows {
+		if len(row) != numParams {
+			return nil, fmt.Errorf("Batch row %v has %v parameters, expected %v", index, len(row), numParams)
+		}
+	}
+
+	//If rows is not nil, close rows and set to nil
+	if stmt.rows != nil {
+		stmt.rows.closeRows()
+		stmt.rows = nil
+	}
+
+	defer stmt.resetParamArrays()
+	var batchErr error
+	for start := 0; start < len(rows); start += maxBatchRows {
+		end := start + maxBatchRows
+		if end > len(rows) {
+			end = len(rows)
+		}
+		rowsAffected, err := stmt.execParamArrays(ctx, rows[start:end], start, res.Status[start:end])
+		res.Messages = append(res.Messages, stmt.messages...)
+		if err != nil {
+			if ctx.Err() != nil {
+				return res, ctx.Err()
+			}
+			if batchErr == nil {
+				batchErr = fmt.Errorf("Batch rows %v to %v: %w", start, end-1, err)
+			}
+		}
+		res.RowsAffected += rowsAffected
+	}
+	return res, batchErr
+}
+
+// Binds rows as parameter arrays and executes them, filling status with the outcome of each row.
+// Start is the index of the first row in the batch, used to number rows in errors.
+func (stmt *statement) execParamArrays(ctx context.Context, rows [][]interface{}, start int, status []BatchRowStatus) (int64, error) {
+	//Do not start executing if the context is already done
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	//Bind the columns
+	columns := make([]*batchColumn, len(rows[0]))
+	for index := range columns {
+		column, err := stmt.newBatchColumn(rows, start, index)
+		if err != nil {
+			return 0, err
+		}
+		columns[index] = column
+		err = stmt.bindParamArray(index+1, column)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	//Have the driver report the outcome of each row
+	paramStatus := make([]odbc.SQLUSMALLINT, len(rows))
+	for index := range paramStatus {
+		paramStatus[index] = odbc.SQL_PARAM_UNUSED
+	}
+	var processed odbc.SQLULEN
+	attrs := []struct {
+		attribute odbc.SQLINTEGER
+		value     odbc.SQLPOINTER
+		length    odbc.SQLINTEGER
+	}{
+		{odbc.SQL_ATTR_PARAM_BIND_TYPE, odbc.SQL_PARAM_BIND_BY_COLUMN, odbc.SQL_IS_UINTEGER},
+		{odbc.SQL_ATTR_PARAMSET_SIZE, odbc.SQLPOINTER(len(rows)), odbc.SQL_IS_UINTEGER},
+		{odbc.SQL_ATTR_PARAM_STATUS_PTR, odbc.SQLPOINTER(unsafe.Pointer(&paramStatus[0])), odbc.SQL_IS_POINTER},
+		{odbc.SQL_ATTR_PARAMS_PROCESSED_PTR, odbc.SQLPOINTER(unsafe.Pointer(&processed)), odbc.SQL_IS_POINTER},
+	}
+	for _, attr := range attrs {
+		ret := stmt.api.SQLSetStmtAttr(stmt.handle, attr.attribute, attr.value, attr.length)
+		if isError(ret) {
+			return 0, errorStatement(stmt.api, stmt.handle, stmt.sqlStmt)
+		}
+	}
+
+	//Set the query timeout for the context deadline
+	err := stmt.setQueryTimeout(contextQueryTimeout(ctx, stmt.conn.queryTimeout))
+	if err != nil {
+		return 0, err
+	}
+
+	//Execute, watching for the context to be cancelled
+	stopWatch := watchContext(ctx, stmt.api, stmt.handle)
+	stmt.messages = nil
+	ret := stmt.api.SQLExecute(stmt.handle)
+	cancelled := stopWatch()
+	stmt.collectMessages(ret)
+
+	//Record the outcome of each row
+	for index, rowStatus := range paramStatus {
+		switch rowStatus {
+		case odbc.SQL_PARAM_SUCCESS:
+			status[index] = BatchRowSuccess
+		case odbc.SQL_PARAM_SUCCESS_WITH_INFO:
+			status[index] = BatchRowSuccessWithInfo
+		case odbc.SQL_PARAM_ERROR:
+			status[index] = BatchRowError
+		default:
+			status[index] = BatchRowUnused
+		}
+	}
+	runtime.KeepAlive(columns)
+
+	if isError(ret) {
+		if cancelled || ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		err = errorStatement(stmt.api, stmt.handle, fmt.Sprintf("SQL Stmt: %v", stmt.sqlStmt))
+		stmt.api.SQLFreeStmt(stmt.handle, odbc.SQL_CLOSE)
+		return 0, err
+	}
+
+	//Some rows may have failed even though the execute succeeded
+	err = nil
+	for index, rowStatus := range status {
+		if rowStatus == BatchRowError {
+			err = errorStatement(stmt.api, stmt.handle, fmt.Sprintf("SQL Stmt: %v\nRow: %v", stmt.sqlStmt, start+index))
+			break
+		}
+	}
+	rowsAffected, rowsErr := stmt.rowsAffected()
+	if rowsErr != nil && err == nil {
+		err = rowsErr
+	}
+	return rowsAffected, err
+}
+
+// Unbinds the parameter arrays and returns the statement to executing a single set of parameters
+func (stmt *statement) resetParamArrays() {
+	stmt.api.SQLSetStmtAttr(stmt.handle, odbc.SQL_ATTR_PARAMSET_SIZE, 1, odbc.SQL_IS_UINTEGER)
+	stmt.api.SQLSetStmtAttr(stmt.handle, odbc.SQL_ATTR_PARAM_STATUS_PTR, 0, odbc.SQL_IS_POINTER)
+	stmt.api.SQLSetStmtAttr(stmt.handle, odbc.SQL_ATTR_PARAMS_PROCESSED_PTR, 0, odbc.SQL_IS_POINTER)
+	stmt.api.SQLFreeStmt(stmt.handle, odbc.SQL_RESET_PARAMS)
+	stmt.bindValues = nil
+}
+
+// Binds a parameter array with SQLBindParameter
+func (stmt *statement) bindParamArray(index int, column *batchColumn) error {
+	dataPtr := unsafe.Pointer(&column.data[0])
+	ret := stmt.api.SQLBindParameter(stmt.handle, odbc.SQLUSMALLINT(index), odbc.SQL_PARAM_INPUT, column.cType, column.sqlType, odbc.SQLULEN(column.columnSize), odbc.SQLSMALLINT(column.decimalDigits), dataPtr, odbc.SQLLEN(column.elementSize), &column.ind[0])
+	if isError(ret) {
+		return errorStatement(stmt.api, stmt.handle, fmt.Sprintf("Bind index: %v, batch column", index))
+	}
+
+	if column.cType == odbc.SQL_C_NUMERIC {
+		return setNumericDescriptor(stmt.api, stmt.stmtDescHandle, index, column.columnSize, column.decimalDigits, dataPtr, fmt.Sprintf("Bind index: %v, batch column", index))
+	}
+	return nil
+}
+
+// Converts a batch value to a BindParameter, unwrapping driver.Valuer and pointers
+func (stmt *statement) batchParameter(value interface{}) (BindParameter, error) {
+	switch v := value.(type) {
+	case BindParameter:
+		//Bind an int as a BIGINT, like int arguments, so the values of a column are encoded alike
+		if data, ok := v.Data.(int); ok {
+			if entry, _ := parameterEncoderFor(data); entry != nil && entry.isBuiltin {
+				v.Data = int64(data)
+			}
+		}
+		return v, nil
+	case *big.Rat, *big.Int:
+		return BindParameter{Data: v}, nil
+	}
+	if entry, _ := parameterEncoderFor(value); entry != nil && !entry.isBuiltin {
+		return BindParameter{Data: value}, nil
+	}
+	converted, err := driver.DefaultParameterConverter.ConvertValue(value)
+	if err != nil {
+		return BindParameter{}, err
+	}
+	parameters, err := stmt.convertToBindParameters([]driver.Value{converted})
+	if err != nil {
+		return BindParameter{}, err
+	}
+	parameter := parameters[0]
+	if !isNil(parameter.Data) {
+		parameter.Data = reflect.Indirect(reflect.ValueOf(parameter.Data)).Interface()
+	}
+	return parameter, nil
+}
+
+// Builds the parameter array for column index of rows.  Every value is converted by the ParameterEncoder
+// registered for its type, built-in or not, and the array is laid out for the widest of them.
+// Start is the index of the first of rows in the batch.
+func (stmt *statement) newBatchColumn(rows [][]interface{}, start int, index int) (*batchColumn, error) {
+	//Convert the values, checking they all have the same type
+	parameters := make([]BindParameter, len(rows))
+	var kind reflect.Type
+	for row := range rows {
+		parameter, err := stmt.batchParameter(rows[row][index])
+		if err != nil {
+			return nil, fmt.Errorf("Batch row %v, parameter number %v: %v", start+row, index+1, err)
+		}
+		parameters[row] = parameter
+		if isNil(parameter.Data) {
+			continue
+		}
+		if kind == nil {
+			kind = reflect.TypeOf(parameter.Data)
+		} else if reflect.TypeOf(parameter.Data) != kind {
+			return nil, fmt.Errorf("Batch row %v, parameter number %v: %T does not match the column's %v", start+row, index+1, parameter.Data, kind)
+		}
+	}
+
+	//A column of nil values is bound as NULL text
+	if kind == nil {
+		column := &batchColumn{cType: odbc.SQL_C_WCHAR, sqlType: odbc.SQL_WCHAR, columnSize: 1, elementSize: 2}
+		return column, column.fill(parameters, nil)
+	}
+
+	//Encode the values, and again with the largest length, precision and scale of the column if they were encoded differently
+	encoded, err := stmt.encodeBatchValues(parameters, start, index)
+	if err != nil {
+		return nil, err
+	}
+	if widened, ok := widenBatchParameters(parameters, encoded); ok {
+		encoded, err = stmt.encodeBatchValues(widened, start, index)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	//Size the array for the largest value -- every value must have the C and SQL types of the first
+	var column *batchColumn
+	for row, value := range encoded {
+		if isNil(parameters[row].Data) {
+			continue
+		}
+		if column == nil {
+			column = &batchColumn{cType: value.CType, sqlType: value.SQLType, decimalDigits: value.DecimalDigits, elementSize: 1}
+		} else if value.CType != column.cType || value.SQLType != column.sqlType {
+			return nil, fmt.Errorf("Batch row %v, parameter number %v: C type %v and SQL type %v do not match the column's %v and %v", start+row, index+1, value.CType, value.SQLType, column.cType, column.sqlType)
+		}
+		if value.ColumnSize > column.columnSize {
+			column.columnSize = value.ColumnSize
+		}
+		if len(value.Data) > column.elementSize {
+			column.elementSize = len(value.Data)
+		}
+	}
+	err = column.fill(parameters, func(p unsafe.Pointer, row int, parameter BindParameter) (int, error) {
+		return copy(unsafe.Slice((*byte)(p), column.elementSize), encoded[row].Data), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Batch parameter number %v: %v", index+1, err)
+	}
+	return column, nil
+}
+
+// Allocates the column's buffers and writes each value with put, or SQL_NULL_DATA for nil values
+func (column *batchColumn) fill(parameters []BindParameter, put func(p unsafe.Pointer, row int, parameter BindParameter) (int, error)) error {
+	column.data = alignedBuffer(column.elementSize * len(parameters))
+	column.ind = make([]odbc.SQLLEN, len(parameters))
+	for row, parameter := range parameters {
+		if isNil(parameter.Data) || put == nil {
+			column.ind[row] = odbc.SQL_NULL_DATA
+			continue
+		}
+		length, err := put(unsafe.Pointer(&column.data[row*column.elementSize]), row, parameter)
+		if err != nil {
+			return fmt.Errorf("Row %v: %v", row, err)
+		}
+		column.ind[row] = odbc.SQLLEN(length)
+	}
+	return nil
+}
+
+// Converts each value that is not nil with the ParameterEncoder registered for its type
+func (stmt *statement) encodeBatchValues(parameters []BindParameter, start int, index int) ([]EncodedParameter, error) {
+	encoded := make([]EncodedParameter, len(parameters))
+	for row, parameter := range parameters {
+		if isNil(parameter.Data) {
+			continue
+		}
+		var entry *parameterEncoderEntry
+		entry, parameter.Data = parameterEncoderFor(parameter.Data)
+		if entry == nil {
+			return nil, fmt.Errorf("Error binding parameter number: %v.  Parameter type not supported: %T", index+1, parameter.Data)
+		}
+		value, err := entry.encode(parameter, stmt.conn.convertContext())
+		if err != nil {
+			return nil, fmt.Errorf("Batch row %v, parameter number %v: %v", start+row, index+1, err)
+		}
+		encoded[row] = value
+	}
+	return encoded, nil
+}
+
+// Returns the parameters with the largest length of the column, and for SQL_C_NUMERIC the precision and scale
+// that hold every value, if the values were encoded with different SQL types or SQL_C_NUMERIC shapes.
+// One array has one SQL type, and one precision and scale set on the descriptor.
+func widenBatchParameters(parameters []BindParameter, encoded []EncodedParameter) ([]BindParameter, bool) {
+	var first *EncodedParameter
+	isMixed := false
+	length, integerDigits, scale := 0, 0, 0
+	for row := range encoded {
+		if isNil(parameters[row].Data) {
+			continue
+		}
+		value := &encoded[row]
+		if first == nil {
+			first = value
+		}
+		if value.SQLType != first.SQLType || (value.CType == odbc.SQL_C_NUMERIC && (value.ColumnSize != first.ColumnSize || value.DecimalDigits != first.DecimalDigits)) {
+			isMixed = true
+		}
+		if value.ColumnSize > length {
+			length = value.ColumnSize
+		}
+		if value.ColumnSize-value.DecimalDigits > integerDigits {
+			integerDigits = value.ColumnSize - value.DecimalDigits
+		}
+		if value.DecimalDigits > scale {
+			scale = value.DecimalDigits
+		}
+	}
+	if !isMixed {
+		return nil, false
+	}
+
+	widened := make([]BindParameter, len(parameters))
+	for row, parameter := range parameters {
+		if parameter.Length < length {
+			parameter.Length = length
+		}
+		if encoded[row].CType == odbc.SQL_C_NUMERIC {
+			parameter.Precision, parameter.Scale = integerDigits+scale, scale
+		}
+		widened[row] = parameter
+	}
+	return widened, true
+}
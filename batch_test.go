@@ -0,0 +1,140 @@
+package lodbc_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/LukeMauldin/lodbc"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"github.com/LukeMauldin/lodbc/odbc/fake"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Flag bound as a Y or N character by encodeYesNo, so registering it leaves the encoders of other tests alone
+type yesNo bool
+
+func encodeYesNo(parameter lodbc.BindParameter, ctx lodbc.ConvertContext) (lodbc.EncodedParameter, error) {
+	data := []byte("N")
+	if parameter.Data.(yesNo) {
+		data = []byte("Y")
+	}
+	return lodbc.EncodedParameter{CType: odbc.SQL_C_CHAR, SQLType: odbc.SQL_CHAR, ColumnSize: 1, Data: data}, nil
+}
+
+func TestExecBatchEncodesEveryColumn(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("insert into t values (?, ?, ?, ?, ?)", &fake.Response{ResultSets: []*fake.ResultSet{{RowsAffected: 1}}})
+	db := openDB(t, dm, nil)
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	//Values of one column encoded with different lengths, SQL types and decimal shapes share one array
+	long := strings.Repeat("x", 5000)
+	rows := [][]interface{}{
+		{"short", []byte{1}, big.NewRat(3, 2), lodbc.BindParameter{Data: 1}, true},
+		{long, bytes.Repeat([]byte{2}, 5000), big.NewRat(12325, 100), lodbc.BindParameter{Data: 1 << 40}, nil},
+		{nil, nil, nil, nil, false},
+	}
+	res, err := lodbc.ExecBatch(context.Background(), conn, "insert into t values (?, ?, ?, ?, ?)", rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Failed()) != 0 {
+		t.Fatalf("rows %v failed", res.Failed())
+	}
+	executions := dm.Executions()
+	if len(executions) != 3 {
+		t.Fatalf("%v executions", len(executions))
+	}
+	first, second := executions[0].Params, executions[1].Params
+	if first[0] != "short" || second[0] != long || !bytes.Equal(first[1].([]byte), []byte{1}) || len(second[1].([]byte)) != 5000 {
+		t.Errorf("text and binary parameters %.20v and %.20v", first, second)
+	}
+	if first[2].(*big.Rat).Cmp(big.NewRat(3, 2)) != 0 || second[2].(*big.Rat).Cmp(big.NewRat(12325, 100)) != 0 {
+		t.Errorf("decimal parameters %v and %v", first[2], second[2])
+	}
+	if first[3] != int64(1) || second[3] != int64(1<<40) || first[4] != true || executions[2].Params[4] != false {
+		t.Errorf("integer and bit parameters %.20v and %.20v", first, second)
+	}
+
+	//A registered encoder converts its type in batches too
+	lodbc.RegisterParameterEncoder(reflect.TypeOf(yesNo(false)), encodeYesNo)
+	for _, row := range rows {
+		if flag, ok := row[4].(bool); ok {
+			row[4] = yesNo(flag)
+		}
+	}
+	_, err = lodbc.ExecBatch(context.Background(), conn, "insert into t values (?, ?, ?, ?, ?)", rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	executions = dm.Executions()[3:]
+	if executions[0].Params[4] != "Y" || executions[1].Params[4] != nil || executions[2].Params[4] != "N" {
+		t.Errorf("encoded flag parameters %v, %v and %v", executions[0].Params[4], executions[1].Params[4], executions[2].Params[4])
+	}
+}
+
+// Rows of a batch large enough to be executed in three chunks
+func batchRows() [][]interface{} {
+	rows := make([][]interface{}, 2500)
+	for index := range rows {
+		rows[index] = []interface{}{int64(index)}
+	}
+	return rows
+}
+
+func TestExecBatchReportsTheRowsOfEachChunk(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("insert into t values (?)", &fake.Response{ResultSets: []*fake.ResultSet{{RowsAffected: 1}}})
+	db := openDB(t, dm, nil)
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	//A value that cannot be bound fails its chunk before SQLExecute, leaving its rows unused
+	rows := batchRows()
+	rows[1500][0] = "text"
+	res, err := lodbc.ExecBatch(context.Background(), conn, "insert into t values (?)", rows)
+	if err == nil || !strings.Contains(err.Error(), "Batch row 1500,") {
+		t.Errorf("got %v, want an error for batch row 1500", err)
+	}
+	for index, status := range res.Status {
+		want := lodbc.BatchRowSuccess
+		if index >= 1000 && index < 2000 {
+			want = lodbc.BatchRowUnused
+		}
+		if status != want {
+			t.Fatalf("row %v has status %v, want %v", index, status, want)
+		}
+	}
+	if res.RowsAffected != 1500 || len(res.Failed()) != 0 {
+		t.Errorf("%v rows affected and rows %v failed", res.RowsAffected, res.Failed())
+	}
+
+	//Cancelling stops the batch, leaving the chunks after the cancelled one unused
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dm.HandleQuery("insert into t values (?)", func(e *fake.Execution) *fake.Response {
+		if e.Params[0] == int64(1000) {
+			cancel()
+		}
+		return &fake.Response{ResultSets: []*fake.ResultSet{{RowsAffected: 1}}}
+	})
+	res, err = lodbc.ExecBatch(ctx, conn, "insert into t values (?)", batchRows())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	for index, status := range res.Status {
+		if index < 1000 && status != lodbc.BatchRowSuccess || index >= 2000 && status != lodbc.BatchRowUnused {
+			t.Fatalf("row %v has status %v", index, status)
+		}
+	}
+}
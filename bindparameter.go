@@ -5,13 +5,16 @@ import (
 	"database/sql/driver"
 	"encoding/gob"
 	"github.com/LukeMauldin/lodbc/odbc"
+	"math/big"
 	"time"
 )
 
-// Registers the time.Time struct with the gob package
+// Registers the time.Time struct and exact decimal types with the gob package
 func init() {
 	tp := new(time.Time)
 	gob.Register(tp)
+	gob.Register(new(big.Rat))
+	gob.Register(new(big.Int))
 }
 
 // Struct to hold additional metadata for bind parameters for use in
@@ -22,20 +25,37 @@ type BindParameter struct {
 	// Contains the bind parameter value
 	Data driver.Value
 
-	// Valid for strings only.  Specifies the maximum length of the string.
-	// If 0, defaults to the length of the string in Data
+	// Valid for strings and []byte.  Specifies the maximum length of the string in characters, or of the []byte in bytes.
+	// If 0, defaults to the length of the value in Data.  For output parameters, the size of the
+	// buffer in characters for strings or bytes for []byte -- 0 defaults to 4000 characters or 8000 bytes.
 	Length int
 
-	// Valid for float64 only
+	// Valid for float64, *big.Rat, *big.Int and decimal strings.  When set, the value is
+	// bound exactly as SQL_NUMERIC with this precision.  If 0 for *big.Rat or *big.Int,
+	// defaults to the digits needed by the value.
 	Precision int
 
-	// Valid for float64 only
+	// Valid for float64, *big.Rat, *big.Int and decimal strings.  Digits after the decimal point.
 	Scale int
 
-	// Valid for time.Time only
+	// Valid for time.Time only.  Binds the calendar date as a DATE.
 	DateOnly bool
 
-	// Specifies the direction of the ODBC parameter.  Defaults to InputParameter
+	// Valid for time.Time only.  Binds the clock time as a TIME, without fractional seconds.
+	TimeOnly bool
+
+	// Valid for time.Time with TimeOnly.  Binds the clock time with its fractional seconds as a SQL Server TIME,
+	// which SQL Server requires to keep them.
+	WithFraction bool
+
+	// Valid for time.Time only.  Binds the value with its offset from UTC as a SQL Server DATETIMEOFFSET,
+	// instead of converting it to the connection location for a TIMESTAMP.
+	WithOffset bool
+
+	// Specifies the direction of the ODBC parameter.  Defaults to InputParameter.
+	// To read an output parameter, pass sql.Out{Dest: &x}, or sql.Out{Dest: &x, In: true} for
+	// input/output.  For sizes or a return value, make Dest a *BindParameter whose Data is the
+	// destination pointer, such as sql.Out{Dest: &BindParameter{Data: &rc, Direction: ReturnValueParameter}}.
 	Direction ParameterDirection
 }
 
@@ -59,32 +79,26 @@ func (bp BindParameter) Value() (driver.Value, error) {
 }
 
 // Indicates direction of ODBC parameter. Maps to an ODBC parameter direction.
-// Currently the only supported value is input parameter
+// Output, input/output and return value parameters are passed as sql.Out -- see BindParameter.Direction.
 type ParameterDirection int
 
 const (
 	InputParameter ParameterDirection = 1 << iota
-	// OutputParameter - not suported
-	//InputOutputParameter - not suported
+	OutputParameter
+	InputOutputParameter
+	ReturnValueParameter
 )
 
-/*
- * Converts ParameterDirection to an ODBC parameter direction
- * Currently the only supported value is SQL_PARAM_INPUT
- */
+// Converts ParameterDirection to an ODBC parameter direction -- the zero value is an input parameter.
+// SQLBindParameter has no return value direction, so the return value marker is bound as an output parameter.
 func (p ParameterDirection) SQLBindParameterType() odbc.SQLSMALLINT {
+	switch p {
+	case OutputParameter, ReturnValueParameter:
+		return odbc.SQL_PARAM_OUTPUT
+	case InputOutputParameter:
+		return odbc.SQL_PARAM_INPUT_OUTPUT
+	}
 	return odbc.SQL_PARAM_INPUT
-	/*
-		switch p {
-		case InputParameter:
-			return odbc.SQL_PARAM_INPUT
-		case OutputParameter:
-			return odbc.SQL_PARAM_OUTPUT
-		case InputOutputParameter:
-			return odbc.SQL_PARAM_INPUT_OUTPUT
-		}
-		panic("Parameter direction: " + strconv.Itoa(int(p)))
-	*/
 }
 
 // Create a new bind parameter for an int
@@ -112,6 +126,26 @@ func NewParameterDateTime(data driver.Value) *BindParameter {
 	return &BindParameter{Data: data, DateOnly: false}
 }
 
+// Create a new bind parameter for a time of day
+func NewParameterTime(data driver.Value) *BindParameter {
+	return &BindParameter{Data: data, TimeOnly: true}
+}
+
+// Create a new bind parameter for a time of day with fractional seconds on SQL Server
+func NewParameterTimeWithFraction(data driver.Value) *BindParameter {
+	return &BindParameter{Data: data, TimeOnly: true, WithFraction: true}
+}
+
+// Create a new bind parameter for a date time with its offset from UTC
+func NewParameterDateTimeOffset(data driver.Value) *BindParameter {
+	return &BindParameter{Data: data, WithOffset: true}
+}
+
+// Create a new bind parameter for an exact decimal -- data may be a *big.Rat, *big.Int or decimal string
+func NewParameterDecimal(data driver.Value, precision int, scale int) *BindParameter {
+	return &BindParameter{Data: data, Precision: precision, Scale: scale}
+}
+
 // Create a new bind parameter for a string
 func NewParameterString(data driver.Value, length int) *BindParameter {
 	return &BindParameter{Data: data, Length: length}
@@ -0,0 +1,550 @@
+package lodbc
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"io"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// Reads the tables, columns, keys and procedures of a database with the ODBC catalog functions.
+// Empty filter fields match everything; the other name fields are search patterns where the
+// driver supports them, with '%' matching any characters and '_' matching one.
+type CatalogReader struct {
+	conn *sql.Conn
+}
+
+// Returns a CatalogReader for conn, which must be a lodbc connection
+func Catalog(conn *sql.Conn) *CatalogReader {
+	return &CatalogReader{conn: conn}
+}
+
+// Filter for Tables
+type TableFilter struct {
+	Catalog string
+	Schema  string
+	Table   string
+
+	// Table types to return, such as "TABLE" and "VIEW" -- empty returns every type
+	Types []string
+}
+
+// Filter for Columns
+type ColumnFilter struct {
+	Catalog string
+	Schema  string
+	Table   string
+	Column  string
+}
+
+// Filter for Procedures
+type ProcedureFilter struct {
+	Catalog   string
+	Schema    string
+	Procedure string
+}
+
+// Filter for ProcedureColumns
+type ProcedureColumnFilter struct {
+	Catalog   string
+	Schema    string
+	Procedure string
+	Column    string
+}
+
+// Table named exactly, without search patterns
+type TableName struct {
+	Catalog string
+	Schema  string
+	Table   string
+}
+
+// Row of SQLTables
+type Table struct {
+	Catalog string
+	Schema  string
+	Name    string
+	Type    string
+	Remarks string
+}
+
+// Row of SQLColumns
+type TableColumn struct {
+	Catalog       string
+	Schema        string
+	Table         string
+	Name          string
+	DataType      odbc.SQLDataType
+	TypeName      string
+	ColumnSize    int64
+	DecimalDigits int64
+	Nullable      sql.NullBool
+	Remarks       string
+	Default       sql.NullString
+
+	// Position of the column in the table, starting at 1
+	OrdinalPosition int64
+}
+
+// Row of SQLPrimaryKeys
+type PrimaryKey struct {
+	Catalog string
+	Schema  string
+	Table   string
+	Column  string
+
+	// Position of the column in the key, starting at 1
+	KeySequence int64
+	Name        string
+}
+
+// Row of SQLForeignKeys, one for each column of a foreign key
+type ForeignKey struct {
+	PKCatalog string
+	PKSchema  string
+	PKTable   string
+	PKColumn  string
+	FKCatalog string
+	FKSchema  string
+	FKTable   string
+	FKColumn  string
+
+	// Position of the column in the key, starting at 1
+	KeySequence int64
+
+	// Referential actions, such as SQL_CASCADE (0) and SQL_NO_ACTION (3)
+	UpdateRule int64
+	DeleteRule int64
+
+	Name   string
+	PKName string
+}
+
+// Row of SQLStatistics, one for each column of an index
+type IndexColumn struct {
+	Catalog   string
+	Schema    string
+	Table     string
+	NonUnique bool
+	Index     string
+
+	// Position of the column in the index, starting at 1
+	OrdinalPosition int64
+	Column          string
+
+	// "A" for ascending, "D" for descending, empty if the driver does not say
+	SortOrder string
+}
+
+// Row of SQLProcedures
+type Procedure struct {
+	Catalog string
+	Schema  string
+	Name    string
+	Remarks string
+
+	// SQL_PT_PROCEDURE (1), SQL_PT_FUNCTION (2) or SQL_PT_UNKNOWN (0)
+	Type int64
+}
+
+// Row of SQLProcedureColumns, one for each parameter and result column of a procedure
+type ProcedureColumn struct {
+	Catalog   string
+	Schema    string
+	Procedure string
+	Name      string
+
+	// SQL_PARAM_INPUT (1), SQL_PARAM_INPUT_OUTPUT (2), SQL_RESULT_COL (3), SQL_PARAM_OUTPUT (4) or SQL_RETURN_VALUE (5)
+	ColumnType    int64
+	DataType      odbc.SQLDataType
+	TypeName      string
+	ColumnSize    int64
+	DecimalDigits int64
+	Nullable      sql.NullBool
+	Remarks       string
+}
+
+// Kind of columns returned by SpecialColumns
+type SpecialColumnKind int
+
+const (
+	// Columns that identify a row uniquely
+	BestRowID SpecialColumnKind = iota
+
+	// Columns updated whenever the row is updated, such as a row version
+	RowVersion
+)
+
+// Row of SQLSpecialColumns
+type SpecialColumn struct {
+	Name          string
+	DataType      odbc.SQLDataType
+	TypeName      string
+	ColumnSize    int64
+	DecimalDigits int64
+
+	// Whether the column is a pseudo column such as Oracle's ROWID
+	Pseudo bool
+}
+
+// Returns the tables matching filter
+func (cat *CatalogReader) Tables(ctx context.Context, filter TableFilter) ([]Table, error) {
+	types := strings.Join(filter.Types, ",")
+	values, err := cat.query(ctx, "SQLTables", func(api odbc.API, handle odbc.SQLHandle) odbc.SQLReturn {
+		catalogPtr, catalogLen := catalogArg(filter.Catalog)
+		schemaPtr, schemaLen := catalogArg(filter.Schema)
+		tablePtr, tableLen := catalogArg(filter.Table)
+		typesPtr, typesLen := catalogArg(types)
+		return api.SQLTables(handle, catalogPtr, catalogLen, schemaPtr, schemaLen, tablePtr, tableLen, typesPtr, typesLen)
+	})
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]Table, len(values))
+	for i, row := range values {
+		tables[i] = Table{
+			Catalog: catalogString(row, 0),
+			Schema:  catalogString(row, 1),
+			Name:    catalogString(row, 2),
+			Type:    catalogString(row, 3),
+			Remarks: catalogString(row, 4),
+		}
+	}
+	return tables, nil
+}
+
+// Returns the columns matching filter, ordered by table and position
+func (cat *CatalogReader) Columns(ctx context.Context, filter ColumnFilter) ([]TableColumn, error) {
+	values, err := cat.query(ctx, "SQLColumns", func(api odbc.API, handle odbc.SQLHandle) odbc.SQLReturn {
+		catalogPtr, catalogLen := catalogArg(filter.Catalog)
+		schemaPtr, schemaLen := catalogArg(filter.Schema)
+		tablePtr, tableLen := catalogArg(filter.Table)
+		columnPtr, columnLen := catalogArg(filter.Column)
+		return api.SQLColumns(handle, catalogPtr, catalogLen, schemaPtr, schemaLen, tablePtr, tableLen, columnPtr, columnLen)
+	})
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]TableColumn, len(values))
+	for i, row := range values {
+		columns[i] = TableColumn{
+			Catalog:         catalogString(row, 0),
+			Schema:          catalogString(row, 1),
+			Table:           catalogString(row, 2),
+			Name:            catalogString(row, 3),
+			DataType:        odbc.SQLDataType(catalogInt(row, 4)),
+			TypeName:        catalogString(row, 5),
+			ColumnSize:      catalogInt(row, 6),
+			DecimalDigits:   catalogInt(row, 8),
+			Nullable:        catalogNullable(row, 10),
+			Remarks:         catalogString(row, 11),
+			Default:         catalogNullString(row, 12),
+			OrdinalPosition: catalogInt(row, 16),
+		}
+	}
+	return columns, nil
+}
+
+// Returns the columns of the primary key of table, ordered by their position in the key
+func (cat *CatalogReader) PrimaryKeys(ctx context.Context, table TableName) ([]PrimaryKey, error) {
+	values, err := cat.query(ctx, "SQLPrimaryKeys", func(api odbc.API, handle odbc.SQLHandle) odbc.SQLReturn {
+		catalogPtr, catalogLen := catalogArg(table.Catalog)
+		schemaPtr, schemaLen := catalogArg(table.Schema)
+		tablePtr, tableLen := catalogArg(table.Table)
+		return api.SQLPrimaryKeys(handle, catalogPtr, catalogLen, schemaPtr, schemaLen, tablePtr, tableLen)
+	})
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]PrimaryKey, len(values))
+	for i, row := range values {
+		keys[i] = PrimaryKey{
+			Catalog:     catalogString(row, 0),
+			Schema:      catalogString(row, 1),
+			Table:       catalogString(row, 2),
+			Column:      catalogString(row, 3),
+			KeySequence: catalogInt(row, 4),
+			Name:        catalogString(row, 5),
+		}
+	}
+	return keys, nil
+}
+
+// Returns the foreign keys between two tables.  With only pkTable set, returns the foreign keys
+// referencing it; with only fkTable set, returns the foreign keys of fkTable.
+func (cat *CatalogReader) ForeignKeys(ctx context.Context, pkTable TableName, fkTable TableName) ([]ForeignKey, error) {
+	if len(pkTable.Table) == 0 && len(fkTable.Table) == 0 {
+		return nil, fmt.Errorf("ForeignKeys requires the primary key table or the foreign key table")
+	}
+	values, err := cat.query(ctx, "SQLForeignKeys", func(api odbc.API, handle odbc.SQLHandle) odbc.SQLReturn {
+		pkCatalogPtr, pkCatalogLen := catalogArg(pkTable.Catalog)
+		pkSchemaPtr, pkSchemaLen := catalogArg(pkTable.Schema)
+		pkTablePtr, pkTableLen := catalogArg(pkTable.Table)
+		fkCatalogPtr, fkCatalogLen := catalogArg(fkTable.Catalog)
+		fkSchemaPtr, fkSchemaLen := catalogArg(fkTable.Schema)
+		fkTablePtr, fkTableLen := catalogArg(fkTable.Table)
+		return api.SQLForeignKeys(handle, pkCatalogPtr, pkCatalogLen, pkSchemaPtr, pkSchemaLen, pkTablePtr, pkTableLen,
+			fkCatalogPtr, fkCatalogLen, fkSchemaPtr, fkSchemaLen, fkTablePtr, fkTableLen)
+	})
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]ForeignKey, len(values))
+	for i, row := range values {
+		keys[i] = ForeignKey{
+			PKCatalog:   catalogString(row, 0),
+			PKSchema:    catalogString(row, 1),
+			PKTable:     catalogString(row, 2),
+			PKColumn:    catalogString(row, 3),
+			FKCatalog:   catalogString(row, 4),
+			FKSchema:    catalogString(row, 5),
+			FKTable:     catalogString(row, 6),
+			FKColumn:    catalogString(row, 7),
+			KeySequence: catalogInt(row, 8),
+			UpdateRule:  catalogInt(row, 9),
+			DeleteRule:  catalogInt(row, 10),
+			Name:        catalogString(row, 11),
+			PKName:      catalogString(row, 12),
+		}
+	}
+	return keys, nil
+}
+
+// Returns the columns of the indexes of table, or of its unique indexes if uniqueOnly is true.
+// The table statistics rows SQLStatistics also returns, with a NULL NON_UNIQUE, are skipped.
+func (cat *CatalogReader) Statistics(ctx context.Context, table TableName, uniqueOnly bool) ([]IndexColumn, error) {
+	unique := odbc.SQL_INDEX_ALL
+	if uniqueOnly {
+		unique = odbc.SQL_INDEX_UNIQUE
+	}
+	values, err := cat.query(ctx, "SQLStatistics", func(api odbc.API, handle odbc.SQLHandle) odbc.SQLReturn {
+		catalogPtr, catalogLen := catalogArg(table.Catalog)
+		schemaPtr, schemaLen := catalogArg(table.Schema)
+		tablePtr, tableLen := catalogArg(table.Table)
+		return api.SQLStatistics(handle, catalogPtr, catalogLen, schemaPtr, schemaLen, tablePtr, tableLen, unique, odbc.SQL_QUICK)
+	})
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]IndexColumn, 0, len(values))
+	for _, row := range values {
+		//TYPE is SQL_TABLE_STAT (0) and NON_UNIQUE is NULL for the row describing the table itself
+		if catalogInt(row, 6) == 0 || row[3] == nil {
+			continue
+		}
+		columns = append(columns, IndexColumn{
+			Catalog:         catalogString(row, 0),
+			Schema:          catalogString(row, 1),
+			Table:           catalogString(row, 2),
+			NonUnique:       catalogInt(row, 3) != 0,
+			Index:           catalogString(row, 5),
+			OrdinalPosition: catalogInt(row, 7),
+			Column:          catalogString(row, 8),
+			SortOrder:       catalogString(row, 9),
+		})
+	}
+	return columns, nil
+}
+
+// Returns the procedures and functions matching filter
+func (cat *CatalogReader) Procedures(ctx context.Context, filter ProcedureFilter) ([]Procedure, error) {
+	values, err := cat.query(ctx, "SQLProcedures", func(api odbc.API, handle odbc.SQLHandle) odbc.SQLReturn {
+		catalogPtr, catalogLen := catalogArg(filter.Catalog)
+		schemaPtr, schemaLen := catalogArg(filter.Schema)
+		procPtr, procLen := catalogArg(filter.Procedure)
+		return api.SQLProcedures(handle, catalogPtr, catalogLen, schemaPtr, schemaLen, procPtr, procLen)
+	})
+	if err != nil {
+		return nil, err
+	}
+	procedures := make([]Procedure, len(values))
+	for i, row := range values {
+		procedures[i] = Procedure{
+			Catalog: catalogString(row, 0),
+			Schema:  catalogString(row, 1),
+			Name:    catalogString(row, 2),
+			Remarks: catalogString(row, 6),
+			Type:    catalogInt(row, 7),
+		}
+	}
+	return procedures, nil
+}
+
+// Returns the parameters and result columns of the procedures matching filter
+func (cat *CatalogReader) ProcedureColumns(ctx context.Context, filter ProcedureColumnFilter) ([]ProcedureColumn, error) {
+	values, err := cat.query(ctx, "SQLProcedureColumns", func(api odbc.API, handle odbc.SQLHandle) odbc.SQLReturn {
+		catalogPtr, catalogLen := catalogArg(filter.Catalog)
+		schemaPtr, schemaLen := catalogArg(filter.Schema)
+		procPtr, procLen := catalogArg(filter.Procedure)
+		columnPtr, columnLen := catalogArg(filter.Column)
+		return api.SQLProcedureColumns(handle, catalogPtr, catalogLen, schemaPtr, schemaLen, procPtr, procLen, columnPtr, columnLen)
+	})
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]ProcedureColumn, len(values))
+	for i, row := range values {
+		columns[i] = ProcedureColumn{
+			Catalog:       catalogString(row, 0),
+			Schema:        catalogString(row, 1),
+			Procedure:     catalogString(row, 2),
+			Name:          catalogString(row, 3),
+			ColumnType:    catalogInt(row, 4),
+			DataType:      odbc.SQLDataType(catalogInt(row, 5)),
+			TypeName:      catalogString(row, 6),
+			ColumnSize:    catalogInt(row, 7),
+			DecimalDigits: catalogInt(row, 9),
+			Nullable:      catalogNullable(row, 11),
+			Remarks:       catalogString(row, 12),
+		}
+	}
+	return columns, nil
+}
+
+// Returns the columns that identify a row of table, or that change when it is updated,
+// valid for the whole session and including nullable columns
+func (cat *CatalogReader) SpecialColumns(ctx context.Context, table TableName, kind SpecialColumnKind) ([]SpecialColumn, error) {
+	identifierType := odbc.SQL_BEST_ROWID
+	if kind == RowVersion {
+		identifierType = odbc.SQL_ROWVER
+	}
+	values, err := cat.query(ctx, "SQLSpecialColumns", func(api odbc.API, handle odbc.SQLHandle) odbc.SQLReturn {
+		catalogPtr, catalogLen := catalogArg(table.Catalog)
+		schemaPtr, schemaLen := catalogArg(table.Schema)
+		tablePtr, tableLen := catalogArg(table.Table)
+		return api.SQLSpecialColumns(handle, identifierType, catalogPtr, catalogLen, schemaPtr, schemaLen, tablePtr, tableLen,
+			odbc.SQL_SCOPE_SESSION, odbc.SQLUSMALLINT(odbc.SQL_NULLABLE))
+	})
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]SpecialColumn, len(values))
+	for i, row := range values {
+		columns[i] = SpecialColumn{
+			Name:          catalogString(row, 1),
+			DataType:      odbc.SQLDataType(catalogInt(row, 2)),
+			TypeName:      catalogString(row, 3),
+			ColumnSize:    catalogInt(row, 4),
+			DecimalDigits: catalogInt(row, 6),
+			//PSEUDO_COLUMN is SQL_PC_PSEUDO (2) for pseudo columns
+			Pseudo: catalogInt(row, 7) == 2,
+		}
+	}
+	return columns, nil
+}
+
+// Runs a catalog function on the connection and reads every row of its result set
+func (cat *CatalogReader) query(ctx context.Context, function string, call func(api odbc.API, handle odbc.SQLHandle) odbc.SQLReturn) ([][]driver.Value, error) {
+	var values [][]driver.Value
+	err := cat.conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(*connection)
+		if !ok {
+			return fmt.Errorf("Catalog requires a lodbc connection, not %T", driverConn)
+		}
+		var err error
+		values, err = c.catalogQuery(ctx, function, call)
+		return err
+	})
+	return values, err
+}
+
+// Allocates a statement, runs the catalog function on it in place of a SQL statement and reads the rows
+func (c *connection) catalogQuery(ctx context.Context, function string, call func(api odbc.API, handle odbc.SQLHandle) odbc.SQLReturn) ([][]driver.Value, error) {
+	if c.bad() {
+		return nil, driver.ErrBadConn
+	}
+
+	stmt, err := c.newStatement(function)
+	if err != nil {
+		return nil, c.badConn(err)
+	}
+	defer stmt.Close()
+	stmt.catalogCall = func() odbc.SQLReturn {
+		return call(stmt.api, stmt.handle)
+	}
+
+	driverRows, err := stmt.query(ctx, nil)
+	if err != nil {
+		return nil, c.checkLink(err)
+	}
+	values := make([][]driver.Value, 0)
+	for {
+		row := make([]driver.Value, len(driverRows.Columns()))
+		err = driverRows.Next(row)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		values = append(values, row)
+	}
+	return values, nil
+}
+
+// Returns a catalog function argument, a null pointer for an empty string so it matches everything
+func catalogArg(s string) (*odbc.SQLCHAR, odbc.SQLSMALLINT) {
+	if len(s) == 0 {
+		return nil, 0
+	}
+	return (*odbc.SQLCHAR)(unsafe.Pointer(stringToUTF16Ptr(s))), odbc.SQL_NTS
+}
+
+// Returns column index of a catalog row as a string, empty for NULL or a missing column
+func catalogString(row []driver.Value, index int) string {
+	return catalogNullString(row, index).String
+}
+
+// Returns column index of a catalog row as a string, invalid for NULL or a missing column
+func catalogNullString(row []driver.Value, index int) sql.NullString {
+	if index >= len(row) || row[index] == nil {
+		return sql.NullString{}
+	}
+	switch value := row[index].(type) {
+	case string:
+		return sql.NullString{String: value, Valid: true}
+	case []byte:
+		return sql.NullString{String: string(value), Valid: true}
+	default:
+		return sql.NullString{String: fmt.Sprint(value), Valid: true}
+	}
+}
+
+// Returns column index of a catalog row as an integer, 0 for NULL or a missing column
+func catalogInt(row []driver.Value, index int) int64 {
+	if index >= len(row) {
+		return 0
+	}
+	switch value := row[index].(type) {
+	case int:
+		return int64(value)
+	case int32:
+		return int64(value)
+	case int64:
+		return value
+	case float64:
+		return int64(value)
+	case string:
+		n, _ := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		return n
+	}
+	return 0
+}
+
+// Returns a NULLABLE column of a catalog row, invalid when the driver does not know
+func catalogNullable(row []driver.Value, index int) sql.NullBool {
+	if index >= len(row) || row[index] == nil {
+		return sql.NullBool{}
+	}
+	switch catalogInt(row, index) {
+	case int64(odbc.SQL_NO_NULLS):
+		return sql.NullBool{Bool: false, Valid: true}
+	case int64(odbc.SQL_NULLABLE):
+		return sql.NullBool{Bool: true, Valid: true}
+	}
+	return sql.NullBool{}
+}
@@ -0,0 +1,197 @@
+package lodbc_test
+
+import (
+	"context"
+	"database/sql"
+	"github.com/LukeMauldin/lodbc"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"github.com/LukeMauldin/lodbc/odbc/fake"
+	"reflect"
+	"testing"
+)
+
+// Result set of a catalog function with the named columns, typed from the first value that is not nil
+func catalogResult(names []string, rows ...[]interface{}) *fake.Response {
+	rs := &fake.ResultSet{Rows: rows}
+	for index, name := range names {
+		column := fake.Column{Name: name, Type: odbc.SQL_WVARCHAR, Precision: 128, Nullable: true}
+		for _, row := range rows {
+			if _, ok := row[index].(int); ok {
+				column = fake.Column{Name: name, Type: odbc.SQL_SMALLINT, Nullable: true}
+				break
+			}
+		}
+		rs.Columns = append(rs.Columns, column)
+	}
+	return &fake.Response{ResultSets: []*fake.ResultSet{rs}}
+}
+
+// Opens a connection on dm for a CatalogReader
+func openCatalog(t *testing.T, dm *fake.DriverManager) *lodbc.CatalogReader {
+	t.Helper()
+	conn, err := openDB(t, dm, nil).Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return lodbc.Catalog(conn)
+}
+
+// Checks a catalog reader's result and the arguments of its call
+func checkCatalog(t *testing.T, dm *fake.DriverManager, function string, got interface{}, err error, want interface{}, args []interface{}) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%v: %v", function, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%v returned\n%+v\nwant\n%+v", function, got, want)
+	}
+	executions := dm.Executions()
+	if last := executions[len(executions)-1]; last.Query != function || !reflect.DeepEqual(last.Params, args) {
+		t.Errorf("%v called with %#v, want %#v", last.Query, last.Params, args)
+	}
+}
+
+func TestCatalogTables(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("SQLTables", catalogResult([]string{"TABLE_CAT", "TABLE_SCHEM", "TABLE_NAME", "TABLE_TYPE", "REMARKS"},
+		[]interface{}{"sales", "dbo", "orders", "TABLE", "Customer orders"},
+		[]interface{}{nil, nil, "totals", "VIEW", nil},
+	))
+	cat := openCatalog(t, dm)
+
+	tables, err := cat.Tables(context.Background(), lodbc.TableFilter{Schema: "dbo", Table: "%", Types: []string{"TABLE", "VIEW"}})
+	checkCatalog(t, dm, "SQLTables", tables, err, []lodbc.Table{
+		{Catalog: "sales", Schema: "dbo", Name: "orders", Type: "TABLE", Remarks: "Customer orders"},
+		{Name: "totals", Type: "VIEW"},
+	}, []interface{}{nil, "dbo", "%", "TABLE,VIEW"})
+}
+
+func TestCatalogColumns(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("SQLColumns", catalogResult([]string{"TABLE_CAT", "TABLE_SCHEM", "TABLE_NAME", "COLUMN_NAME", "DATA_TYPE", "TYPE_NAME", "COLUMN_SIZE",
+		"BUFFER_LENGTH", "DECIMAL_DIGITS", "NUM_PREC_RADIX", "NULLABLE", "REMARKS", "COLUMN_DEF", "SQL_DATA_TYPE", "SQL_DATETIME_SUB", "CHAR_OCTET_LENGTH", "ORDINAL_POSITION"},
+		[]interface{}{"sales", "dbo", "orders", "amount", int(odbc.SQL_DECIMAL), "decimal", 10, 12, 2, 10, int(odbc.SQL_NO_NULLS), "Total", "((0))", int(odbc.SQL_DECIMAL), nil, nil, 2},
+		[]interface{}{"sales", "dbo", "orders", "note", int(odbc.SQL_WVARCHAR), "nvarchar", 50, 100, nil, nil, int(odbc.SQL_NULLABLE), nil, nil, int(odbc.SQL_WVARCHAR), nil, 100, 3},
+		[]interface{}{"sales", "dbo", "orders", "other", int(odbc.SQL_INTEGER), "int", 10, 4, 0, 10, int(odbc.SQL_NULLABLE_UNKNOWN), nil, nil, int(odbc.SQL_INTEGER), nil, nil, 4},
+	))
+	cat := openCatalog(t, dm)
+
+	columns, err := cat.Columns(context.Background(), lodbc.ColumnFilter{Table: "orders"})
+	checkCatalog(t, dm, "SQLColumns", columns, err, []lodbc.TableColumn{
+		{Catalog: "sales", Schema: "dbo", Table: "orders", Name: "amount", DataType: odbc.SQL_DECIMAL, TypeName: "decimal", ColumnSize: 10, DecimalDigits: 2,
+			Nullable: sql.NullBool{Bool: false, Valid: true}, Remarks: "Total", Default: sql.NullString{String: "((0))", Valid: true}, OrdinalPosition: 2},
+		{Catalog: "sales", Schema: "dbo", Table: "orders", Name: "note", DataType: odbc.SQL_WVARCHAR, TypeName: "nvarchar", ColumnSize: 50,
+			Nullable: sql.NullBool{Bool: true, Valid: true}, OrdinalPosition: 3},
+		{Catalog: "sales", Schema: "dbo", Table: "orders", Name: "other", DataType: odbc.SQL_INTEGER, TypeName: "int", ColumnSize: 10, OrdinalPosition: 4},
+	}, []interface{}{nil, nil, "orders", nil})
+}
+
+func TestCatalogPrimaryKeys(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("SQLPrimaryKeys", catalogResult([]string{"TABLE_CAT", "TABLE_SCHEM", "TABLE_NAME", "COLUMN_NAME", "KEY_SEQ", "PK_NAME"},
+		[]interface{}{"sales", "dbo", "lines", "order_id", 1, "PK_lines"},
+		[]interface{}{"sales", "dbo", "lines", "line", 2, "PK_lines"},
+	))
+	cat := openCatalog(t, dm)
+
+	keys, err := cat.PrimaryKeys(context.Background(), lodbc.TableName{Catalog: "sales", Schema: "dbo", Table: "lines"})
+	checkCatalog(t, dm, "SQLPrimaryKeys", keys, err, []lodbc.PrimaryKey{
+		{Catalog: "sales", Schema: "dbo", Table: "lines", Column: "order_id", KeySequence: 1, Name: "PK_lines"},
+		{Catalog: "sales", Schema: "dbo", Table: "lines", Column: "line", KeySequence: 2, Name: "PK_lines"},
+	}, []interface{}{"sales", "dbo", "lines"})
+}
+
+func TestCatalogForeignKeys(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("SQLForeignKeys", catalogResult([]string{"PKTABLE_CAT", "PKTABLE_SCHEM", "PKTABLE_NAME", "PKCOLUMN_NAME", "FKTABLE_CAT", "FKTABLE_SCHEM",
+		"FKTABLE_NAME", "FKCOLUMN_NAME", "KEY_SEQ", "UPDATE_RULE", "DELETE_RULE", "FK_NAME", "PK_NAME", "DEFERRABILITY"},
+		[]interface{}{"sales", "dbo", "orders", "id", "sales", "dbo", "lines", "order_id", 1, 3, 0, "FK_lines_orders", "PK_orders", 7},
+	))
+	cat := openCatalog(t, dm)
+
+	if _, err := cat.ForeignKeys(context.Background(), lodbc.TableName{}, lodbc.TableName{}); err == nil {
+		t.Error("ForeignKeys without a table succeeded")
+	}
+	keys, err := cat.ForeignKeys(context.Background(), lodbc.TableName{Table: "orders"}, lodbc.TableName{})
+	checkCatalog(t, dm, "SQLForeignKeys", keys, err, []lodbc.ForeignKey{
+		{PKCatalog: "sales", PKSchema: "dbo", PKTable: "orders", PKColumn: "id", FKCatalog: "sales", FKSchema: "dbo", FKTable: "lines", FKColumn: "order_id",
+			KeySequence: 1, UpdateRule: 3, DeleteRule: 0, Name: "FK_lines_orders", PKName: "PK_orders"},
+	}, []interface{}{nil, nil, "orders", nil, nil, nil})
+}
+
+func TestCatalogStatisticsSkipsTableRows(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("SQLStatistics", catalogResult([]string{"TABLE_CAT", "TABLE_SCHEM", "TABLE_NAME", "NON_UNIQUE", "INDEX_QUALIFIER", "INDEX_NAME", "TYPE",
+		"ORDINAL_POSITION", "COLUMN_NAME", "ASC_OR_DESC", "CARDINALITY", "PAGES", "FILTER_CONDITION"},
+		[]interface{}{"sales", "dbo", "orders", nil, nil, nil, 0, nil, nil, nil, 1000, 10, nil},
+		[]interface{}{"sales", "dbo", "orders", nil, nil, "stats", 3, 1, "id", nil, nil, nil, nil},
+		[]interface{}{"sales", "dbo", "orders", 0, "orders", "PK_orders", 1, 1, "id", "A", nil, nil, nil},
+		[]interface{}{"sales", "dbo", "orders", 1, "orders", "IX_orders_date", 3, 1, "created", "D", nil, nil, nil},
+	))
+	cat := openCatalog(t, dm)
+
+	columns, err := cat.Statistics(context.Background(), lodbc.TableName{Table: "orders"}, true)
+	checkCatalog(t, dm, "SQLStatistics", columns, err, []lodbc.IndexColumn{
+		{Catalog: "sales", Schema: "dbo", Table: "orders", NonUnique: false, Index: "PK_orders", OrdinalPosition: 1, Column: "id", SortOrder: "A"},
+		{Catalog: "sales", Schema: "dbo", Table: "orders", NonUnique: true, Index: "IX_orders_date", OrdinalPosition: 1, Column: "created", SortOrder: "D"},
+	}, []interface{}{nil, nil, "orders", int64(odbc.SQL_INDEX_UNIQUE), int64(odbc.SQL_QUICK)})
+}
+
+func TestCatalogProcedures(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("SQLProcedures", catalogResult([]string{"PROCEDURE_CAT", "PROCEDURE_SCHEM", "PROCEDURE_NAME", "NUM_INPUT_PARAMS", "NUM_OUTPUT_PARAMS",
+		"NUM_RESULT_SETS", "REMARKS", "PROCEDURE_TYPE"},
+		[]interface{}{"sales", "dbo", "close_order;1", nil, nil, nil, "Closes an order", 1},
+		[]interface{}{"sales", "dbo", "order_total;0", nil, nil, nil, nil, 2},
+	))
+	cat := openCatalog(t, dm)
+
+	procedures, err := cat.Procedures(context.Background(), lodbc.ProcedureFilter{Procedure: "%order%"})
+	checkCatalog(t, dm, "SQLProcedures", procedures, err, []lodbc.Procedure{
+		{Catalog: "sales", Schema: "dbo", Name: "close_order;1", Remarks: "Closes an order", Type: 1},
+		{Catalog: "sales", Schema: "dbo", Name: "order_total;0", Type: 2},
+	}, []interface{}{nil, nil, "%order%"})
+}
+
+func TestCatalogProcedureColumns(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("SQLProcedureColumns", catalogResult([]string{"PROCEDURE_CAT", "PROCEDURE_SCHEM", "PROCEDURE_NAME", "COLUMN_NAME", "COLUMN_TYPE",
+		"DATA_TYPE", "TYPE_NAME", "COLUMN_SIZE", "BUFFER_LENGTH", "DECIMAL_DIGITS", "NUM_PREC_RADIX", "NULLABLE", "REMARKS"},
+		[]interface{}{"sales", "dbo", "close_order;1", "@RETURN_VALUE", 5, int(odbc.SQL_INTEGER), "int", 10, 4, 0, 10, int(odbc.SQL_NO_NULLS), nil},
+		[]interface{}{"sales", "dbo", "close_order;1", "@amount", 2, int(odbc.SQL_DECIMAL), "decimal", 18, 20, 4, 10, int(odbc.SQL_NULLABLE), "New total"},
+	))
+	cat := openCatalog(t, dm)
+
+	columns, err := cat.ProcedureColumns(context.Background(), lodbc.ProcedureColumnFilter{Schema: "dbo", Procedure: "close_order"})
+	checkCatalog(t, dm, "SQLProcedureColumns", columns, err, []lodbc.ProcedureColumn{
+		{Catalog: "sales", Schema: "dbo", Procedure: "close_order;1", Name: "@RETURN_VALUE", ColumnType: 5, DataType: odbc.SQL_INTEGER, TypeName: "int",
+			ColumnSize: 10, Nullable: sql.NullBool{Bool: false, Valid: true}},
+		{Catalog: "sales", Schema: "dbo", Procedure: "close_order;1", Name: "@amount", ColumnType: 2, DataType: odbc.SQL_DECIMAL, TypeName: "decimal",
+			ColumnSize: 18, DecimalDigits: 4, Nullable: sql.NullBool{Bool: true, Valid: true}, Remarks: "New total"},
+	}, []interface{}{nil, "dbo", "close_order", nil})
+}
+
+func TestCatalogSpecialColumns(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("SQLSpecialColumns", catalogResult([]string{"SCOPE", "COLUMN_NAME", "DATA_TYPE", "TYPE_NAME", "COLUMN_SIZE", "BUFFER_LENGTH",
+		"DECIMAL_DIGITS", "PSEUDO_COLUMN"},
+		[]interface{}{2, "ROWID", int(odbc.SQL_VARCHAR), "ROWID", 18, 18, nil, 2},
+		[]interface{}{2, "id", int(odbc.SQL_INTEGER), "int", 10, 4, 0, 1},
+	))
+	cat := openCatalog(t, dm)
+
+	columns, err := cat.SpecialColumns(context.Background(), lodbc.TableName{Table: "orders"}, lodbc.BestRowID)
+	checkCatalog(t, dm, "SQLSpecialColumns", columns, err, []lodbc.SpecialColumn{
+		{Name: "ROWID", DataType: odbc.SQL_VARCHAR, TypeName: "ROWID", ColumnSize: 18, Pseudo: true},
+		{Name: "id", DataType: odbc.SQL_INTEGER, TypeName: "int", ColumnSize: 10},
+	}, []interface{}{int64(odbc.SQL_BEST_ROWID), nil, nil, "orders", int64(odbc.SQL_SCOPE_SESSION), int64(odbc.SQL_NULLABLE)})
+
+	if _, err := cat.SpecialColumns(context.Background(), lodbc.TableName{Table: "orders"}, lodbc.RowVersion); err != nil {
+		t.Fatal(err)
+	}
+	executions := dm.Executions()
+	if kind := executions[len(executions)-1].Params[0]; kind != int64(odbc.SQL_ROWVER) {
+		t.Errorf("RowVersion called SQLSpecialColumns with identifier type %v", kind)
+	}
+}
@@ -0,0 +1,132 @@
+package lodbc
+
+import (
+	"github.com/LukeMauldin/lodbc/odbc"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ODBC names of SQL types, reported for columns the driver does not give a type name for
+var sqlTypeNames = map[odbc.SQLDataType]string{
+	odbc.SQL_CHAR:           "CHAR",
+	odbc.SQL_VARCHAR:        "VARCHAR",
+	odbc.SQL_LONGVARCHAR:    "LONGVARCHAR",
+	odbc.SQL_WCHAR:          "WCHAR",
+	odbc.SQL_WVARCHAR:       "WVARCHAR",
+	odbc.SQL_WLONGVARCHAR:   "WLONGVARCHAR",
+	odbc.SQL_SS_XML:         "XML",
+	odbc.SQL_BINARY:         "BINARY",
+	odbc.SQL_VARBINARY:      "VARBINARY",
+	odbc.SQL_LONGVARBINARY:  "LONGVARBINARY",
+	odbc.SQL_BIT:            "BIT",
+	odbc.SQL_TINYINT:        "TINYINT",
+	odbc.SQL_SMALLINT:       "SMALLINT",
+	odbc.SQL_INTEGER:        "INTEGER",
+	odbc.SQL_BIGINT:         "BIGINT",
+	odbc.SQL_REAL:           "REAL",
+	odbc.SQL_FLOAT:          "FLOAT",
+	odbc.SQL_DOUBLE:         "DOUBLE",
+	odbc.SQL_NUMERIC:        "NUMERIC",
+	odbc.SQL_DECIMAL:        "DECIMAL",
+	odbc.SQL_TYPE_DATE:      "DATE",
+	odbc.SQL_TYPE_TIME:      "TIME",
+	odbc.SQL_TYPE_TIMESTAMP: "TIMESTAMP",
+	odbc.SQL_GUID:           "GUID",
+
+	odbc.SQL_SS_TIME2:           "TIME",
+	odbc.SQL_SS_TIMESTAMPOFFSET: "DATETIMEOFFSET",
+	odbc.SQL_SS_VARIANT:         "SQL_VARIANT",
+	odbc.SQL_SS_UDT:             "UDT",
+}
+
+// Implements driver.RowsColumnTypeDatabaseTypeName -- the upper case type name reported by the driver, such as "NVARCHAR"
+func (rows *rows) ColumnTypeDatabaseTypeName(index int) string {
+	def := rows.resultColumnDefs[index]
+	if len(def.TypeName) > 0 {
+		return strings.ToUpper(def.TypeName)
+	}
+	return sqlTypeNames[def.DataType]
+}
+
+// Implements driver.RowsColumnTypeLength -- characters for string columns and bytes for binary columns,
+// math.MaxInt64 for unbounded (max) columns
+func (rows *rows) ColumnTypeLength(index int) (int64, bool) {
+	def := rows.resultColumnDefs[index]
+	switch def.DataType {
+	case odbc.SQL_CHAR, odbc.SQL_VARCHAR, odbc.SQL_LONGVARCHAR, odbc.SQL_WCHAR, odbc.SQL_WVARCHAR, odbc.SQL_WLONGVARCHAR, odbc.SQL_SS_XML,
+		odbc.SQL_BINARY, odbc.SQL_VARBINARY, odbc.SQL_LONGVARBINARY:
+		if def.Length <= 0 {
+			return math.MaxInt64, true
+		}
+		return int64(def.Length), true
+	}
+	return 0, false
+}
+
+// Implements driver.RowsColumnTypeNullable -- ok is false if the driver does not know
+func (rows *rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	switch rows.resultColumnDefs[index].Nullable {
+	case odbc.SQL_NULLABLE:
+		return true, true
+	case odbc.SQL_NO_NULLS:
+		return false, true
+	}
+	return false, false
+}
+
+// Implements driver.RowsColumnTypePrecisionScale for NUMERIC and DECIMAL columns
+func (rows *rows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	def := rows.resultColumnDefs[index]
+	if def.DataType == odbc.SQL_NUMERIC || def.DataType == odbc.SQL_DECIMAL {
+		return int64(def.Precision), int64(def.Scale), true
+	}
+	return 0, 0, false
+}
+
+// Implements driver.RowsColumnTypeScanType -- the type of the values Next returns for the column
+func (rows *rows) ColumnTypeScanType(index int) reflect.Type {
+	if rows.lobColumnType != 0 && index == len(rows.resultColumnDefs)-1 {
+		return reflect.TypeOf((*LOB)(nil))
+	}
+	if decoder := rows.columnDecoders[index]; decoder != nil && !decoder.isBuiltin {
+		if decoder.ScanType != nil {
+			return decoder.ScanType
+		}
+		return reflect.TypeOf((*interface{})(nil)).Elem()
+	}
+	switch rows.resultColumnDefs[index].DataType {
+	case odbc.SQL_BIT:
+		return reflect.TypeOf(false)
+	case odbc.SQL_INTEGER, odbc.SQL_SMALLINT, odbc.SQL_TINYINT:
+		return reflect.TypeOf(int(0))
+	case odbc.SQL_BIGINT:
+		return reflect.TypeOf(int64(0))
+	case odbc.SQL_FLOAT, odbc.SQL_DOUBLE, odbc.SQL_REAL:
+		return reflect.TypeOf(float64(0))
+	case odbc.SQL_NUMERIC, odbc.SQL_DECIMAL:
+		if rows.decimalFormat == DecimalString {
+			return reflect.TypeOf("")
+		}
+		return reflect.TypeOf(float64(0))
+	case odbc.SQL_CHAR, odbc.SQL_VARCHAR, odbc.SQL_LONGVARCHAR, odbc.SQL_WCHAR, odbc.SQL_WVARCHAR, odbc.SQL_WLONGVARCHAR, odbc.SQL_SS_XML, odbc.SQL_GUID:
+		return reflect.TypeOf("")
+	case odbc.SQL_BINARY, odbc.SQL_VARBINARY, odbc.SQL_LONGVARBINARY, odbc.SQL_SS_UDT:
+		return reflect.TypeOf([]byte(nil))
+	case odbc.SQL_TYPE_DATE, odbc.SQL_TYPE_TIME, odbc.SQL_TYPE_TIMESTAMP, odbc.SQL_SS_TIME2, odbc.SQL_SS_TIMESTAMPOFFSET:
+		return reflect.TypeOf(time.Time{})
+	}
+	return reflect.TypeOf((*interface{})(nil)).Elem()
+}
+
+// Returns the table the result column at index is read from, if the driver reports it.
+// Available on the driver's rows, for example through sql.Conn.Raw.
+func (rows *rows) ColumnTypeBaseTableName(index int) string {
+	return rows.resultColumnDefs[index].BaseTableName
+}
+
+// Returns the table column the result column at index is read from, if the driver reports it
+func (rows *rows) ColumnTypeBaseColumnName(index int) string {
+	return rows.resultColumnDefs[index].BaseColumnName
+}
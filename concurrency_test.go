@@ -0,0 +1,192 @@
+package lodbc_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"github.com/LukeMauldin/lodbc"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"github.com/LukeMauldin/lodbc/odbc/fake"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Result set with enough rows to still be fetching when another goroutine closes it
+func manyRows(count int) *fake.ResultSet {
+	rs := &fake.ResultSet{Columns: []fake.Column{{Name: "id", Type: odbc.SQL_INTEGER}, {Name: "name", Type: odbc.SQL_WVARCHAR, Precision: 10}}}
+	for i := 0; i < count; i++ {
+		rs.Rows = append(rs.Rows, []interface{}{i, "name"})
+	}
+	return rs
+}
+
+func TestCloseWhileIterating(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("select many", &fake.Response{ResultSets: []*fake.ResultSet{manyRows(1000)}})
+	db := openDB(t, dm, func(config *lodbc.Config) {
+		config.RowsetSize = 10
+	})
+
+	for i := 0; i < 20; i++ {
+		rows, err := db.Query("select many")
+		if err != nil {
+			t.Fatal(err)
+		}
+		started := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for n := 0; rows.Next(); n++ {
+				if n == 0 {
+					close(started)
+				}
+				var id int
+				var name string
+				rows.Scan(&id, &name)
+			}
+		}()
+		<-started
+		if err := rows.Close(); err != nil {
+			t.Error(err)
+		}
+		<-done
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if open := dm.OpenHandles(odbc.SQL_HANDLE_STMT); open != 0 {
+		t.Errorf("%v statement handles still open", open)
+	}
+}
+
+func TestCancelFromAnotherGoroutine(t *testing.T) {
+	started := make(chan struct{}, 1)
+	var cancelled int32
+	dm := fake.New()
+	dm.HandleQuery("waitfor delay", func(e *fake.Execution) *fake.Response {
+		started <- struct{}{}
+		<-e.Cancelled()
+		atomic.StoreInt32(&cancelled, 1)
+		return &fake.Response{}
+	})
+	dm.SetResponse("select many", &fake.Response{ResultSets: []*fake.ResultSet{manyRows(5)}})
+	db := openDB(t, dm, nil)
+	db.SetMaxOpenConns(1)
+
+	for i := 0; i < 5; i++ {
+		atomic.StoreInt32(&cancelled, 0)
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-started
+			cancel()
+		}()
+		_, err := db.ExecContext(ctx, "waitfor delay")
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want context.Canceled", err)
+		}
+		if atomic.LoadInt32(&cancelled) != 1 {
+			t.Error("the execution was not cancelled with SQLCancel")
+		}
+		cancel()
+
+		//The connection is still usable
+		rows, err := db.Query("select many")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for rows.Next() {
+		}
+		if err := rows.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Prepares statements on conn and drops them without closing them, leaving half of them with open rows
+func dropStatements(t *testing.T, conn driver.Conn, count int) {
+	t.Helper()
+	ctx := context.Background()
+	for i := 0; i < count; i++ {
+		stmt, err := conn.(driver.ConnPrepareContext).PrepareContext(ctx, "select many")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i%2 == 0 {
+			if _, err := stmt.(driver.StmtQueryContext).QueryContext(ctx, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestFinalizersCloseDroppedStatements(t *testing.T) {
+	ctx := context.Background()
+	dm := fake.New()
+	dm.SetResponse("select many", &fake.Response{ResultSets: []*fake.ResultSet{manyRows(100)}})
+	db := openDB(t, dm, func(config *lodbc.Config) {
+		config.RowsetSize = 10
+	})
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = conn.Raw(func(driverConn interface{}) error {
+		dropStatements(t, driverConn.(driver.Conn), 10)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Keep using the connection while the finalizers close the statements
+	deadline := time.Now().Add(10 * time.Second)
+	for dm.OpenHandles(odbc.SQL_HANDLE_STMT) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("%v statement handles still open", dm.OpenHandles(odbc.SQL_HANDLE_STMT))
+		}
+		runtime.GC()
+		rows, err := conn.QueryContext(ctx, "select many")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for rows.Next() {
+		}
+		if err := rows.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	//Statements finalized while and after their connection closes do not use the freed handles
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		db = openDB(t, dm, nil)
+		conn, err = db.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.Raw(func(driverConn interface{}) error {
+			dropStatements(t, driverConn.(driver.Conn), 10)
+			return nil
+		})
+		conn.Close()
+		runtime.GC()
+		if err := db.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runtime.GC()
+	for _, handleType := range []odbc.SQLSMALLINT{odbc.SQL_HANDLE_STMT, odbc.SQL_HANDLE_DBC} {
+		if open := dm.OpenHandles(handleType); open != 0 {
+			t.Errorf("%v handles of type %v still open", open, handleType)
+		}
+	}
+}
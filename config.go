@@ -0,0 +1,121 @@
+package lodbc
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// Settings for the connections opened by a Connector.  Start from NewConfig, which fills in the
+// package level settings, so sql.DB pools created with sql.OpenDB can each have their own settings.
+type Config struct {
+	// Connection string passed to SQLDriverConnect
+	ConnectionString string
+
+	// Time to wait for the login to complete, limited by the Connect ctx deadline -- 0 uses the driver default
+	LoginTimeout time.Duration
+
+	// Timeout for statements executed without a ctx deadline -- 0 means no timeout
+	QueryTimeout time.Duration
+
+	// Isolation level of the connections, restored when a transaction with its own level completes --
+	// sql.LevelDefault keeps the driver default
+	Isolation sql.IsolationLevel
+
+	// Turns autocommit off, so statements outside a database/sql transaction are only committed by an explicit
+	// COMMIT statement.  Uncommitted work is rolled back when the connection is closed.
+	DisableAutocommit bool
+
+	// Network packet size in bytes, set with SQL_ATTR_PACKET_SIZE -- 0 uses the driver default
+	PacketSize int
+
+	// Application name reported to the server, added to the connection string as the APP keyword
+	AppName string
+
+	// Statements executed on each new connection, such as SET options, before it is used
+	InitStatements []string
+
+	// Format NUMERIC and DECIMAL columns are returned in
+	DecimalFormat DecimalFormat
+
+	// Number of rows fetched per round trip -- 0 reads every value with SQLGetData
+	RowsetSize int
+
+	// Bytes read per SQLGetData call when streaming LOB columns as *LOB -- 0 reads them whole
+	LOBChunkSize int
+
+	// Location DATE, TIME and TIMESTAMP values are read in and time.Time parameters are converted to -- nil is time.UTC
+	Location *time.Location
+
+	// Query appended to each Exec of an INSERT statement, in the same batch, to populate Result.LastInsertId -- see SetLastInsertIdQuery.
+	// Only statements starting with INSERT after any comments are recognized.  Empty disables LastInsertId.
+	LastInsertIdQuery string
+
+	// Query run by Ping -- empty only checks SQL_ATTR_CONNECTION_DEAD
+	PingQuery string
+
+	// Called with informational messages, nil to discard them
+	MessageHandler MessageHandler
+}
+
+// Returns a Config for connectionString with the package level settings, such as the ones set with
+// SetQueryTimeout, SetRowsetSize and SetMessageHandler
+func NewConfig(connectionString string) *Config {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	return &Config{
+		ConnectionString:  connectionString,
+		QueryTimeout:      queryTimeout,
+		DecimalFormat:     decimalFormat,
+		RowsetSize:        rowsetSize,
+		LOBChunkSize:      lobChunkSize,
+		Location:          location,
+		LastInsertIdQuery: lastInsertIdQuery,
+		PingQuery:         pingQuery,
+		MessageHandler:    messageHandler,
+	}
+}
+
+// Parses the connection string passed to SQLDriverConnect, adding the APP keyword when AppName is set
+func (config *Config) connectionString() (*ConnectionString, error) {
+	cs, err := ParseConnectionString(config.ConnectionString)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.AppName) > 0 {
+		cs.set("APP", config.AppName)
+	}
+	return cs, nil
+}
+
+// Implements database/sql/driver Connector interface
+type connector struct {
+	driver *lodbcDriver
+	config Config
+}
+
+// Returns a connector opening connections with config through the driver registered as "lodbc", for sql.OpenDB
+func NewConnector(config *Config) driver.Connector {
+	return &connector{driver: defaultDriver, config: *config}
+}
+
+// Returns a connector opening connections with config through d, which must be created with NewDriver
+func NewDriverConnector(d driver.Driver, config *Config) (driver.Connector, error) {
+	lodbcDriver, ok := d.(*lodbcDriver)
+	if !ok {
+		return nil, fmt.Errorf("NewDriverConnector requires a lodbc driver, not %T", d)
+	}
+	return &connector{driver: lodbcDriver, config: *config}, nil
+}
+
+// Opens a new connection with the connector's config
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.driver.connect(ctx, &c.config)
+}
+
+// Returns the driver the connector opens connections with
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
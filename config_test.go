@@ -0,0 +1,132 @@
+package lodbc_test
+
+import (
+	"database/sql"
+	"github.com/LukeMauldin/lodbc"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"github.com/LukeMauldin/lodbc/odbc/fake"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConnectorsApplyTheirOwnSettings(t *testing.T) {
+	dm := fake.New()
+	dm.HandleDefault(func(e *fake.Execution) *fake.Response { return &fake.Response{} })
+	var mu sync.Mutex
+	var connStrings []string
+	dm.HandleConnect(func(connString string) []fake.Diagnostic {
+		mu.Lock()
+		defer mu.Unlock()
+		connStrings = append(connStrings, connString)
+		return nil
+	})
+	d, err := lodbc.NewDriver(dm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	openPool := func(config *lodbc.Config) *sql.DB {
+		connector, err := lodbc.NewDriverConnector(d, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		db := sql.OpenDB(connector)
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+
+	reports := lodbc.NewConfig("DSN=reports")
+	reports.LoginTimeout = 1500 * time.Millisecond
+	reports.QueryTimeout = 10 * time.Minute
+	reports.Isolation = sql.LevelSnapshot
+	reports.DisableAutocommit = true
+	reports.PacketSize = 32768
+	reports.AppName = "reports"
+	reports.InitStatements = []string{"SET ANSI_WARNINGS OFF", "SET ARITHABORT ON"}
+	reportsDB := openPool(reports)
+	orders := lodbc.NewConfig("DSN=orders")
+	orders.QueryTimeout = 5 * time.Second
+	ordersDB := openPool(orders)
+
+	//The configs are copied, so changing them afterwards does not affect the pools
+	reports.QueryTimeout = time.Second
+	orders.AppName = "changed"
+
+	if _, err := reportsDB.Exec("select reports"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ordersDB.Exec("select orders"); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(connStrings) != 2 {
+		t.Fatalf("connection strings %q", connStrings)
+	}
+	for i, want := range []struct{ dsn, app string }{{"reports", "reports"}, {"orders", ""}} {
+		cs, err := lodbc.ParseConnectionString(connStrings[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dsn, _ := cs.Get("DSN"); dsn != want.dsn {
+			t.Errorf("DSN %q, want %q", dsn, want.dsn)
+		}
+		if app, _ := cs.Get("APP"); app != want.app {
+			t.Errorf("APP %q, want %q", app, want.app)
+		}
+	}
+
+	executions := dm.Executions()
+	var queries []string
+	for _, e := range executions {
+		queries = append(queries, e.Query)
+	}
+	if len(queries) != 4 || queries[0] != "SET ANSI_WARNINGS OFF" || queries[1] != "SET ARITHABORT ON" || queries[2] != "select reports" || queries[3] != "select orders" {
+		t.Fatalf("executed %q", queries)
+	}
+
+	reportsExec, ordersExec := executions[2], executions[3]
+	wantConnAttrs := []struct {
+		name      string
+		attrs     map[odbc.SQLINTEGER]odbc.SQLPOINTER
+		attribute odbc.SQLINTEGER
+		value     odbc.SQLPOINTER
+		set       bool
+	}{
+		{"reports login timeout", reportsExec.ConnAttrs, odbc.SQL_ATTR_LOGIN_TIMEOUT, 2, true},
+		{"reports packet size", reportsExec.ConnAttrs, odbc.SQL_ATTR_PACKET_SIZE, 32768, true},
+		{"reports isolation", reportsExec.ConnAttrs, odbc.SQL_ATTR_TXN_ISOLATION, odbc.SQLPOINTER(odbc.SQL_TXN_SS_SNAPSHOT), true},
+		{"reports autocommit", reportsExec.ConnAttrs, odbc.SQL_ATTR_AUTOCOMMIT, odbc.SQLPOINTER(odbc.SQL_AUTOCOMMIT_OFF), true},
+		{"orders login timeout", ordersExec.ConnAttrs, odbc.SQL_ATTR_LOGIN_TIMEOUT, 0, false},
+		{"orders packet size", ordersExec.ConnAttrs, odbc.SQL_ATTR_PACKET_SIZE, 0, false},
+		{"orders isolation", ordersExec.ConnAttrs, odbc.SQL_ATTR_TXN_ISOLATION, 0, false},
+		{"orders autocommit", ordersExec.ConnAttrs, odbc.SQL_ATTR_AUTOCOMMIT, 0, false},
+	}
+	for _, w := range wantConnAttrs {
+		value, set := w.attrs[w.attribute]
+		if value != w.value || set != w.set {
+			t.Errorf("%v %v (set %v), want %v (set %v)", w.name, value, set, w.value, w.set)
+		}
+	}
+	if timeout := reportsExec.StmtAttrs[odbc.SQL_ATTR_QUERY_TIMEOUT]; timeout != 600 {
+		t.Errorf("reports query timeout %v, want 600", timeout)
+	}
+	if timeout := ordersExec.StmtAttrs[odbc.SQL_ATTR_QUERY_TIMEOUT]; timeout != 5 {
+		t.Errorf("orders query timeout %v, want 5", timeout)
+	}
+}
+
+func TestFailedInitStatementsFailTheConnection(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("SET BAD OPTION", &fake.Response{Errors: []fake.Diagnostic{{State: "42000", Message: "Incorrect syntax"}}})
+	db := openDB(t, dm, func(config *lodbc.Config) {
+		config.InitStatements = []string{"SET BAD OPTION"}
+	})
+	if err := db.Ping(); err == nil {
+		t.Fatal("Ping succeeded with a failing init statement")
+	}
+	if open := dm.OpenHandles(odbc.SQL_HANDLE_DBC); open != 0 {
+		t.Errorf("%v connections open after the init statement failed", open)
+	}
+}
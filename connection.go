@@ -1,55 +1,195 @@
 package lodbc
 
 import (
+	"context"
+	"database/sql"
 	"database/sql/driver"
 	"fmt"
 	"github.com/LukeMauldin/lodbc/odbc"
 	"runtime"
+	"sync"
+	"time"
 	"unsafe"
+	"weak"
 )
 
 // Implements type database/sql/driver Conn interface
+//
+// A connection, its statements and their rows are used by one goroutine at a time, which database/sql
+// guarantees by holding the connection's lock around every call.  The exceptions are Close, which
+// finalizers and other goroutines may call on a connection, statement or rows at any time, and the
+// goroutine watching a ctx, which only calls SQLCancel.  The connection owns its statements and closes
+// them when it is closed; a statement owns its current rows; rows returned by QueryContext own their statement.
+// The connection refers to its statements weakly, so a statement dropped without Close is closed by its finalizer.
 type connection struct {
 
+	// ODBC API used by the connection
+	api odbc.API
+
 	// Connection handle
 	handle odbc.SQLHandle
 
 	// Is transaction active
 	isTransactionActive bool
 
-	// Statements owned by the connection
-	statements map[driver.Stmt]bool
+	// Statements owned by the connection, by handle
+	statements map[odbc.SQLHandle]weak.Pointer[statement]
 
 	// Is closed -- allows Close() to be called multiple times without error
 	isClosed bool
+
+	// Is bad -- the link to the data source was lost and the pool must discard the connection
+	isBad bool
+
+	// Guards statements, isClosed and isBad
+	mu sync.Mutex
+
+	// Held for reading while Close releases a statement or rows, and for writing while the connection
+	// disconnects, which frees the handles of its statements along with its own
+	handleMu sync.RWMutex
+
+	// Format NUMERIC and DECIMAL columns are returned in
+	decimalFormat DecimalFormat
+
+	// Number of rows fetched per round trip
+	rowsetSize int
+
+	//Bytes read per SQLGetData call when streaming LOB columns, 0 to read them whole
+	lobChunkSize int
+
+	// Location of DATE, TIME and TIMESTAMP values
+	location *time.Location
+
+	// Called with informational messages, nil to discard them
+	messageHandler MessageHandler
+
+	// Timeout for statements executed without a ctx deadline
+	queryTimeout time.Duration
+
+	// Query run in the same batch as each Exec to populate Result.LastInsertId, empty to disable it
+	lastInsertIdQuery string
+
+	// Query run by Ping, empty to only check SQL_ATTR_CONNECTION_DEAD
+	pingQuery string
+
+	// SQL_ATTR_AUTOCOMMIT value outside transactions
+	autocommit odbc.SQLINTEGER
 }
 
 // Prepare returns a prepared statement, bound to this connection
 func (c *connection) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext returns a prepared statement, bound to this connection
+func (c *connection) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if c.bad() {
+		return nil, driver.ErrBadConn
+	}
+
+	// Create new statement
+	stmt, err := c.newStatement(query)
+	if err != nil {
+		return nil, c.badConn(err)
+	}
+
+	// Prepare the statement so it is compiled once and executed many times
+	err = stmt.prepare()
+	if err != nil {
+		stmt.Close()
+		return nil, c.badConn(err)
+	}
+
+	return stmt, nil
+}
+
+// QueryContext executes query directly with SQLExecDirect, without preparing it first.
+// The statement is closed when the returned rows are closed.
+func (c *connection) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	if c.bad() {
+		return nil, driver.ErrBadConn
+	}
+
+	stmt, err := c.newStatement(query)
+	if err != nil {
+		return nil, c.badConn(err)
+	}
+
+	driverRows, err := stmt.query(ctx, values)
+	if err != nil {
+		stmt.Close()
+		return nil, c.checkLink(err)
+	}
+	driverRows.(*rows).ownedStmt = stmt
+
+	//The rows close the statement -- a finalizer on both would keep the pair from being collected
+	runtime.SetFinalizer(stmt, nil)
+
+	return driverRows, nil
+}
+
+// ExecContext executes query directly with SQLExecDirect, without preparing it first
+func (c *connection) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	if c.bad() {
+		return nil, driver.ErrBadConn
+	}
+
+	stmt, err := c.newStatement(query)
+	if err != nil {
+		return nil, c.badConn(err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.exec(ctx, values)
+	if err != nil {
+		return nil, c.checkLink(err)
+	}
+	return res, nil
+}
+
+// Allocates a statement handle for query, bound to this connection
+func (c *connection) newStatement(query string) (*statement, error) {
 
 	// Allocate the statement handle
 	var stmtHandle odbc.SQLHandle
-	ret := odbc.SQLAllocHandle(odbc.SQL_HANDLE_STMT, c.handle, &stmtHandle)
+	ret := c.api.SQLAllocHandle(odbc.SQL_HANDLE_STMT, c.handle, &stmtHandle)
 	if isError(ret) {
-		return nil, errorConnection(c.handle)
+		return nil, errorConnection(c.api, c.handle)
 	}
 
 	// Set the query timeout
-	ret = odbc.SQLSetStmtAttr(stmtHandle, odbc.SQL_ATTR_QUERY_TIMEOUT, odbc.SQLPOINTER(queryTimeout.Seconds()), odbc.SQL_IS_INTEGER)
+	timeout := timeoutSeconds(c.queryTimeout)
+	ret = c.api.SQLSetStmtAttr(stmtHandle, odbc.SQL_ATTR_QUERY_TIMEOUT, odbc.SQLPOINTER(timeout), odbc.SQL_IS_INTEGER)
 	if isError(ret) {
-		return nil, errorStatement(stmtHandle, query)
+		err := errorStatement(c.api, stmtHandle, query)
+		c.api.SQLFreeHandle(odbc.SQL_HANDLE_STMT, stmtHandle)
+		return nil, err
 	}
 
 	// Get the statement descriptor table
 	var stmtDescHandle odbc.SQLHandle
-	ret = odbc.SQLGetStmtAttr(stmtHandle, odbc.SQL_ATTR_APP_PARAM_DESC, uintptr(unsafe.Pointer(&stmtDescHandle)), 0, nil)
+	ret = c.api.SQLGetStmtAttr(stmtHandle, odbc.SQL_ATTR_APP_PARAM_DESC, unsafe.Pointer(&stmtDescHandle), 0, nil)
 	if isError(ret) {
-		return nil, errorConnection(c.handle)
+		err := errorStatement(c.api, stmtHandle, query)
+		c.api.SQLFreeHandle(odbc.SQL_HANDLE_STMT, stmtHandle)
+		return nil, err
 	}
 
 	// Parse query options
 	queryOptions, err := parseQueryOptions(query)
 	if err != nil {
+		c.api.SQLFreeHandle(odbc.SQL_HANDLE_STMT, stmtHandle)
 		return nil, err
 	}
 
@@ -57,10 +197,12 @@ func (c *connection) Prepare(query string) (driver.Stmt, error) {
 	query = removeOptions(query)
 
 	// Create new statement
-	stmt := &statement{handle: stmtHandle, stmtDescHandle: stmtDescHandle, sqlStmt: query, conn: c, queryOptions: queryOptions}
+	stmt := &statement{api: c.api, handle: stmtHandle, stmtDescHandle: stmtDescHandle, sqlStmt: query, conn: c, queryOptions: queryOptions, numInput: -1, queryTimeout: timeout}
 
 	// Add to map of statements owned by the connection
-	c.statements[stmt] = true
+	c.mu.Lock()
+	c.statements[stmtHandle] = weak.Make(stmt)
+	c.mu.Unlock()
 
 	//Add a finalizer
 	runtime.SetFinalizer(stmt, (*statement).Close)
@@ -73,60 +215,65 @@ func (c *connection) Prepare(query string) (driver.Stmt, error) {
 // connection as no longer in use.
 func (c *connection) Close() error {
 
-	// Verify that connHandle is valid
-	if c.handle == 0 {
-		return nil
-	}
-
-	// Verify that connection has not already been closed
-	if c.isClosed {
+	// Verify that connHandle is valid and the connection has not already been closed
+	c.mu.Lock()
+	if c.handle == 0 || c.isClosed {
+		c.mu.Unlock()
 		return nil
 	}
+	c.isClosed = true
+	statements := c.statements
+	c.statements = nil
+	c.mu.Unlock()
 
 	var err error
 
-	// Close all of the statements owned by the connection
-	for key, _ := range c.statements {
-		// Skip the statement if it is already nil
-		if isNil(key) {
-			continue
+	// Close all of the statements owned by the connection -- collected statements are closed by their finalizer
+	for _, ref := range statements {
+		if stmt := ref.Value(); stmt != nil {
+			stmt.Close()
 		}
-		key.Close()
 	}
-	c.statements = nil
+
+	// Wait for statements being closed by finalizers to release their handles
+	c.handleMu.Lock()
+	defer c.handleMu.Unlock()
 
 	// If the transaction is active, roll it back
 	if c.isTransactionActive {
-		ret := odbc.SQLEndTran(odbc.SQL_HANDLE_DBC, c.handle, odbc.SQL_ROLLBACK)
+		ret := c.api.SQLEndTran(odbc.SQL_HANDLE_DBC, c.handle, odbc.SQL_ROLLBACK)
 		if isError(ret) {
-			err = errorConnection(c.handle)
+			err = errorConnection(c.api, c.handle)
 		}
 
 		//Turn AutoCommit back on
-		ret = odbc.SQLSetConnectAttr(c.handle, odbc.SQL_ATTR_AUTOCOMMIT, odbc.SQLPOINTER(odbc.SQL_AUTOCOMMIT_ON), 0, nil)
+		ret = c.api.SQLSetConnectAttr(c.handle, odbc.SQL_ATTR_AUTOCOMMIT, odbc.SQLPOINTER(odbc.SQL_AUTOCOMMIT_ON), 0, nil)
+		if isError(ret) {
+			err = errorConnection(c.api, c.handle)
+		}
+	} else if c.autocommit == odbc.SQL_AUTOCOMMIT_OFF && !c.bad() {
+		// Roll back the work that was not committed with an explicit COMMIT
+		ret := c.api.SQLEndTran(odbc.SQL_HANDLE_DBC, c.handle, odbc.SQL_ROLLBACK)
 		if isError(ret) {
-			err = errorConnection(c.handle)
+			err = errorConnection(c.api, c.handle)
 		}
 	}
 
 	// Disconnect connection
-	ret := odbc.SQLDisconnect(c.handle)
+	ret := c.api.SQLDisconnect(c.handle)
 	if isError(ret) {
-		err = errorConnection(c.handle)
+		err = errorConnection(c.api, c.handle)
 	}
 
 	// Deallocate connection
-	ret = odbc.SQLFreeHandle(odbc.SQL_HANDLE_DBC, c.handle)
+	ret = c.api.SQLFreeHandle(odbc.SQL_HANDLE_DBC, c.handle)
 	if isError(ret) {
-		err = errorConnection(c.handle)
+		err = errorConnection(c.api, c.handle)
 	}
 
 	// Clear the handle
 	c.handle = 0
 
-	// Set connection to closed
-	c.isClosed = true
-
 	//Clear the finalizer
 	runtime.SetFinalizer(c, nil)
 
@@ -141,23 +288,208 @@ func (c *connection) Close() error {
 // Begin starts and returns a new transaction
 // Only one transaction is supported at a time for a connection
 func (c *connection) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx starts and returns a new transaction with the isolation level and access mode in opts.
+// The connection's previous settings are restored when the transaction completes.
+func (c *connection) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if c.bad() {
+		return nil, driver.ErrBadConn
+	}
+
 	// Do not allow a  new transaction if one already exists
 	if c.isTransactionActive {
 		return nil, fmt.Errorf("Transaction already active for connection")
 	}
 
-	ret := odbc.SQLSetConnectAttr(c.handle, odbc.SQL_ATTR_AUTOCOMMIT, odbc.SQLPOINTER(odbc.SQL_AUTOCOMMIT_OFF), 0, nil)
-	if isError(ret) {
-		return nil, errorConnection(c.handle)
+	tx := &transaction{conn: c}
+
+	// Set the isolation level, remembering the current one
+	if sql.IsolationLevel(opts.Isolation) != sql.LevelDefault {
+		isolation, err := txnIsolation(opts.Isolation)
+		if err != nil {
+			return nil, c.badConn(err)
+		}
+		tx.previousIsolation, err = c.getConnectAttr(odbc.SQL_ATTR_TXN_ISOLATION)
+		if err != nil {
+			return nil, c.badConn(err)
+		}
+		err = c.setConnectAttr(odbc.SQL_ATTR_TXN_ISOLATION, isolation)
+		if err != nil {
+			return nil, c.badConn(err)
+		}
+		tx.restoreIsolation = true
+	}
+
+	// Set the access mode to read only, remembering the current one
+	if opts.ReadOnly {
+		var err error
+		tx.previousAccessMode, err = c.getConnectAttr(odbc.SQL_ATTR_ACCESS_MODE)
+		if err == nil {
+			err = c.setConnectAttr(odbc.SQL_ATTR_ACCESS_MODE, odbc.SQL_MODE_READ_ONLY)
+		}
+		if err != nil {
+			tx.restoreSettings()
+			return nil, c.badConn(err)
+		}
+		tx.restoreAccessMode = true
+	}
+
+	err := c.setConnectAttr(odbc.SQL_ATTR_AUTOCOMMIT, odbc.SQL_AUTOCOMMIT_OFF)
+	if err != nil {
+		tx.restoreSettings()
+		return nil, c.badConn(err)
 	}
 	c.isTransactionActive = true
 
-	tx := &transaction{conn: c}
 	return tx, nil
 }
 
+// Returns the value of an integer connection attribute
+func (c *connection) getConnectAttr(attribute odbc.SQLINTEGER) (odbc.SQLINTEGER, error) {
+	var value odbc.SQLUINTEGER
+	ret := c.api.SQLGetConnectAttr(c.handle, attribute, unsafe.Pointer(&value), 0, nil)
+	if isError(ret) {
+		return 0, errorConnection(c.api, c.handle)
+	}
+	return odbc.SQLINTEGER(value), nil
+}
+
+// Sets an integer connection attribute
+func (c *connection) setConnectAttr(attribute odbc.SQLINTEGER, value odbc.SQLINTEGER) error {
+	ret := c.api.SQLSetConnectAttr(c.handle, attribute, odbc.SQLPOINTER(value), 0, nil)
+	if isError(ret) {
+		return errorConnection(c.api, c.handle)
+	}
+	return nil
+}
+
+// Implements driver.Validator -- false once the link to the data source was lost
+func (c *connection) IsValid() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.isClosed && !c.isBad
+}
+
+// Implements driver.SessionResetter -- called before a pooled connection is reused.
+// Returns driver.ErrBadConn if the connection was lost so the pool discards it.
+func (c *connection) ResetSession(ctx context.Context) error {
+	if !c.IsValid() || c.isDead() {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+// Implements driver.Pinger -- checks SQL_ATTR_CONNECTION_DEAD, then runs the ping query
+// to make sure the data source can still be reached
+func (c *connection) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !c.IsValid() || c.isDead() {
+		return driver.ErrBadConn
+	}
+	if len(c.pingQuery) == 0 {
+		return nil
+	}
+	return c.badConn(c.execDirect(ctx, c.pingQuery))
+}
+
+// Applies the isolation level, init statements and autocommit setting of config to a new connection
+func (c *connection) initSession(ctx context.Context, config *Config) error {
+	if config.Isolation != sql.LevelDefault {
+		isolation, err := txnIsolation(driver.IsolationLevel(config.Isolation))
+		if err != nil {
+			return err
+		}
+		err = c.setConnectAttr(odbc.SQL_ATTR_TXN_ISOLATION, isolation)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, query := range config.InitStatements {
+		err := c.execDirect(ctx, query)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.autocommit == odbc.SQL_AUTOCOMMIT_OFF {
+		return c.setConnectAttr(odbc.SQL_ATTR_AUTOCOMMIT, odbc.SQL_AUTOCOMMIT_OFF)
+	}
+	return nil
+}
+
+// Executes query without parameters, discarding any results
+func (c *connection) execDirect(ctx context.Context, query string) error {
+	stmt, err := c.newStatement(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	return stmt.execute(ctx, nil, "")
+}
+
+// Asks the driver whether the connection was lost with SQL_ATTR_CONNECTION_DEAD.  The driver only
+// notices after a failed call on the connection; drivers without the attribute report it alive.
+func (c *connection) isDead() bool {
+	dead, err := c.getConnectAttr(odbc.SQL_ATTR_CONNECTION_DEAD)
+	if err != nil {
+		c.checkLink(err)
+	} else if dead == odbc.SQL_CD_TRUE {
+		c.markBad()
+	}
+	return c.bad()
+}
+
+// Marks the connection bad if err reports a lost link, returning err unchanged.
+// Used once a statement may have reached the server, where retrying it could run it twice.
+func (c *connection) checkLink(err error) error {
+	if isLinkFailure(err) {
+		c.markBad()
+	}
+	return err
+}
+
+// Like checkLink, but returns driver.ErrBadConn for a lost link so database/sql retries on another
+// connection.  Only used before anything has been executed on the server.
+func (c *connection) badConn(err error) error {
+	if isLinkFailure(err) {
+		c.markBad()
+		return driver.ErrBadConn
+	}
+	return err
+}
+
 // To be called by the statements owned by the connection when the statement is closed
 // Removed the statement from the connection's list of statements'
-func (c *connection) closeStatement(stmt driver.Stmt) {
-	delete(c.statements, stmt)
+func (c *connection) closeStatement(stmt *statement) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.statements, stmt.handle)
+}
+
+// Reports whether the link to the data source was lost
+func (c *connection) bad() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.isBad
+}
+
+// Records that the link to the data source was lost
+func (c *connection) markBad() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.isBad = true
+}
+
+// Returns the settings parameters are converted with
+func (c *connection) convertContext() ConvertContext {
+	return ConvertContext{Location: c.location, DecimalFormat: c.decimalFormat}
 }
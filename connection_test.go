@@ -0,0 +1,129 @@
+package lodbc_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"github.com/LukeMauldin/lodbc"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"github.com/LukeMauldin/lodbc/odbc/fake"
+	"testing"
+)
+
+// Response reporting that the server dropped the connection while running the statement
+var linkFailure = &fake.Response{Errors: []fake.Diagnostic{{State: "08S01", Message: "Communication link failure"}}}
+
+func TestLinkFailuresReturnErrBadConnOnlyBeforeExecuting(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("update t", linkFailure)
+	dm.SetResponse("select 1", &fake.Response{})
+	db := openDB(t, dm, nil)
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	err = conn.Raw(func(driverConn interface{}) error {
+		//The statement reached the server, so the real error is returned rather than retrying it
+		execer := driverConn.(driver.ExecerContext)
+		_, err := execer.ExecContext(ctx, "update t", nil)
+		var odbcErr *lodbc.ODBCError
+		if !errors.As(err, &odbcErr) || odbcErr.SQLState() != "08S01" {
+			t.Errorf("ExecContext returned %v, want the 08S01 error", err)
+		}
+		if len(dm.Executions()) != 1 {
+			t.Errorf("%v executions, want 1", len(dm.Executions()))
+		}
+
+		//Later statements fail before reaching the server, so database/sql can retry them on another connection
+		if _, err := execer.ExecContext(ctx, "select 1", nil); err != driver.ErrBadConn {
+			t.Errorf("ExecContext on the lost connection returned %v", err)
+		}
+		if _, err := driverConn.(driver.QueryerContext).QueryContext(ctx, "select 1", nil); err != driver.ErrBadConn {
+			t.Errorf("QueryContext on the lost connection returned %v", err)
+		}
+		if _, err := driverConn.(driver.ConnPrepareContext).PrepareContext(ctx, "select 1"); err != driver.ErrBadConn {
+			t.Errorf("PrepareContext on the lost connection returned %v", err)
+		}
+		if _, err := driverConn.(driver.ConnBeginTx).BeginTx(ctx, driver.TxOptions{}); err != driver.ErrBadConn {
+			t.Errorf("BeginTx on the lost connection returned %v", err)
+		}
+		if len(dm.Executions()) != 1 {
+			t.Errorf("%v executions, want 1", len(dm.Executions()))
+		}
+
+		if driverConn.(driver.Validator).IsValid() {
+			t.Error("IsValid reported the lost connection valid")
+		}
+		if err := driverConn.(driver.SessionResetter).ResetSession(ctx); err != driver.ErrBadConn {
+			t.Errorf("ResetSession returned %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestThePoolReplacesDroppedConnections(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("SELECT 1", &fake.Response{})
+	db := openDB(t, dm, nil)
+	if _, err := db.Exec("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	//ResetSession finds the idle connection dead with SQL_ATTR_CONNECTION_DEAD, so database/sql opens a new one
+	dm.DropConnections()
+	if _, err := db.Exec("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if open := dm.OpenHandles(odbc.SQL_HANDLE_DBC); open != 1 {
+		t.Errorf("%v connections open, want 1", open)
+	}
+}
+
+func TestPingReportsDroppedConnections(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("SELECT 1", &fake.Response{})
+	db := openDB(t, dm, nil)
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	err = conn.Raw(func(driverConn interface{}) error {
+		pinger := driverConn.(driver.Pinger)
+		if err := pinger.Ping(ctx); err != nil {
+			t.Errorf("Ping returned %v", err)
+		}
+		if executions := dm.Executions(); len(executions) != 1 || executions[0].Query != "SELECT 1" {
+			t.Errorf("Ping executed %+v", executions)
+		}
+		if !driverConn.(driver.Validator).IsValid() {
+			t.Error("IsValid reported the connection invalid")
+		}
+		if err := driverConn.(driver.SessionResetter).ResetSession(ctx); err != nil {
+			t.Errorf("ResetSession returned %v", err)
+		}
+
+		dm.DropConnections()
+		if err := pinger.Ping(ctx); err != driver.ErrBadConn {
+			t.Errorf("Ping on a dropped connection returned %v", err)
+		}
+		if driverConn.(driver.Validator).IsValid() {
+			t.Error("IsValid reported the dropped connection valid")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,225 @@
+package lodbc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Replaces the values of password attributes when a connection string is printed
+const redactedValue = "*****"
+
+// ODBC connection string such as "DSN=Sales;UID=app;PWD=secret", kept as keyword and value pairs in order.
+// Keywords are case insensitive.  The zero value is an empty connection string.
+type ConnectionString struct {
+	attrs []connAttr
+}
+
+// Connection string attribute
+type connAttr struct {
+	key   string
+	value string
+}
+
+// Parses s with the ODBC rules: attributes are separated by semicolons and values containing
+// semicolons are enclosed in braces, with "}}" standing for a closing brace.  When a keyword
+// is repeated the first value is kept, as drivers use the first occurrence.
+func ParseConnectionString(s string) (*ConnectionString, error) {
+	cs := &ConnectionString{}
+	rest := s
+	for {
+		// Skip the separators, including empty attributes
+		rest = strings.TrimLeft(rest, " \t;")
+		if len(rest) == 0 {
+			break
+		}
+		position := len(s) - len(rest)
+
+		// Read the keyword
+		end := strings.IndexAny(rest, "=;")
+		if end < 0 || rest[end] != '=' {
+			return nil, fmt.Errorf("Connection string attribute at position %v has no '='", position)
+		}
+		key := strings.TrimSpace(rest[:end])
+		if !isValidKeyword(key) {
+			return nil, fmt.Errorf("Connection string attribute at position %v has an invalid keyword", position)
+		}
+		rest = strings.TrimLeft(rest[end+1:], " \t")
+
+		// Read the value, braced or up to the next semicolon
+		var value string
+		if strings.HasPrefix(rest, "{") {
+			var ok bool
+			value, rest, ok = parseBracedValue(rest)
+			if !ok {
+				return nil, fmt.Errorf("Connection string value of %v has no closing brace", key)
+			}
+			rest = strings.TrimLeft(rest, " \t")
+			if len(rest) > 0 && rest[0] != ';' {
+				return nil, fmt.Errorf("Connection string value of %v has text after the closing brace", key)
+			}
+		} else {
+			end = strings.IndexByte(rest, ';')
+			if end < 0 {
+				end = len(rest)
+			}
+			value = strings.TrimSpace(rest[:end])
+			rest = rest[end:]
+		}
+
+		if cs.index(key) < 0 {
+			cs.attrs = append(cs.attrs, connAttr{key: key, value: value})
+		}
+	}
+	return cs, nil
+}
+
+// Reads a value enclosed in braces from the start of s, returning the value and the text after it
+func parseBracedValue(s string) (string, string, bool) {
+	var value strings.Builder
+	for i := 1; i < len(s); i++ {
+		if s[i] != '}' {
+			value.WriteByte(s[i])
+		} else if i+1 < len(s) && s[i+1] == '}' {
+			value.WriteByte('}')
+			i++
+		} else {
+			return value.String(), s[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// Returns the value of keyword and whether it is present
+func (cs *ConnectionString) Get(keyword string) (string, bool) {
+	if i := cs.index(keyword); i >= 0 {
+		return cs.attrs[i].value, true
+	}
+	return "", false
+}
+
+// Sets the value of keyword, replacing any current value in place.  Values may contain anything
+// and are quoted by Build, but keywords cannot be quoted, so a keyword that is empty, contains
+// '=', ';' or braces, or starts or ends with a space is rejected.
+func (cs *ConnectionString) Set(keyword string, value string) error {
+	if !isValidKeyword(keyword) {
+		return fmt.Errorf("Invalid connection string keyword %q", keyword)
+	}
+	cs.set(keyword, value)
+	return nil
+}
+
+// Sets the value of a valid keyword
+func (cs *ConnectionString) set(keyword string, value string) {
+	if i := cs.index(keyword); i >= 0 {
+		cs.attrs[i].value = value
+		return
+	}
+	cs.attrs = append(cs.attrs, connAttr{key: keyword, value: value})
+}
+
+// Removes keyword
+func (cs *ConnectionString) Delete(keyword string) {
+	if i := cs.index(keyword); i >= 0 {
+		cs.attrs = append(cs.attrs[:i], cs.attrs[i+1:]...)
+	}
+}
+
+// Returns the keywords in order
+func (cs *ConnectionString) Keywords() []string {
+	keys := make([]string, len(cs.attrs))
+	for i, attr := range cs.attrs {
+		keys[i] = attr.key
+	}
+	return keys
+}
+
+// Connects through the data source name dsn, removing any DRIVER and FILEDSN keywords,
+// since the driver manager would otherwise use whichever of them comes first
+func (cs *ConnectionString) SetDSN(dsn string) {
+	cs.Delete("DRIVER")
+	cs.Delete("FILEDSN")
+	cs.set("DSN", dsn)
+}
+
+// Connects with the driver named driverName, such as "ODBC Driver 18 for SQL Server",
+// without a data source name, removing any DSN and FILEDSN keywords
+func (cs *ConnectionString) SetDriver(driverName string) {
+	cs.Delete("DSN")
+	cs.Delete("FILEDSN")
+	cs.set("DRIVER", driverName)
+}
+
+// Sets every attribute of overrides, for example per environment settings over a base connection string.
+// A DSN, DRIVER or FILEDSN in overrides replaces the way cs connects.
+func (cs *ConnectionString) Merge(overrides *ConnectionString) {
+	for _, attr := range overrides.attrs {
+		switch strings.ToUpper(attr.key) {
+		case "DSN":
+			cs.SetDSN(attr.value)
+		case "DRIVER":
+			cs.SetDriver(attr.value)
+		case "FILEDSN":
+			cs.Delete("DSN")
+			cs.Delete("DRIVER")
+			cs.set(attr.key, attr.value)
+		default:
+			cs.set(attr.key, attr.value)
+		}
+	}
+}
+
+// Returns the connection string to pass to SQLDriverConnect, quoting values with braces where needed
+func (cs *ConnectionString) Build() string {
+	return cs.build(false)
+}
+
+// Returns the connection string with the passwords replaced, so it can be logged
+func (cs *ConnectionString) String() string {
+	return cs.build(true)
+}
+
+// Serializes the attributes, replacing the password values if redact is true
+func (cs *ConnectionString) build(redact bool) string {
+	var b strings.Builder
+	for i, attr := range cs.attrs {
+		if i > 0 {
+			b.WriteByte(';')
+		}
+		b.WriteString(attr.key)
+		b.WriteByte('=')
+		if redact && isPasswordKeyword(attr.key) {
+			b.WriteString(redactedValue)
+		} else {
+			b.WriteString(connStringValue(attr.value))
+		}
+	}
+	return b.String()
+}
+
+// Returns the position of keyword, or -1
+func (cs *ConnectionString) index(keyword string) int {
+	for i, attr := range cs.attrs {
+		if strings.EqualFold(attr.key, keyword) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Reports whether keyword can be written to a connection string, where it is never quoted
+func isValidKeyword(keyword string) bool {
+	return len(keyword) > 0 && strings.TrimSpace(keyword) == keyword && !strings.ContainsAny(keyword, "=;{}")
+}
+
+// Reports whether the value of keyword is a password
+func isPasswordKeyword(keyword string) bool {
+	return strings.EqualFold(keyword, "PWD") || strings.EqualFold(keyword, "PASSWORD")
+}
+
+// Quotes a connection string attribute value with braces if it contains characters that would end it
+func connStringValue(value string) string {
+	if !strings.ContainsAny(value, ";{}=") && strings.TrimSpace(value) == value {
+		return value
+	}
+	return "{" + strings.Replace(value, "}", "}}", -1) + "}"
+}
@@ -0,0 +1,126 @@
+package lodbc_test
+
+import (
+	"github.com/LukeMauldin/lodbc"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConnectionStringRoundTrip(t *testing.T) {
+	tests := []struct {
+		input    string
+		values   [][2]string
+		build    string
+		redacted string
+	}{
+		{"DSN=Sales;UID=app", [][2]string{{"DSN", "Sales"}, {"UID", "app"}}, "DSN=Sales;UID=app", "DSN=Sales;UID=app"},
+		{" ; DSN = Sales ;;UID=app; ", [][2]string{{"DSN", "Sales"}, {"UID", "app"}}, "DSN=Sales;UID=app", "DSN=Sales;UID=app"},
+		{"DRIVER={ODBC Driver 18 for SQL Server};Server=db", [][2]string{{"DRIVER", "ODBC Driver 18 for SQL Server"}, {"Server", "db"}}, "DRIVER=ODBC Driver 18 for SQL Server;Server=db", "DRIVER=ODBC Driver 18 for SQL Server;Server=db"},
+		{"PWD={a;b};UID=app", [][2]string{{"PWD", "a;b"}, {"UID", "app"}}, "PWD={a;b};UID=app", "PWD=*****;UID=app"},
+		{"pwd={x}}y}", [][2]string{{"pwd", "x}y"}}, "pwd={x}}y}", "pwd=*****"},
+		{"Password={{}}}", [][2]string{{"Password", "{}"}}, "Password={{}}}", "Password=*****"},
+		{"App=a=b", [][2]string{{"App", "a=b"}}, "App={a=b}", "App={a=b}"},
+		{"App={ padded }", [][2]string{{"App", " padded "}}, "App={ padded }", "App={ padded }"},
+		{"DSN=first;dsn=second", [][2]string{{"DSN", "first"}}, "DSN=first", "DSN=first"},
+		{"App=", [][2]string{{"App", ""}}, "App=", "App="},
+		{"", nil, "", ""},
+	}
+	for _, test := range tests {
+		cs, err := lodbc.ParseConnectionString(test.input)
+		if err != nil {
+			t.Errorf("ParseConnectionString(%q): %v", test.input, err)
+			continue
+		}
+		var values [][2]string
+		for _, keyword := range cs.Keywords() {
+			value, _ := cs.Get(keyword)
+			values = append(values, [2]string{keyword, value})
+		}
+		if !reflect.DeepEqual(values, test.values) {
+			t.Errorf("ParseConnectionString(%q) = %q, want %q", test.input, values, test.values)
+		}
+		if got := cs.Build(); got != test.build {
+			t.Errorf("Build of %q = %q, want %q", test.input, got, test.build)
+		}
+		if got := cs.String(); got != test.redacted {
+			t.Errorf("String of %q = %q, want %q", test.input, got, test.redacted)
+		}
+
+		//The built string parses back to the same attributes
+		again, err := lodbc.ParseConnectionString(cs.Build())
+		if err != nil {
+			t.Errorf("ParseConnectionString(%q): %v", cs.Build(), err)
+			continue
+		}
+		if again.Build() != cs.Build() {
+			t.Errorf("%q built %q, then %q", test.input, cs.Build(), again.Build())
+		}
+	}
+}
+
+func TestConnectionStringRejectsMalformedInput(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"DSN", "has no '='"},
+		{"DSN=Sales;UID", "has no '='"},
+		{"=Sales", "invalid keyword"},
+		{"{DSN}=Sales", "invalid keyword"},
+		{"PWD={secret", "no closing brace"},
+		{"PWD={secret}}", "no closing brace"},
+		{"PWD={secret} x;UID=app", "text after the closing brace"},
+	}
+	for _, test := range tests {
+		_, err := lodbc.ParseConnectionString(test.input)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("ParseConnectionString(%q) returned %v, want an error containing %q", test.input, err, test.err)
+		}
+		if err != nil && strings.Contains(err.Error(), "secret") {
+			t.Errorf("error %q contains the password", err)
+		}
+	}
+}
+
+func TestConnectionStringSetMergeAndDelete(t *testing.T) {
+	cs, err := lodbc.ParseConnectionString("DSN=Sales;UID=app;PWD=old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.Set("pwd", "new;value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.Set("App", "report"); err != nil {
+		t.Fatal(err)
+	}
+	overrides, err := lodbc.ParseConnectionString("DRIVER={SQL Server};UID=admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs.Merge(overrides)
+	cs.Delete("App")
+	if got, want := cs.Build(), "UID=admin;PWD={new;value};DRIVER=SQL Server"; got != want {
+		t.Errorf("Build = %q, want %q", got, want)
+	}
+	if got, want := cs.String(), "UID=admin;PWD=*****;DRIVER=SQL Server"; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+}
+
+func TestConnectionStringSetRejectsInvalidKeywords(t *testing.T) {
+	cs, err := lodbc.ParseConnectionString("DSN=Sales;UID=app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Keywords are written unquoted, so one that would end the attribute could add another such as PWD
+	for _, keyword := range []string{"", " APP", "X;PWD", "X=Y", "{APP}"} {
+		if err := cs.Set(keyword, "value"); err == nil {
+			t.Errorf("Set accepted the keyword %q", keyword)
+		}
+	}
+	if got, want := cs.Build(), "DSN=Sales;UID=app"; got != want {
+		t.Errorf("Build = %q, want %q", got, want)
+	}
+}
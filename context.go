@@ -0,0 +1,76 @@
+package lodbc
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"time"
+)
+
+// Watches ctx while a statement executes and calls SQLCancel on the statement handle if ctx is done first.
+// The returned function stops watching and reports whether SQLCancel was called; it must be called once
+// the ODBC call returns so that the statement handle is never cancelled after it has been reused.
+func watchContext(ctx context.Context, api odbc.API, handle odbc.SQLHandle) func() bool {
+	//Nothing to watch if the context can never be cancelled
+	if ctx.Done() == nil {
+		return func() bool { return false }
+	}
+
+	stop := make(chan struct{})
+	cancelled := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			api.SQLCancel(handle)
+			cancelled <- true
+		case <-stop:
+			cancelled <- false
+		}
+	}()
+
+	return func() bool {
+		close(stop)
+		return <-cancelled
+	}
+}
+
+// Query timeout in seconds for a statement executed with ctx -- the time remaining until the
+// ctx deadline rounded up, limited by the connection's query timeout.  0 means no timeout.
+func contextQueryTimeout(ctx context.Context, timeout time.Duration) int {
+	seconds := timeoutSeconds(timeout)
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return seconds
+	}
+
+	remaining := timeoutSeconds(time.Until(deadline))
+	if remaining < 1 {
+		remaining = 1
+	}
+	if seconds > 0 && seconds < remaining {
+		return seconds
+	}
+	return remaining
+}
+
+// Converts named values to ordinal values -- ODBC parameter markers are positional
+func namedValuesToValues(named []driver.NamedValue) ([]driver.Value, error) {
+	args := make([]driver.Value, len(named))
+	for index, value := range named {
+		if len(value.Name) > 0 {
+			return nil, fmt.Errorf("Named parameters are not supported: %v", value.Name)
+		}
+		args[index] = value.Value
+	}
+	return args, nil
+}
+
+// Converts timeout to the whole seconds of SQL_ATTR_QUERY_TIMEOUT and SQL_ATTR_LOGIN_TIMEOUT, rounded up
+// so a timeout below a second is not 0, which means no timeout.  Timeouts of 0 or less are 0.
+func timeoutSeconds(timeout time.Duration) int {
+	if timeout <= 0 {
+		return 0
+	}
+	return int((timeout + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,45 @@
+package lodbc_test
+
+import (
+	"context"
+	"github.com/LukeMauldin/lodbc"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"github.com/LukeMauldin/lodbc/odbc/fake"
+	"testing"
+	"time"
+)
+
+func TestQueryTimeoutsRoundUpToWholeSeconds(t *testing.T) {
+	tests := []struct {
+		queryTimeout time.Duration
+		ctxTimeout   time.Duration
+		want         odbc.SQLPOINTER
+	}{
+		{0, 0, 0},
+		{500 * time.Millisecond, 0, 1},
+		{time.Second, 0, 1},
+		{1500 * time.Millisecond, 0, 2},
+		{0, 300 * time.Millisecond, 1},
+		{10 * time.Second, 2500 * time.Millisecond, 3},
+		{1500 * time.Millisecond, time.Minute, 2},
+	}
+	for _, test := range tests {
+		dm := fake.New()
+		dm.SetResponse("update t", &fake.Response{})
+		db := openDB(t, dm, func(config *lodbc.Config) {
+			config.QueryTimeout = test.queryTimeout
+		})
+		ctx := context.Background()
+		if test.ctxTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, test.ctxTimeout)
+			defer cancel()
+		}
+		if _, err := db.ExecContext(ctx, "update t"); err != nil {
+			t.Fatal(err)
+		}
+		if got := dm.Executions()[0].StmtAttrs[odbc.SQL_ATTR_QUERY_TIMEOUT]; got != test.want {
+			t.Errorf("query timeout %v with a context timeout of %v set %v seconds, want %v", test.queryTimeout, test.ctxTimeout, got, test.want)
+		}
+	}
+}
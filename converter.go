@@ -0,0 +1,355 @@
+package lodbc
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// Settings of the connection or query a value is converted for
+type ConvertContext struct {
+	// Location DATE, TIME and TIMESTAMP values are read in and timestamp parameters are converted to
+	Location *time.Location
+
+	// Format NUMERIC and DECIMAL columns are returned in
+	DecimalFormat DecimalFormat
+}
+
+// Parameter converted to the C buffer bound with SQLBindParameter
+type EncodedParameter struct {
+	CType         odbc.CDataType
+	SQLType       odbc.SQLDataType
+	ColumnSize    int
+	DecimalDigits int
+
+	// Value in the layout of CType -- UTF-16 without a NUL terminator for SQL_C_WCHAR.  Its length is passed
+	// as the length indicator.
+	Data []byte
+}
+
+// Converts a non-nil input parameter of a registered Go type.  parameter.Data holds the value, with any
+// pointer the type was not registered as removed, and the other fields the BindParameter settings.
+type ParameterEncoder func(parameter BindParameter, ctx ConvertContext) (EncodedParameter, error)
+
+// Reads the columns of a SQL type or type name.  The value is read as CType, and Decode is not called for NULL values.
+type ColumnDecoder struct {
+	// C type the value is read as, such as odbc.SQL_C_BINARY or odbc.SQL_C_WCHAR
+	CType odbc.CDataType
+
+	// Size in bytes of a fixed size C type, such as 4 for SQL_C_LONG -- 0 for text and binary values, which are
+	// read whole.  Columns with a fixed size or a length that fits the fetch buffers are fetched a rowset at a time.
+	Size int
+
+	// Converts the value read -- UTF-8 text for SQL_C_WCHAR and SQL_C_CHAR, otherwise the bytes of the C type.
+	// Fixed size values point into the fetch buffers and must not be kept after Decode returns.
+	Decode func(data []byte, ctx ConvertContext) (driver.Value, error)
+
+	// Type of the values Decode returns, reported by ColumnTypeScanType -- nil reports interface{}
+	ScanType reflect.Type
+
+	// Registered by the driver -- only built-in decoders stream a last LOB column as a *LOB
+	isBuiltin bool
+}
+
+// Parameter encoder registered for a Go type
+type parameterEncoderEntry struct {
+	encode    ParameterEncoder
+	isBuiltin bool
+}
+
+// Converters registered by Go type, SQL type and data source type name, starting with the built-in ones
+var (
+	convertersMu      sync.RWMutex
+	parameterEncoders = make(map[reflect.Type]parameterEncoderEntry)
+	columnDecoders    = make(map[odbc.SQLDataType]ColumnDecoder)
+	typeNameDecoders  = make(map[string]ColumnDecoder)
+)
+
+// Registers encoder for parameters of valueType, such as reflect.TypeOf(uuid.UUID{}), replacing the built-in
+// conversion of the type if it has one.  Values of registered types are passed to the driver unchanged,
+// without calling their Value method.
+func RegisterParameterEncoder(valueType reflect.Type, encoder ParameterEncoder) {
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	parameterEncoders[valueType] = parameterEncoderEntry{encode: encoder}
+}
+
+// Registers decoder for the columns of sqlType, such as odbc.SQL_SS_UDT, in the queries executed afterwards,
+// replacing the built-in decoding of the type if it has one
+func RegisterColumnDecoder(sqlType odbc.SQLDataType, decoder ColumnDecoder) {
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	decoder.isBuiltin = false
+	columnDecoders[sqlType] = decoder
+}
+
+// Registers decoder for the columns whose data source type name is typeName, such as "geography", in the
+// queries executed afterwards.  Names are matched without regard to case and take precedence over SQL types.
+func RegisterColumnTypeDecoder(typeName string, decoder ColumnDecoder) {
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	decoder.isBuiltin = false
+	typeNameDecoders[strings.ToLower(typeName)] = decoder
+}
+
+// Returns the encoder for value and the value to pass it, removing pointers until a registered type is found.
+// Returns a nil encoder if there is none.
+func parameterEncoderFor(value interface{}) (*parameterEncoderEntry, interface{}) {
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+	v := reflect.ValueOf(value)
+	for v.IsValid() {
+		if entry, ok := parameterEncoders[v.Type()]; ok {
+			return &entry, v.Interface()
+		}
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			break
+		}
+		v = v.Elem()
+	}
+	return nil, value
+}
+
+// Returns the decoder for a result column, by type name and then SQL type, or nil if there is none
+func columnDecoderFor(def resultColumnDef) *ColumnDecoder {
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+	decoder, ok := typeNameDecoders[strings.ToLower(def.TypeName)]
+	if !ok || def.TypeName == "" {
+		decoder, ok = columnDecoders[def.DataType]
+	}
+	if !ok {
+		return nil
+	}
+	return &decoder
+}
+
+// Returns the decoders of the columns of a result set -- nil for columns of unknown types
+func resultColumnDecoders(defs []resultColumnDef) []*ColumnDecoder {
+	decoders := make([]*ColumnDecoder, len(defs))
+	for index, def := range defs {
+		decoders[index] = columnDecoderFor(def)
+	}
+	return decoders
+}
+
+// Registers the built-in conversions
+func init() {
+	builtinEncoders := map[reflect.Type]ParameterEncoder{
+		reflect.TypeOf(false):        encodeBool,
+		reflect.TypeOf(int(0)):       encodeInt,
+		reflect.TypeOf(int64(0)):     encodeInt64,
+		reflect.TypeOf(float64(0)):   encodeFloat64,
+		reflect.TypeOf(""):           encodeString,
+		reflect.TypeOf([]byte(nil)):  encodeBytes,
+		reflect.TypeOf(time.Time{}):  encodeTime,
+		reflect.TypeOf(big.Rat{}):    encodeDecimal,
+		reflect.TypeOf(new(big.Rat)): encodeDecimal,
+		reflect.TypeOf(big.Int{}):    encodeDecimal,
+		reflect.TypeOf(new(big.Int)): encodeDecimal,
+	}
+	for valueType, encoder := range builtinEncoders {
+		parameterEncoders[valueType] = parameterEncoderEntry{encode: encoder, isBuiltin: true}
+	}
+
+	builtinDecoders := []struct {
+		sqlTypes []odbc.SQLDataType
+		decoder  ColumnDecoder
+	}{
+		{[]odbc.SQLDataType{odbc.SQL_BIT}, ColumnDecoder{CType: odbc.SQL_C_BIT, Size: 1, Decode: decodeBool}},
+		{[]odbc.SQLDataType{odbc.SQL_INTEGER, odbc.SQL_SMALLINT, odbc.SQL_TINYINT}, ColumnDecoder{CType: odbc.SQL_C_LONG, Size: 4, Decode: decodeInt}},
+		{[]odbc.SQLDataType{odbc.SQL_BIGINT}, ColumnDecoder{CType: odbc.SQL_C_SBIGINT, Size: 8, Decode: decodeInt64}},
+		{[]odbc.SQLDataType{odbc.SQL_FLOAT, odbc.SQL_DOUBLE, odbc.SQL_REAL}, ColumnDecoder{CType: odbc.SQL_C_DOUBLE, Size: 8, Decode: decodeFloat64}},
+		{[]odbc.SQLDataType{odbc.SQL_NUMERIC, odbc.SQL_DECIMAL}, ColumnDecoder{CType: odbc.SQL_C_NUMERIC, Size: int(unsafe.Sizeof(odbc.SQL_NUMERIC_STRUCT{})), Decode: decodeNumeric}},
+		{[]odbc.SQLDataType{odbc.SQL_CHAR, odbc.SQL_VARCHAR, odbc.SQL_LONGVARCHAR, odbc.SQL_WCHAR, odbc.SQL_WVARCHAR, odbc.SQL_WLONGVARCHAR, odbc.SQL_SS_XML}, ColumnDecoder{CType: odbc.SQL_C_WCHAR, Decode: decodeString}},
+		{[]odbc.SQLDataType{odbc.SQL_BINARY, odbc.SQL_VARBINARY, odbc.SQL_LONGVARBINARY}, ColumnDecoder{CType: odbc.SQL_C_BINARY, Decode: decodeBytes}},
+		{[]odbc.SQLDataType{odbc.SQL_GUID}, ColumnDecoder{CType: odbc.SQL_C_GUID, Size: int(unsafe.Sizeof(odbc.SQLGUID{})), Decode: decodeGUID}},
+	}
+	for _, builtin := range builtinDecoders {
+		for _, sqlType := range builtin.sqlTypes {
+			decoder := builtin.decoder
+			decoder.isBuiltin = true
+			columnDecoders[sqlType] = decoder
+		}
+	}
+	for _, sqlType := range []odbc.SQLDataType{odbc.SQL_TYPE_DATE, odbc.SQL_TYPE_TIME, odbc.SQL_TYPE_TIMESTAMP, odbc.SQL_SS_TIME2, odbc.SQL_SS_TIMESTAMPOFFSET} {
+		cType, _ := timeColumnType(sqlType)
+		columnDecoders[sqlType] = ColumnDecoder{CType: cType, Size: timeStructSize(cType), Decode: timeDecoder(cType), isBuiltin: true}
+	}
+}
+
+// Returns a buffer holding the size bytes at p
+func bytesOf(p unsafe.Pointer, size int) []byte {
+	data := alignedBuffer(size)
+	copy(data, unsafe.Slice((*byte)(p), size))
+	return data
+}
+
+func encodeBool(parameter BindParameter, ctx ConvertContext) (EncodedParameter, error) {
+	data := []byte{0}
+	if parameter.Data.(bool) {
+		data[0] = 1
+	}
+	return EncodedParameter{CType: odbc.SQL_C_BIT, SQLType: odbc.SQL_BIT, Data: data}, nil
+}
+
+// Binds an int as an INTEGER, or a BIGINT if it does not fit 32 bits
+func encodeInt(parameter BindParameter, ctx ConvertContext) (EncodedParameter, error) {
+	value := parameter.Data.(int)
+	if value < math.MinInt32 || value > math.MaxInt32 {
+		return encodeInt64(BindParameter{Data: int64(value)}, ctx)
+	}
+	v := int32(value)
+	return EncodedParameter{CType: odbc.SQL_C_LONG, SQLType: odbc.SQL_INTEGER, Data: bytesOf(unsafe.Pointer(&v), 4)}, nil
+}
+
+func encodeInt64(parameter BindParameter, ctx ConvertContext) (EncodedParameter, error) {
+	v := parameter.Data.(int64)
+	return EncodedParameter{CType: odbc.SQL_C_SBIGINT, SQLType: odbc.SQL_BIGINT, Data: bytesOf(unsafe.Pointer(&v), 8)}, nil
+}
+
+// Binds a float64 as a DOUBLE, or exactly as a DECIMAL if a precision was given
+func encodeFloat64(parameter BindParameter, ctx ConvertContext) (EncodedParameter, error) {
+	if parameter.Precision > 0 {
+		return encodeDecimal(parameter, ctx)
+	}
+	v := parameter.Data.(float64)
+	return EncodedParameter{CType: odbc.SQL_C_DOUBLE, SQLType: odbc.SQL_DOUBLE, Data: bytesOf(unsafe.Pointer(&v), 8)}, nil
+}
+
+// Binds a string as UTF-16 text, or exactly as a DECIMAL if a precision was given
+func encodeString(parameter BindParameter, ctx ConvertContext) (EncodedParameter, error) {
+	if parameter.Precision > 0 {
+		return encodeDecimal(parameter, ctx)
+	}
+	value := parameter.Data.(string)
+	length := parameter.Length
+	if length == 0 {
+		length = len(value)
+	}
+	sqlType := odbc.SQL_VARCHAR
+	if length >= 4000 {
+		sqlType = odbc.SQL_LONGVARCHAR
+	}
+	units := utf16.Encode([]rune(value))
+	var data []byte
+	if len(units) > 0 {
+		data = bytesOf(unsafe.Pointer(&units[0]), len(units)*2)
+	}
+	return EncodedParameter{CType: odbc.SQL_C_WCHAR, SQLType: sqlType, ColumnSize: length, Data: data}, nil
+}
+
+// Binds a []byte as a VARBINARY of the parameter's length, or of the length of the value if it is longer
+func encodeBytes(parameter BindParameter, ctx ConvertContext) (EncodedParameter, error) {
+	value := parameter.Data.([]byte)
+	length := parameter.Length
+	if length < len(value) {
+		length = len(value)
+	}
+	sqlType := odbc.SQL_VARBINARY
+	if length > 4000 {
+		sqlType = odbc.SQL_LONGVARBINARY
+	}
+	return EncodedParameter{CType: odbc.SQL_C_BINARY, SQLType: sqlType, ColumnSize: length, Data: value}, nil
+}
+
+// Binds a time.Time as a DATE, TIME, TIMESTAMP or DATETIMEOFFSET -- see timeParameterBinding
+func encodeTime(parameter BindParameter, ctx ConvertContext) (EncodedParameter, error) {
+	binding := timeParameterBinding(parameter)
+	data := alignedBuffer(timeStructSize(binding.cType))
+	binding.write(unsafe.Pointer(&data[0]), parameter.Data.(time.Time), ctx.Location)
+	return EncodedParameter{CType: binding.cType, SQLType: binding.sqlType, ColumnSize: binding.columnSize, DecimalDigits: binding.decimalDigits, Data: data}, nil
+}
+
+// Binds a big.Rat, big.Int, float64 or decimal string exactly as a DECIMAL with the parameter's precision and
+// scale, or the ones of the value if no precision was given
+func encodeDecimal(parameter BindParameter, ctx ConvertContext) (EncodedParameter, error) {
+	var value *big.Rat
+	switch data := parameter.Data.(type) {
+	case big.Rat:
+		value = &data
+	case *big.Rat:
+		value = data
+	case big.Int:
+		value = new(big.Rat).SetInt(&data)
+	case *big.Int:
+		value = new(big.Rat).SetInt(data)
+	case float64:
+		var err error
+		value, err = floatToRat(data)
+		if err != nil {
+			return EncodedParameter{}, err
+		}
+	case string:
+		var ok bool
+		value, ok = parseDecimalText(data)
+		if !ok {
+			return EncodedParameter{}, fmt.Errorf("Invalid decimal: %v", data)
+		}
+	}
+	precision, scale := parameter.Precision, parameter.Scale
+	if precision == 0 {
+		if scale == 0 {
+			scale = decimalScale(value)
+		}
+		precision = decimalPrecision(value, scale)
+	}
+	numeric, err := ratToNumeric(value, precision, scale)
+	if err != nil {
+		return EncodedParameter{}, err
+	}
+	return EncodedParameter{CType: odbc.SQL_C_NUMERIC, SQLType: odbc.SQL_DECIMAL, ColumnSize: precision, DecimalDigits: scale,
+		Data: bytesOf(unsafe.Pointer(&numeric), int(unsafe.Sizeof(numeric)))}, nil
+}
+
+func decodeBool(data []byte, ctx ConvertContext) (driver.Value, error) {
+	return data[0] != 0, nil
+}
+
+func decodeInt(data []byte, ctx ConvertContext) (driver.Value, error) {
+	return int(*(*int32)(unsafe.Pointer(&data[0]))), nil
+}
+
+func decodeInt64(data []byte, ctx ConvertContext) (driver.Value, error) {
+	return *(*int64)(unsafe.Pointer(&data[0])), nil
+}
+
+func decodeFloat64(data []byte, ctx ConvertContext) (driver.Value, error) {
+	return *(*float64)(unsafe.Pointer(&data[0])), nil
+}
+
+// Returns a NUMERIC or DECIMAL as a float64 or exact string, depending on the DecimalFormat
+func decodeNumeric(data []byte, ctx ConvertContext) (driver.Value, error) {
+	value := *(*odbc.SQL_NUMERIC_STRUCT)(unsafe.Pointer(&data[0]))
+	if ctx.DecimalFormat == DecimalString {
+		return numericToString(value), nil
+	}
+	return numericToFloat(value), nil
+}
+
+func decodeString(data []byte, ctx ConvertContext) (driver.Value, error) {
+	return string(data), nil
+}
+
+func decodeBytes(data []byte, ctx ConvertContext) (driver.Value, error) {
+	return data, nil
+}
+
+func decodeGUID(data []byte, ctx ConvertContext) (driver.Value, error) {
+	return guidFromStruct(*(*odbc.SQLGUID)(unsafe.Pointer(&data[0]))).String(), nil
+}
+
+// Returns the decoder of a date or time C structure -- see readTime
+func timeDecoder(cType odbc.CDataType) func(data []byte, ctx ConvertContext) (driver.Value, error) {
+	return func(data []byte, ctx ConvertContext) (driver.Value, error) {
+		return readTime(cType, unsafe.Pointer(&data[0]), ctx.Location), nil
+	}
+}
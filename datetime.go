@@ -0,0 +1,135 @@
+package lodbc
+
+import (
+	"github.com/LukeMauldin/lodbc/odbc"
+	"time"
+	"unsafe"
+)
+
+// Location of DATE, TIME and TIMESTAMP values for new connections -- see SetLocation
+var location = time.UTC
+
+// Fractional second digits of bound TIME, TIMESTAMP and DATETIMEOFFSET parameters -- 100 nanoseconds, as in datetime2(7)
+const timeFractionDigits = 7
+
+// Sets the location DATE, TIME and TIMESTAMP values, which have no time zone, are interpreted in for connections
+// opened afterwards.  Values read are returned in loc, and time.Time parameters bound as timestamps are converted
+// to loc before they are sent.  Defaults to time.UTC.  DATETIMEOFFSET values keep their own offset.
+func SetLocation(loc *time.Location) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	location = loc
+}
+
+// C and SQL types a time.Time parameter is bound with
+type timeBinding struct {
+	cType         odbc.CDataType
+	sqlType       odbc.SQLDataType
+	columnSize    int
+	decimalDigits int
+
+	// The value is a timestamp without a time zone, converted to the connection location before it is sent
+	isNaive bool
+}
+
+// Returns the binding for a time.Time parameter -- DateOnly binds a DATE, TimeOnly a TIME, with WithFraction
+// a SQL Server time2, WithOffset a DATETIMEOFFSET and otherwise a TIMESTAMP
+func timeParameterBinding(parameter BindParameter) timeBinding {
+	switch {
+	case parameter.DateOnly:
+		return timeBinding{cType: odbc.SQL_C_DATE, sqlType: odbc.SQL_DATE, columnSize: 10}
+	case parameter.TimeOnly && !parameter.WithFraction:
+		return timeBinding{cType: odbc.SQL_C_TIME, sqlType: odbc.SQL_TYPE_TIME, columnSize: 8}
+	case parameter.TimeOnly:
+		//SQL_TYPE_TIME has no fractional seconds, and a timestamp with them converted to it fails with 22008
+		return timeBinding{cType: odbc.SQL_C_SS_TIME2, sqlType: odbc.SQL_SS_TIME2, columnSize: 9 + timeFractionDigits, decimalDigits: timeFractionDigits}
+	case parameter.WithOffset:
+		return timeBinding{cType: odbc.SQL_C_SS_TIMESTAMPOFFSET, sqlType: odbc.SQL_SS_TIMESTAMPOFFSET, columnSize: 27 + timeFractionDigits, decimalDigits: timeFractionDigits}
+	}
+	return timeBinding{cType: odbc.SQL_C_TIMESTAMP, sqlType: odbc.SQL_TIMESTAMP, columnSize: 20 + timeFractionDigits, decimalDigits: timeFractionDigits, isNaive: true}
+}
+
+// Writes t to the C structure at p, converting timestamps to loc.  Dates and times keep the calendar
+// date and clock time of t.
+func (binding timeBinding) write(p unsafe.Pointer, t time.Time, loc *time.Location) {
+	if binding.isNaive {
+		t = t.In(loc)
+	}
+	writeTime(binding.cType, p, t)
+}
+
+// Returns the C type to read a date or time column with, or false if the column is not one
+func timeColumnType(dataType odbc.SQLDataType) (odbc.CDataType, bool) {
+	switch dataType {
+	case odbc.SQL_TYPE_DATE:
+		return odbc.SQL_C_DATE, true
+	case odbc.SQL_TYPE_TIME:
+		return odbc.SQL_C_TIME, true
+	case odbc.SQL_TYPE_TIMESTAMP:
+		return odbc.SQL_C_TIMESTAMP, true
+	case odbc.SQL_SS_TIME2:
+		return odbc.SQL_C_SS_TIME2, true
+	case odbc.SQL_SS_TIMESTAMPOFFSET:
+		return odbc.SQL_C_SS_TIMESTAMPOFFSET, true
+	}
+	return 0, false
+}
+
+// Size in bytes of the C structure of a date or time C type
+func timeStructSize(cType odbc.CDataType) int {
+	switch cType {
+	case odbc.SQL_C_DATE:
+		return int(unsafe.Sizeof(odbc.SQL_DATE_STRUCT{}))
+	case odbc.SQL_C_TIME:
+		return int(unsafe.Sizeof(odbc.SQL_TIME_STRUCT{}))
+	case odbc.SQL_C_SS_TIME2:
+		return int(unsafe.Sizeof(odbc.SQL_SS_TIME2_STRUCT{}))
+	case odbc.SQL_C_SS_TIMESTAMPOFFSET:
+		return int(unsafe.Sizeof(odbc.SQL_SS_TIMESTAMPOFFSET_STRUCT{}))
+	}
+	return int(unsafe.Sizeof(odbc.SQL_TIMESTAMP_STRUCT{}))
+}
+
+// Writes t to the C structure of cType at p, with the fraction truncated to 100 nanoseconds.
+// DATETIMEOFFSET keeps the offset of t.
+func writeTime(cType odbc.CDataType, p unsafe.Pointer, t time.Time) {
+	fraction := odbc.SQLUINTEGER(t.Nanosecond() - t.Nanosecond()%100)
+	switch cType {
+	case odbc.SQL_C_DATE:
+		*(*odbc.SQL_DATE_STRUCT)(p) = odbc.SQL_DATE_STRUCT{Year: odbc.SQLSMALLINT(t.Year()), Month: odbc.SQLUSMALLINT(t.Month()), Day: odbc.SQLUSMALLINT(t.Day())}
+	case odbc.SQL_C_TIME:
+		*(*odbc.SQL_TIME_STRUCT)(p) = odbc.SQL_TIME_STRUCT{Hour: odbc.SQLUSMALLINT(t.Hour()), Minute: odbc.SQLUSMALLINT(t.Minute()), Second: odbc.SQLUSMALLINT(t.Second())}
+	case odbc.SQL_C_SS_TIME2:
+		*(*odbc.SQL_SS_TIME2_STRUCT)(p) = odbc.SQL_SS_TIME2_STRUCT{Hour: odbc.SQLUSMALLINT(t.Hour()), Minute: odbc.SQLUSMALLINT(t.Minute()), Second: odbc.SQLUSMALLINT(t.Second()), Fraction: fraction}
+	case odbc.SQL_C_SS_TIMESTAMPOFFSET:
+		_, offset := t.Zone()
+		*(*odbc.SQL_SS_TIMESTAMPOFFSET_STRUCT)(p) = odbc.SQL_SS_TIMESTAMPOFFSET_STRUCT{Year: odbc.SQLSMALLINT(t.Year()), Month: odbc.SQLUSMALLINT(t.Month()), Day: odbc.SQLUSMALLINT(t.Day()),
+			Hour: odbc.SQLUSMALLINT(t.Hour()), Minute: odbc.SQLUSMALLINT(t.Minute()), Second: odbc.SQLUSMALLINT(t.Second()), Fraction: fraction,
+			TimezoneHour: odbc.SQLSMALLINT(offset / 3600), TimezoneMinute: odbc.SQLSMALLINT(offset % 3600 / 60)}
+	case odbc.SQL_C_TIMESTAMP:
+		*(*odbc.SQL_TIMESTAMP_STRUCT)(p) = odbc.SQL_TIMESTAMP_STRUCT{Year: odbc.SQLSMALLINT(t.Year()), Month: odbc.SQLUSMALLINT(t.Month()), Day: odbc.SQLUSMALLINT(t.Day()),
+			Hour: odbc.SQLUSMALLINT(t.Hour()), Minute: odbc.SQLUSMALLINT(t.Minute()), Second: odbc.SQLUSMALLINT(t.Second()), Fraction: fraction}
+	}
+}
+
+// Reads the C structure of cType at p.  Dates, times and timestamps are returned in loc, with times on
+// January 1 of year 1, and DATETIMEOFFSET values in a time.FixedZone with their offset.
+func readTime(cType odbc.CDataType, p unsafe.Pointer, loc *time.Location) time.Time {
+	switch cType {
+	case odbc.SQL_C_DATE:
+		value := *(*odbc.SQL_DATE_STRUCT)(p)
+		return time.Date(int(value.Year), time.Month(value.Month), int(value.Day), 0, 0, 0, 0, loc)
+	case odbc.SQL_C_TIME:
+		value := *(*odbc.SQL_TIME_STRUCT)(p)
+		return time.Date(1, 1, 1, int(value.Hour), int(value.Minute), int(value.Second), 0, loc)
+	case odbc.SQL_C_SS_TIME2:
+		value := *(*odbc.SQL_SS_TIME2_STRUCT)(p)
+		return time.Date(1, 1, 1, int(value.Hour), int(value.Minute), int(value.Second), int(value.Fraction), loc)
+	case odbc.SQL_C_SS_TIMESTAMPOFFSET:
+		value := *(*odbc.SQL_SS_TIMESTAMPOFFSET_STRUCT)(p)
+		zone := time.FixedZone("", int(value.TimezoneHour)*3600+int(value.TimezoneMinute)*60)
+		return time.Date(int(value.Year), time.Month(value.Month), int(value.Day), int(value.Hour), int(value.Minute), int(value.Second), int(value.Fraction), zone)
+	}
+	value := *(*odbc.SQL_TIMESTAMP_STRUCT)(p)
+	return time.Date(int(value.Year), time.Month(value.Month), int(value.Day), int(value.Hour), int(value.Minute), int(value.Second), int(value.Fraction), loc)
+}
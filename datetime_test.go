@@ -0,0 +1,143 @@
+package lodbc_test
+
+import (
+	"github.com/LukeMauldin/lodbc"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"github.com/LukeMauldin/lodbc/odbc/fake"
+	"testing"
+	"time"
+)
+
+// Reads value from a result set with one column of columnType, through a database opened with config
+func queryTime(t *testing.T, dm *fake.DriverManager, config func(*lodbc.Config), columnType odbc.SQLDataType, value time.Time) time.Time {
+	t.Helper()
+	dm.SetResponse("select value", &fake.Response{ResultSets: []*fake.ResultSet{{
+		Columns: []fake.Column{{Name: "value", Type: columnType, Scale: 7}},
+		Rows:    [][]interface{}{{value}},
+	}}})
+	var got time.Time
+	if err := openDB(t, dm, config).QueryRow("select value").Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestTimestampsKeepFractionalSeconds(t *testing.T) {
+	dm := fake.New()
+	dm.HandleDefault(func(e *fake.Execution) *fake.Response { return &fake.Response{} })
+	db := openDB(t, dm, nil)
+
+	//Parameters are sent with the fraction truncated to the 100 nanoseconds of datetime2(7)
+	value := time.Date(2023, 4, 5, 6, 7, 8, 123456789, time.UTC)
+	if _, err := db.Exec("insert into t values (?)", value); err != nil {
+		t.Fatal(err)
+	}
+	execution := dm.Executions()[0]
+	if sent := execution.Params[0].(time.Time); !sent.Equal(value.Truncate(100 * time.Nanosecond)) {
+		t.Errorf("sent %v, want %v", sent, value.Truncate(100*time.Nanosecond))
+	}
+	if bound := execution.ParamTypes[0]; bound.SQLType != odbc.SQL_TIMESTAMP || bound.ColumnSize != 27 || bound.DecimalDigits != 7 {
+		t.Errorf("bound as %+v", bound)
+	}
+
+	//Values read keep every digit the column returns
+	read := time.Date(2023, 4, 5, 6, 7, 8, 123456700, time.UTC)
+	if got := queryTime(t, dm, nil, odbc.SQL_TYPE_TIMESTAMP, read); !got.Equal(read) || got.Location() != time.UTC {
+		t.Errorf("read %v, want %v", got, read)
+	}
+}
+
+func TestTimestampsAreConvertedToTheConnectionLocation(t *testing.T) {
+	eastern := time.FixedZone("EST", -5*3600)
+	inEastern := func(config *lodbc.Config) {
+		config.Location = eastern
+	}
+	dm := fake.New()
+	dm.HandleDefault(func(e *fake.Execution) *fake.Response { return &fake.Response{} })
+
+	//A parameter is sent as the clock time in the connection location
+	value := time.Date(2023, 1, 2, 12, 0, 0, 0, time.UTC)
+	if _, err := openDB(t, dm, inEastern).Exec("insert into t values (?)", value); err != nil {
+		t.Fatal(err)
+	}
+	if sent := dm.Executions()[0].Params[0].(time.Time); sent.Hour() != 7 || sent.Day() != 2 {
+		t.Errorf("sent %v, want 07:00 on the same day", sent)
+	}
+
+	//A value read is the clock time in the connection location
+	got := queryTime(t, dm, inEastern, odbc.SQL_TYPE_TIMESTAMP, time.Date(2023, 1, 2, 7, 0, 0, 0, time.UTC))
+	if !got.Equal(value) || got.Location() != eastern {
+		t.Errorf("read %v, want %v in EST", got, value)
+	}
+	got = queryTime(t, dm, inEastern, odbc.SQL_TYPE_DATE, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC))
+	if !got.Equal(time.Date(2023, 1, 2, 0, 0, 0, 0, eastern)) {
+		t.Errorf("read the date %v, want midnight in EST", got)
+	}
+}
+
+func TestDateTimeOffsetsKeepTheirOffset(t *testing.T) {
+	india := time.FixedZone("IST", 5*3600+30*60)
+	value := time.Date(2023, 4, 5, 6, 7, 8, 900, india)
+	inEastern := func(config *lodbc.Config) {
+		config.Location = time.FixedZone("EST", -5*3600)
+	}
+	dm := fake.New()
+	dm.HandleDefault(func(e *fake.Execution) *fake.Response { return &fake.Response{} })
+
+	//Read in a FixedZone with the value's offset, not the connection location
+	got := queryTime(t, dm, inEastern, odbc.SQL_SS_TIMESTAMPOFFSET, value)
+	if !got.Equal(value) {
+		t.Errorf("read %v, want %v", got, value)
+	}
+	if _, offset := got.Zone(); offset != 5*3600+30*60 {
+		t.Errorf("read with offset %v, want +05:30", offset)
+	}
+
+	//Sent with its own offset
+	if _, err := openDB(t, dm, inEastern).Exec("insert into t values (?)", lodbc.NewParameterDateTimeOffset(value)); err != nil {
+		t.Fatal(err)
+	}
+	executions := dm.Executions()
+	execution := executions[len(executions)-1]
+	sent := execution.Params[0].(time.Time)
+	if _, offset := sent.Zone(); !sent.Equal(value) || offset != 5*3600+30*60 {
+		t.Errorf("sent %v, want %v", sent, value)
+	}
+	if bound := execution.ParamTypes[0]; bound.SQLType != odbc.SQL_SS_TIMESTAMPOFFSET || bound.DecimalDigits != 7 {
+		t.Errorf("bound as %+v", bound)
+	}
+}
+
+func TestDateAndTimeParameters(t *testing.T) {
+	dm := fake.New()
+	dm.HandleDefault(func(e *fake.Execution) *fake.Response { return &fake.Response{} })
+	db := openDB(t, dm, nil)
+
+	//Dates and times keep the calendar date and clock time of the value, without converting it to the connection location
+	value := time.Date(2023, 4, 5, 23, 30, 15, 250000000, time.FixedZone("EST", -5*3600))
+	if _, err := db.Exec("insert into t values (?, ?, ?)", lodbc.NewParameterDate(value), lodbc.NewParameterTime(value), lodbc.NewParameterTimeWithFraction(value)); err != nil {
+		t.Fatal(err)
+	}
+	execution := dm.Executions()[0]
+	date, clock, fraction := execution.Params[0].(time.Time), execution.Params[1].(time.Time), execution.Params[2].(time.Time)
+	if date.Year() != 2023 || date.Month() != 4 || date.Day() != 5 {
+		t.Errorf("sent the date %v, want 2023-04-05", date)
+	}
+	if clock.Hour() != 23 || clock.Minute() != 30 || clock.Second() != 15 || clock.Nanosecond() != 0 {
+		t.Errorf("sent the time %v, want 23:30:15", clock)
+	}
+	if fraction.Hour() != 23 || fraction.Minute() != 30 || fraction.Second() != 15 || fraction.Nanosecond() != 250000000 {
+		t.Errorf("sent the time %v, want 23:30:15.25", fraction)
+	}
+	if bound := execution.ParamTypes[0]; bound.CType != odbc.SQL_C_DATE || bound.SQLType != odbc.SQL_DATE || bound.ColumnSize != 10 {
+		t.Errorf("date bound as %+v", bound)
+	}
+
+	//Times are bound as the standard TIME, which has no fractional seconds, unless they are asked for with SQL Server time2
+	if bound := execution.ParamTypes[1]; bound.CType != odbc.SQL_C_TIME || bound.SQLType != odbc.SQL_TYPE_TIME || bound.ColumnSize != 8 || bound.DecimalDigits != 0 {
+		t.Errorf("time bound as %+v", bound)
+	}
+	if bound := execution.ParamTypes[2]; bound.CType != odbc.SQL_C_SS_TIME2 || bound.SQLType != odbc.SQL_SS_TIME2 || bound.ColumnSize != 16 || bound.DecimalDigits != 7 {
+		t.Errorf("time with fraction bound as %+v", bound)
+	}
+}
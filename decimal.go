@@ -0,0 +1,165 @@
+package lodbc
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Defines how NUMERIC and DECIMAL columns are returned
+type DecimalFormat int
+
+const (
+	// Return decimals as float64 -- may lose precision
+	DecimalFloat64 DecimalFormat = iota
+
+	// Return decimals as exact strings with the column's scale, for example "12.30".
+	// Scan into a string, a Decimal or a float64.
+	DecimalString
+)
+
+// Format of decimals for new connections
+var decimalFormat = DecimalFloat64
+
+// Sets the format NUMERIC and DECIMAL columns are returned in for connections opened afterwards.
+// Use the DecimalResultFormat query option to override it for a single query.
+func SetDecimalFormat(format DecimalFormat) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	decimalFormat = format
+}
+
+// Exact decimal number.  Scan NUMERIC and DECIMAL columns returned with DecimalString into
+// a Decimal, and pass a Decimal as a query argument to bind it as SQL_NUMERIC.
+type Decimal struct {
+	value big.Rat
+	scale int
+}
+
+// Parses decimal text such as "-12.340", keeping the number of digits after the decimal point as the scale
+func ParseDecimal(s string) (Decimal, error) {
+	var d Decimal
+	value, ok := parseDecimalText(s)
+	if !ok {
+		return Decimal{}, fmt.Errorf("Invalid decimal: %v", s)
+	}
+	d.value.Set(value)
+	d.scale = textScale(s)
+	if exact := decimalScale(&d.value); exact > d.scale {
+		d.scale = exact
+	}
+	return d, nil
+}
+
+// Creates a Decimal from a rational number, rounded to scale digits after the decimal point
+func NewDecimal(value *big.Rat, scale int) Decimal {
+	var d Decimal
+	d.value.SetString(value.FloatString(scale))
+	d.scale = scale
+	return d
+}
+
+// Returns the value as a rational number
+func (d Decimal) Rat() *big.Rat {
+	return new(big.Rat).Set(&d.value)
+}
+
+// Returns the number of digits after the decimal point
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// Returns the nearest float64 to the value
+func (d Decimal) Float64() float64 {
+	f, _ := d.value.Float64()
+	return f
+}
+
+// Returns the decimal text with Scale digits after the decimal point
+func (d Decimal) String() string {
+	return d.value.FloatString(d.scale)
+}
+
+// Implements sql.Scanner
+func (d *Decimal) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case string:
+		parsed, err := ParseDecimal(value)
+		if err != nil {
+			return err
+		}
+		*d = parsed
+	case []byte:
+		parsed, err := ParseDecimal(string(value))
+		if err != nil {
+			return err
+		}
+		*d = parsed
+	case int64:
+		*d = Decimal{}
+		d.value.SetInt64(value)
+	case float64:
+		parsed, err := ParseDecimal(strconv.FormatFloat(value, 'f', -1, 64))
+		if err != nil {
+			return err
+		}
+		*d = parsed
+	default:
+		return fmt.Errorf("Cannot scan %T into Decimal", src)
+	}
+	return nil
+}
+
+// Implements driver.Valuer -- binds the value as SQL_NUMERIC with its precision and scale
+func (d Decimal) Value() (driver.Value, error) {
+	return BindParameter{Data: d.Rat(), Precision: decimalPrecision(&d.value, d.scale), Scale: d.scale}.Value()
+}
+
+// Parses decimal text of an optional sign, digits and an optional decimal point followed by digits, such as
+// "-12.340" or ".5".  Unlike big.Rat.SetString, fractions such as "1/3" and exponents such as "1e400" are rejected.
+func parseDecimalText(s string) (*big.Rat, bool) {
+	digits := strings.TrimLeft(s, "+-")
+	if len(s)-len(digits) > 1 {
+		return nil, false
+	}
+	numDigits, numPoints := 0, 0
+	for i := 0; i < len(digits); i++ {
+		switch {
+		case digits[i] >= '0' && digits[i] <= '9':
+			numDigits++
+		case digits[i] == '.':
+			numPoints++
+		default:
+			return nil, false
+		}
+	}
+	if numDigits == 0 || numPoints > 1 {
+		return nil, false
+	}
+	return new(big.Rat).SetString(s)
+}
+
+// Converts a float64 to a rational number, failing for NaN and infinities, which have no decimal value
+func floatToRat(f float64) (*big.Rat, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("Invalid decimal: %v", f)
+	}
+	return new(big.Rat).SetFloat64(f), nil
+}
+
+// Returns the number of digits after the decimal point in decimal text
+func textScale(s string) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '.' {
+			scale := 0
+			for j := i + 1; j < len(s) && s[j] >= '0' && s[j] <= '9'; j++ {
+				scale++
+			}
+			return scale
+		}
+	}
+	return 0
+}
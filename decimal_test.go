@@ -0,0 +1,33 @@
+package lodbc_test
+
+import (
+	"github.com/LukeMauldin/lodbc"
+	"github.com/LukeMauldin/lodbc/odbc/fake"
+	"math"
+	"testing"
+)
+
+func TestDecimalsRejectNonLiterals(t *testing.T) {
+	for _, s := range []string{"1/3", "1e400", "", "-", ".", "1.2.3", "+-1", "0x10"} {
+		if _, err := lodbc.ParseDecimal(s); err == nil {
+			t.Errorf("ParseDecimal(%q) succeeded", s)
+		}
+	}
+	for _, s := range []string{"12.340", "-0.5", "+7", ".5", "5."} {
+		if _, err := lodbc.ParseDecimal(s); err != nil {
+			t.Errorf("ParseDecimal(%q) failed: %v", s, err)
+		}
+	}
+
+	dm := fake.New()
+	dm.HandleDefault(func(e *fake.Execution) *fake.Response { return &fake.Response{} })
+	db := openDB(t, dm, nil)
+	if _, err := db.Exec("insert into t values (?)", lodbc.BindParameter{Data: "1.25", Precision: 10, Scale: 2}); err != nil {
+		t.Fatal(err)
+	}
+	for _, value := range []interface{}{math.NaN(), math.Inf(1), "1/3"} {
+		if _, err := db.Exec("insert into t values (?)", lodbc.BindParameter{Data: value, Precision: 10, Scale: 2}); err == nil {
+			t.Errorf("binding %v as a decimal succeeded", value)
+		}
+	}
+}
@@ -1,38 +1,220 @@
 package lodbc
 
 import (
+	"context"
 	"database/sql/driver"
+	"fmt"
 	"github.com/LukeMauldin/lodbc/odbc"
 	"runtime"
-	"syscall"
+	"sync"
+	"time"
 	"unsafe"
+	"weak"
 )
 
 // Implements type database/sql/driver Driver interface
 type lodbcDriver struct {
+	// ODBC API used by the driver and every connection it opens
+	api odbc.API
+
+	// Environment handle allocated from api, 0 until the first connection is opened
+	envHandle odbc.SQLHandle
+
+	// Guards envHandle
+	mu sync.Mutex
+}
+
+// Creates a driver that makes all ODBC calls through api, allocating an ODBC v3
+// environment handle for it.  Use odbc.System for the platform driver manager
+// or an odbc/fake DriverManager for tests, then register the result with sql.Register.
+func NewDriver(api odbc.API) (driver.Driver, error) {
+	return newDriver(api)
+}
+
+func newDriver(api odbc.API) (*lodbcDriver, error) {
+	d := &lodbcDriver{api: api}
+	_, err := d.environment()
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Returns the environment handle, allocating it with the current version and pooling settings on first use.
+// A failed allocation is not kept, so a later call can succeed.
+func (d *lodbcDriver) environment() (odbc.SQLHandle, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.envHandle != 0 {
+		return d.envHandle, nil
+	}
+
+	// Load the driver manager, so a missing one is reported rather than panicking on the first call
+	if loader, ok := d.api.(interface{ Load() error }); ok {
+		err := loader.Load()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	settingsMu.RLock()
+	version, pooling, match := odbcVersion, connectionPooling, poolMatch
+	settingsMu.RUnlock()
+
+	// Connection pooling is a process level attribute, set with a null handle before allocating the environment
+	ret := d.api.SQLSetEnvAttr(0, odbc.SQL_ATTR_CONNECTION_POOLING, pooling.attrValue(), 0)
+	if isError(ret) {
+		return 0, fmt.Errorf("Unable to set connection pooling mode %v", pooling)
+	}
+
+	// Allocate the environment handle
+	var envHandle odbc.SQLHandle
+	ret = d.api.SQLAllocHandle(odbc.SQL_HANDLE_ENV, 0, &envHandle)
+	if isError(ret) {
+		if envHandle == 0 {
+			return 0, fmt.Errorf("Unable to allocate the ODBC environment handle")
+		}
+		return 0, errorEnvironment(d.api, envHandle)
+	}
+
+	// Set the ODBC version, then how pooled connections are matched
+	ret = d.api.SQLSetEnvAttr(envHandle, odbc.SQL_ATTR_ODBC_VERSION, version.attrValue(), 0)
+	if !isError(ret) && pooling != PoolingOff {
+		ret = d.api.SQLSetEnvAttr(envHandle, odbc.SQL_ATTR_CP_MATCH, match.attrValue(), 0)
+	}
+	if isError(ret) {
+		err := errorEnvironment(d.api, envHandle)
+		d.api.SQLFreeHandle(odbc.SQL_HANDLE_ENV, envHandle)
+		return 0, err
+	}
+
+	d.envHandle = envHandle
+	return envHandle, nil
+}
+
+// Frees the environment handle, so the next connection allocates a new one
+func (d *lodbcDriver) freeEnvironment() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.envHandle == 0 {
+		return nil
+	}
+	ret := d.api.SQLFreeHandle(odbc.SQL_HANDLE_ENV, d.envHandle)
+	if isError(ret) {
+		return errorEnvironment(d.api, d.envHandle)
+	}
+	d.envHandle = 0
+	return nil
 }
 
-// Returns a new connection to the database
+// Runs change, which updates an environment setting, if the environment has not been allocated yet
+func (d *lodbcDriver) changeEnvironment(change func()) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.envHandle != 0 {
+		return fmt.Errorf("Environment settings must be changed before the first connection is opened or after FreeEnvironment")
+	}
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	change()
+	return nil
+}
+
+// Returns a new connection to the database, using the package level settings
 func (d *lodbcDriver) Open(name string) (driver.Conn, error) {
+	return d.connect(context.Background(), NewConfig(name))
+}
+
+// Implements driver.DriverContext -- returns a connector with the package level settings,
+// so sql.Open reports a malformed connection string before any connection is opened
+func (d *lodbcDriver) OpenConnector(name string) (driver.Connector, error) {
+	_, err := ParseConnectionString(name)
+	if err != nil {
+		return nil, err
+	}
+	return &connector{driver: d, config: *NewConfig(name)}, nil
+}
+
+// Opens a connection with the settings in config
+func (d *lodbcDriver) connect(ctx context.Context, config *Config) (driver.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	connString, err := config.connectionString()
+	if err != nil {
+		return nil, err
+	}
+	envHandle, err := d.environment()
+	if err != nil {
+		return nil, err
+	}
+
 	// Allocate the connection handle
 	var connHandle odbc.SQLHandle
-	ret := odbc.SQLAllocHandle(odbc.SQL_HANDLE_DBC, envHandle, &connHandle)
+	ret := d.api.SQLAllocHandle(odbc.SQL_HANDLE_DBC, envHandle, &connHandle)
 	if isError(ret) {
-		return nil, errorEnvironment(envHandle)
+		return nil, errorEnvironment(d.api, envHandle)
+	}
+
+	// Set the attributes that must be set before connecting
+	err = d.setLoginAttrs(ctx, connHandle, config)
+	if err != nil {
+		d.api.SQLFreeHandle(odbc.SQL_HANDLE_DBC, connHandle)
+		return nil, err
 	}
 
 	// Establish the connection with the database
-	nameSqlPtr := (*odbc.SQLCHAR)(unsafe.Pointer(syscall.StringToUTF16Ptr(name)))
-	ret = odbc.SQLDriverConnect(connHandle, 0, nameSqlPtr, odbc.SQLSMALLINT(odbc.SQL_NTS), nil, 0, nil, odbc.SQL_DRIVER_NOPROMPT)
+	nameSqlPtr := (*odbc.SQLCHAR)(unsafe.Pointer(stringToUTF16Ptr(connString.Build())))
+	ret = d.api.SQLDriverConnect(connHandle, 0, nameSqlPtr, odbc.SQLSMALLINT(odbc.SQL_NTS), nil, 0, nil, odbc.SQL_DRIVER_NOPROMPT)
 	if isError(ret) {
-		return nil, errorConnection(connHandle)
+		err := handleError(d.api, odbc.SQL_HANDLE_DBC, connHandle, fmt.Sprintf("Connection string: %v", connString))
+		d.api.SQLFreeHandle(odbc.SQL_HANDLE_DBC, connHandle)
+		return nil, err
 	}
 
 	// Create new connection
-	var conn = &connection{handle: connHandle, isTransactionActive: false, statements: make(map[driver.Stmt]bool, 0)}
+	autocommit := odbc.SQL_AUTOCOMMIT_ON
+	if config.DisableAutocommit {
+		autocommit = odbc.SQL_AUTOCOMMIT_OFF
+	}
+	var conn = &connection{api: d.api, handle: connHandle, isTransactionActive: false, statements: make(map[odbc.SQLHandle]weak.Pointer[statement]), decimalFormat: config.DecimalFormat, rowsetSize: config.RowsetSize, lobChunkSize: config.LOBChunkSize,
+		messageHandler: config.MessageHandler, queryTimeout: config.QueryTimeout, lastInsertIdQuery: config.LastInsertIdQuery, pingQuery: config.PingQuery, autocommit: autocommit, location: config.Location}
+	if conn.location == nil {
+		conn.location = time.UTC
+	}
+	conn.infoMessages(ret, odbc.SQL_HANDLE_DBC, connHandle, fmt.Sprintf("Connection string: %v", connString))
 
 	//Add a finalizer
 	runtime.SetFinalizer(conn, (*connection).Close)
 
+	// Apply the session settings
+	err = conn.initSession(ctx, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
 	return conn, nil
 }
+
+// Sets the login timeout and packet size, which the driver only reads when connecting
+func (d *lodbcDriver) setLoginAttrs(ctx context.Context, connHandle odbc.SQLHandle, config *Config) error {
+	timeout := config.LoginTimeout
+	if deadline, ok := ctx.Deadline(); ok && (timeout <= 0 || time.Until(deadline) < timeout) {
+		timeout = time.Until(deadline)
+	}
+	if timeout > 0 {
+		seconds := timeoutSeconds(timeout)
+		ret := d.api.SQLSetConnectAttr(connHandle, odbc.SQL_ATTR_LOGIN_TIMEOUT, odbc.SQLPOINTER(seconds), 0, nil)
+		if isError(ret) {
+			return errorConnection(d.api, connHandle)
+		}
+	}
+	if config.PacketSize > 0 {
+		ret := d.api.SQLSetConnectAttr(connHandle, odbc.SQL_ATTR_PACKET_SIZE, odbc.SQLPOINTER(config.PacketSize), 0, nil)
+		if isError(ret) {
+			return errorConnection(d.api, connHandle)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package lodbc_test
+
+import (
+	"database/sql"
+	"github.com/LukeMauldin/lodbc"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"github.com/LukeMauldin/lodbc/odbc/fake"
+	"testing"
+	"time"
+)
+
+// Opens a database on dm through the lodbc driver, with the settings changed by configure
+func openDB(t *testing.T, dm *fake.DriverManager, configure func(config *lodbc.Config)) *sql.DB {
+	t.Helper()
+	d, err := lodbc.NewDriver(dm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := lodbc.NewConfig("DSN=fake")
+	if configure != nil {
+		configure(config)
+	}
+	connector, err := lodbc.NewDriverConnector(d, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(connector)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// Result set with a column of each common type
+var typesResultSet = &fake.ResultSet{
+	Columns: []fake.Column{
+		{Name: "id", Type: odbc.SQL_INTEGER},
+		{Name: "name", Type: odbc.SQL_WVARCHAR, Precision: 20},
+		{Name: "amount", Type: odbc.SQL_DECIMAL, Precision: 10, Scale: 2},
+		{Name: "created", Type: odbc.SQL_TYPE_TIMESTAMP},
+		{Name: "data", Type: odbc.SQL_VARBINARY, Precision: 10},
+		{Name: "active", Type: odbc.SQL_BIT},
+	},
+	Rows: [][]interface{}{
+		{1, "one", "12.34", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), []byte{1, 2, 3}, true},
+		{2, "two", "-0.50", time.Date(2021, 6, 7, 8, 9, 10, 0, time.UTC), nil, false},
+		{nil, nil, nil, nil, nil, nil},
+	},
+}
+
+type typesRow struct {
+	id      sql.NullInt64
+	name    sql.NullString
+	amount  sql.NullString
+	created sql.NullTime
+	data    []byte
+	active  sql.NullBool
+}
+
+func TestNewDriverAppliesEnvironmentSettings(t *testing.T) {
+	//The registered driver has not opened a connection, so its environment settings may still be changed
+	if err := lodbc.SetODBCVersion(lodbc.ODBCVersion(3)); err == nil {
+		t.Error("SetODBCVersion accepted an unsupported version")
+	}
+	for _, setting := range []error{
+		lodbc.SetODBCVersion(lodbc.ODBCVersion_380),
+		lodbc.SetConnectionPooling(lodbc.PoolingOnePerEnvironment),
+		lodbc.SetPoolMatch(lodbc.PoolMatchRelaxed),
+	} {
+		if setting != nil {
+			t.Fatal(setting)
+		}
+	}
+	defer func() {
+		lodbc.SetODBCVersion(lodbc.ODBCVersion_3)
+		lodbc.SetConnectionPooling(lodbc.PoolingOnePerDriver)
+		lodbc.SetPoolMatch(lodbc.PoolMatchStrict)
+	}()
+
+	dm := fake.New()
+	if _, err := lodbc.NewDriver(dm); err != nil {
+		t.Fatal(err)
+	}
+	attrs := dm.EnvAttrs()
+	want := map[odbc.SQLINTEGER]odbc.SQLPOINTER{
+		odbc.SQL_ATTR_CONNECTION_POOLING: odbc.SQL_CP_ONE_PER_HENV,
+		odbc.SQL_ATTR_ODBC_VERSION:       odbc.SQL_OV_ODBC3_80,
+		odbc.SQL_ATTR_CP_MATCH:           odbc.SQL_CP_RELAXED_MATCH,
+	}
+	for attr, value := range want {
+		if attrs[attr] != value {
+			t.Errorf("Environment attribute %v is %v, want %v", attr, attrs[attr], value)
+		}
+	}
+	if dm.OpenHandles(odbc.SQL_HANDLE_ENV) != 1 {
+		t.Errorf("%v environment handles, want 1", dm.OpenHandles(odbc.SQL_HANDLE_ENV))
+	}
+
+	//Without pooling the match attribute is left unset
+	if err := lodbc.SetConnectionPooling(lodbc.PoolingOff); err != nil {
+		t.Fatal(err)
+	}
+	dm = fake.New()
+	if _, err := lodbc.NewDriver(dm); err != nil {
+		t.Fatal(err)
+	}
+	attrs = dm.EnvAttrs()
+	if attrs[odbc.SQL_ATTR_CONNECTION_POOLING] != odbc.SQL_CP_OFF {
+		t.Errorf("Connection pooling is %v, want SQL_CP_OFF", attrs[odbc.SQL_ATTR_CONNECTION_POOLING])
+	}
+	if _, ok := attrs[odbc.SQL_ATTR_CP_MATCH]; ok {
+		t.Error("SQL_ATTR_CP_MATCH set with pooling off")
+	}
+}
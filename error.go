@@ -1,10 +1,11 @@
 package lodbc
 
 import (
+	"database/sql/driver"
+	"errors"
 	"fmt"
 	"github.com/LukeMauldin/lodbc/odbc"
 	"strings"
-	"syscall"
 	"unsafe"
 )
 
@@ -48,35 +49,157 @@ func (e *ODBCError) Error() string {
 	return strings.Join(statusStrings, "\n")
 }
 
+// Returns the SQLSTATE of the first status record, or an empty string if there is none
+func (e *ODBCError) SQLState() string {
+	if len(e.StatusRecords) == 0 {
+		return ""
+	}
+	return e.StatusRecords[0].State
+}
+
+// Returns the SQLSTATE of an ODBCError, or an empty string for other errors
+func sqlState(err error) string {
+	var odbcErr *ODBCError
+	if !errors.As(err, &odbcErr) {
+		return ""
+	}
+	return odbcErr.SQLState()
+}
+
+// Returns the driver specific error number of the first status record
+func (e *ODBCError) NativeError() int {
+	if len(e.StatusRecords) == 0 {
+		return 0
+	}
+	return e.StatusRecords[0].NativeError
+}
+
+// Categories of ODBC errors, derived from the SQLSTATE and native error of the status records.
+// Test an error returned by the driver with errors.Is, for example errors.Is(err, lodbc.ErrDeadlock).
+var (
+	ErrIntegrityConstraint = errors.New("lodbc: integrity constraint violation")
+	ErrDeadlock            = errors.New("lodbc: serialization failure or deadlock")
+	ErrTimeout             = errors.New("lodbc: timeout expired")
+	ErrConnectionFailure   = errors.New("lodbc: connection failure")
+	ErrSyntax              = errors.New("lodbc: syntax error or access violation")
+	ErrTruncation          = errors.New("lodbc: string data, right truncated")
+)
+
+// Native errors that SQL Server reports under a generic SQLSTATE
+const (
+	sqlServerDeadlock    = 1205
+	sqlServerLockTimeout = 1222
+)
+
+// Returns the native error of a status record raised by SQL Server, or 0 for other data sources, whose native
+// errors have their own meanings.  Messages from a data source end their prefix with its name, as in
+// "[Microsoft][ODBC Driver 18 for SQL Server][SQL Server]Lock request time out period exceeded."
+func (sr *StatusRecord) sqlServerError() int {
+	if (sr.State == "40001" || sr.State == "HY000") && strings.Contains(sr.Message, "[SQL Server]") {
+		return sr.NativeError
+	}
+	return 0
+}
+
+// Returns the category of the status record, or nil if it has none
+func (sr *StatusRecord) category() error {
+	switch {
+	case sr.State == "40001" || sr.State == "40P01" || sr.sqlServerError() == sqlServerDeadlock:
+		return ErrDeadlock
+	case sr.State == "HYT00" || sr.State == "HYT01" || sr.sqlServerError() == sqlServerLockTimeout:
+		return ErrTimeout
+	case sr.State == "22001":
+		return ErrTruncation
+	case strings.HasPrefix(sr.State, "23"):
+		return ErrIntegrityConstraint
+	case strings.HasPrefix(sr.State, "08"):
+		return ErrConnectionFailure
+	case strings.HasPrefix(sr.State, "42"):
+		return ErrSyntax
+	}
+	return nil
+}
+
+// Supports errors.Is -- an ODBCError matches the category of any of its status records
+func (e *ODBCError) Is(target error) bool {
+	for i := range e.StatusRecords {
+		if category := e.StatusRecords[i].category(); category != nil && category == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether the statement may succeed if it is run again: deadlocks, serialization failures,
+// lock timeouts and connection failures.  Query timeouts are not retryable because they usually repeat.
+func IsRetryable(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	var odbcErr *ODBCError
+	if !errors.As(err, &odbcErr) {
+		return false
+	}
+	if errors.Is(odbcErr, ErrDeadlock) || errors.Is(odbcErr, ErrConnectionFailure) {
+		return true
+	}
+	for _, sr := range odbcErr.StatusRecords {
+		if sr.sqlServerError() == sqlServerLockTimeout {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether err says the link to the data source was lost, after which the connection cannot be used
+func isLinkFailure(err error) bool {
+	var odbcErr *ODBCError
+	if !errors.As(err, &odbcErr) {
+		return false
+	}
+	for _, sr := range odbcErr.StatusRecords {
+		switch sr.State {
+		case "08S01", "08003", "08007":
+			return true
+		}
+	}
+	return false
+}
+
 // Checks for SQL error
 func isError(ret odbc.SQLReturn) bool {
 	return !(ret == odbc.SQL_SUCCESS || ret == odbc.SQL_SUCCESS_WITH_INFO || ret == odbc.SQL_NO_DATA)
 }
 
-func errorEnvironment(handle odbc.SQLHandle) error {
-	return handleError(odbc.SQL_HANDLE_ENV, handle, "")
+func errorEnvironment(api odbc.API, handle odbc.SQLHandle) error {
+	return handleError(api, odbc.SQL_HANDLE_ENV, handle, "")
+}
+
+func errorConnection(api odbc.API, handle odbc.SQLHandle) error {
+	return handleError(api, odbc.SQL_HANDLE_DBC, handle, "")
 }
 
-func errorConnection(handle odbc.SQLHandle) error {
-	return handleError(odbc.SQL_HANDLE_DBC, handle, "")
+func errorStatement(api odbc.API, handle odbc.SQLHandle, driverInfo string) error {
+	return handleError(api, odbc.SQL_HANDLE_STMT, handle, driverInfo)
 }
 
-func errorStatement(handle odbc.SQLHandle, driverInfo string) error {
-	return handleError(odbc.SQL_HANDLE_STMT, handle, driverInfo)
+func handleError(api odbc.API, handleType odbc.SQLSMALLINT, handle odbc.SQLHandle, driverInfo string) error {
+	return &ODBCError{StatusRecords: diagRecords(api, handleType, handle, driverInfo)}
 }
 
-func handleError(handleType odbc.SQLSMALLINT, handle odbc.SQLHandle, driverInfo string) error {
+// Reads the diagnostic records of handle with SQLGetDiagRec
+func diagRecords(api odbc.API, handleType odbc.SQLSMALLINT, handle odbc.SQLHandle, driverInfo string) []StatusRecord {
 	statusRecords := make([]StatusRecord, 0)
 	if handle != 0 {
 		for recNum := 1; ; recNum++ {
 			sqlState := make([]uint16, sqlStateLength+1)
 			var nativeError odbc.SQLINTEGER
 			message := make([]uint16, errorMaxMessageLength+1)
-			ret := odbc.SQLGetDiagRec(handleType, handle, odbc.SQLSMALLINT(recNum), uintptr(unsafe.Pointer(&sqlState[0])), &nativeError, uintptr(unsafe.Pointer(&message[0])), errorMaxMessageLength, nil)
+			ret := api.SQLGetDiagRec(handleType, handle, odbc.SQLSMALLINT(recNum), unsafe.Pointer(&sqlState[0]), &nativeError, unsafe.Pointer(&message[0]), errorMaxMessageLength, nil)
 			if ret == odbc.SQL_NO_DATA {
 				break
 			} else if !isError(ret) {
-				sr := StatusRecord{State: syscall.UTF16ToString(sqlState), NativeError: int(nativeError), Message: syscall.UTF16ToString(message), DriverInfo: driverInfo}
+				sr := StatusRecord{State: utf16ToString(sqlState), NativeError: int(nativeError), Message: utf16ToString(message), DriverInfo: driverInfo}
 				statusRecords = append(statusRecords, sr)
 			} else {
 				break
@@ -84,5 +207,5 @@ func handleError(handleType odbc.SQLSMALLINT, handle odbc.SQLHandle, driverInfo
 		}
 	}
 
-	return &ODBCError{StatusRecords: statusRecords}
+	return statusRecords
 }
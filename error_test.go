@@ -0,0 +1,52 @@
+package lodbc_test
+
+import (
+	"errors"
+	"github.com/LukeMauldin/lodbc"
+	"github.com/LukeMauldin/lodbc/odbc/fake"
+	"strings"
+	"testing"
+)
+
+func TestErrorsCarryDiagnostics(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("insert dup", &fake.Response{Errors: []fake.Diagnostic{{State: "23000", NativeError: 2627, Message: "Violation of PRIMARY KEY constraint"}}})
+	db := openDB(t, dm, nil)
+
+	_, err := db.Exec("insert dup")
+	var odbcErr *lodbc.ODBCError
+	if !errors.As(err, &odbcErr) {
+		t.Fatalf("got %v, want an ODBCError", err)
+	}
+	if odbcErr.SQLState() != "23000" || odbcErr.StatusRecords[0].NativeError != 2627 || !strings.Contains(odbcErr.StatusRecords[0].Message, "PRIMARY KEY") {
+		t.Errorf("status records %+v", odbcErr.StatusRecords)
+	}
+	if !errors.Is(err, lodbc.ErrIntegrityConstraint) {
+		t.Errorf("%v is not ErrIntegrityConstraint", err)
+	}
+
+	//Statements without a handler fail with a syntax error
+	_, err = db.Exec("select unknown")
+	if !errors.Is(err, lodbc.ErrSyntax) {
+		t.Errorf("got %v, want ErrSyntax", err)
+	}
+}
+
+func TestNativeErrorsAreOnlyCategorizedForSQLServer(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("lock timeout", &fake.Response{Errors: []fake.Diagnostic{{State: "HY000", NativeError: 1222, Message: "[Microsoft][ODBC Driver 18 for SQL Server][SQL Server]Lock request time out period exceeded."}}})
+	dm.SetResponse("other driver", &fake.Response{Errors: []fake.Diagnostic{{State: "HY000", NativeError: 1222, Message: "[vendor][driver]Unrelated error 1222"}}})
+	dm.SetResponse("other deadlock", &fake.Response{Errors: []fake.Diagnostic{{State: "HY000", NativeError: 1205, Message: "[vendor][driver]Unrelated error 1205"}}})
+	db := openDB(t, dm, nil)
+
+	_, err := db.Exec("lock timeout")
+	if !errors.Is(err, lodbc.ErrTimeout) || !lodbc.IsRetryable(err) {
+		t.Errorf("SQL Server lock timeout %v is not a retryable timeout", err)
+	}
+	for _, query := range []string{"other driver", "other deadlock"} {
+		_, err = db.Exec(query)
+		if errors.Is(err, lodbc.ErrTimeout) || errors.Is(err, lodbc.ErrDeadlock) || lodbc.IsRetryable(err) {
+			t.Errorf("%v was categorized by its native error", err)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package lodbc
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"unsafe"
+)
+
+// Largest string column, in characters, fetched into bound buffers.  Longer and unbounded (max) columns are read with SQLGetData.
+const maxBoundStringLength = 4000
+
+// Largest binary column, in bytes, fetched into bound buffers
+const maxBoundBinaryLength = 8000
+
+// Number of rows fetched per round trip for new connections
+var rowsetSize = 100
+
+// Sets the number of rows fetched per round trip with SQLFetchScroll for connections opened afterwards.
+// 0 disables bound column fetching and reads every value with SQLGetData.
+// Use the RowsetSize query option to override it for a single query.
+func SetRowsetSize(size int) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	rowsetSize = size
+}
+
+// Result column fetched into a column-wise bound array
+type boundColumn struct {
+	def         resultColumnDef
+	decoder     *ColumnDecoder
+	elementSize int
+
+	// Buffers bound with SQLBindCol, one element per row of the rowset
+	data []byte
+	ind  []odbc.SQLLEN
+}
+
+// Returns the element size to bind a column read with decoder, or false if the column must be read with SQLGetData
+func columnBinding(def resultColumnDef, decoder *ColumnDecoder) (int, bool) {
+	switch {
+	case decoder == nil:
+		return 0, false
+	case decoder.Size > 0:
+		return decoder.Size, true
+	case def.DataType == odbc.SQL_LONGVARCHAR || def.DataType == odbc.SQL_WLONGVARCHAR || def.DataType == odbc.SQL_SS_XML || def.DataType == odbc.SQL_LONGVARBINARY:
+		return 0, false
+	case decoder.CType == odbc.SQL_C_WCHAR:
+		//The length of other types, such as the precision of a DECIMAL, is not the length of their text.  Drivers count
+		//characters, which take up to two UTF-16 code units, so the buffer holds two per character and the terminator.
+		if isBoundedTextType(def.DataType) && def.Length > 0 && def.Length <= maxBoundStringLength {
+			return int(def.Length*2+1) * 2, true
+		}
+	case decoder.CType == odbc.SQL_C_BINARY:
+		if (def.DataType == odbc.SQL_BINARY || def.DataType == odbc.SQL_VARBINARY) && def.Length > 0 && def.Length <= maxBoundBinaryLength {
+			return int(def.Length), true
+		}
+	}
+	return 0, false
+}
+
+// Returns true for the character types whose length is the maximum number of characters in a value
+func isBoundedTextType(sqlType odbc.SQLDataType) bool {
+	switch sqlType {
+	case odbc.SQL_CHAR, odbc.SQL_VARCHAR, odbc.SQL_WCHAR, odbc.SQL_WVARCHAR:
+		return true
+	}
+	return false
+}
+
+// Binds the result columns to buffers so rows are fetched rowsetSize at a time with SQLFetchScroll.
+// Columns from the first one that cannot be bound onwards are read with SQLGetData, which requires
+// a rowset of a single row.  Does nothing if rowsetSize is less than 1 or no column can be bound.
+func (rows *rows) bindColumns() error {
+	if rows.rowsetSize < 1 {
+		return nil
+	}
+
+	//Bind the leading columns with fixed or bounded sizes
+	boundColumns := make([]*boundColumn, len(rows.resultColumnDefs))
+	numBound := 0
+	for index, def := range rows.resultColumnDefs {
+		elementSize, ok := columnBinding(def, rows.columnDecoders[index])
+		if !ok {
+			break
+		}
+		boundColumns[index] = &boundColumn{def: def, decoder: rows.columnDecoders[index], elementSize: elementSize}
+		numBound++
+	}
+	if numBound == 0 {
+		return nil
+	}
+	arraySize := rows.rowsetSize
+	if numBound < len(rows.resultColumnDefs) {
+		arraySize = 1
+	}
+
+	//Set the rowset size -- a driver may substitute its own value
+	if arraySize > 1 {
+		ret := rows.api.SQLSetStmtAttr(rows.handle, odbc.SQL_ATTR_ROW_ARRAY_SIZE, odbc.SQLPOINTER(arraySize), odbc.SQL_IS_UINTEGER)
+		if isError(ret) {
+			arraySize = 1
+		} else if ret == odbc.SQL_SUCCESS_WITH_INFO {
+			var actual odbc.SQLULEN
+			ret = rows.api.SQLGetStmtAttr(rows.handle, odbc.SQL_ATTR_ROW_ARRAY_SIZE, unsafe.Pointer(&actual), 0, nil)
+			if isError(ret) {
+				return errorStatement(rows.api, rows.handle, rows.sqlStmt)
+			}
+			arraySize = int(actual)
+		}
+	}
+	rows.arraySize = arraySize
+
+	//Bind the buffers
+	for index, column := range boundColumns {
+		if column == nil {
+			continue
+		}
+		column.data = alignedBuffer(column.elementSize * arraySize)
+		column.ind = make([]odbc.SQLLEN, arraySize)
+		colNum := odbc.SQLUSMALLINT(index + 1)
+		dataPtr := unsafe.Pointer(&column.data[0])
+		ret := rows.api.SQLBindCol(rows.handle, colNum, odbc.SQLSMALLINT(column.decoder.CType), dataPtr, odbc.SQLLEN(column.elementSize), &column.ind[0])
+		if isError(ret) {
+			return errorStatement(rows.api, rows.handle, rows.sqlStmt)
+		}
+
+		if column.decoder.CType == odbc.SQL_C_NUMERIC {
+			err := setNumericDescriptor(rows.api, rows.descHandle, index+1, int(column.def.Precision), int(column.def.Scale), dataPtr, fmt.Sprintf("%v\nColumn %v", rows.sqlStmt, column.def.Name))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	rows.boundColumns = boundColumns
+
+	//Have the driver report the number of rows fetched and the status of each
+	rows.rowStatus = make([]odbc.SQLUSMALLINT, arraySize)
+	ret := rows.api.SQLSetStmtAttr(rows.handle, odbc.SQL_ATTR_ROWS_FETCHED_PTR, odbc.SQLPOINTER(unsafe.Pointer(&rows.rowsFetched)), odbc.SQL_IS_POINTER)
+	if isError(ret) {
+		return errorStatement(rows.api, rows.handle, rows.sqlStmt)
+	}
+	ret = rows.api.SQLSetStmtAttr(rows.handle, odbc.SQL_ATTR_ROW_STATUS_PTR, odbc.SQLPOINTER(unsafe.Pointer(&rows.rowStatus[0])), odbc.SQL_IS_POINTER)
+	if isError(ret) {
+		return errorStatement(rows.api, rows.handle, rows.sqlStmt)
+	}
+
+	return nil
+}
+
+// Releases the bound buffers so the statement no longer refers to them
+func (rows *rows) unbindColumns() error {
+	if rows.boundColumns == nil {
+		return nil
+	}
+	rows.boundColumns = nil
+
+	var err error
+	ret := rows.api.SQLFreeStmt(rows.handle, odbc.SQL_UNBIND)
+	if isError(ret) {
+		err = errorStatement(rows.api, rows.handle, rows.sqlStmt)
+	}
+	for _, attr := range []odbc.SQLINTEGER{odbc.SQL_ATTR_ROWS_FETCHED_PTR, odbc.SQL_ATTR_ROW_STATUS_PTR} {
+		ret = rows.api.SQLSetStmtAttr(rows.handle, attr, 0, odbc.SQL_IS_POINTER)
+		if isError(ret) && err == nil {
+			err = errorStatement(rows.api, rows.handle, rows.sqlStmt)
+		}
+	}
+	if rows.arraySize > 1 {
+		ret = rows.api.SQLSetStmtAttr(rows.handle, odbc.SQL_ATTR_ROW_ARRAY_SIZE, 1, odbc.SQL_IS_UINTEGER)
+		if isError(ret) && err == nil {
+			err = errorStatement(rows.api, rows.handle, rows.sqlStmt)
+		}
+	}
+	return err
+}
+
+// Moves to the next row of the rowset, fetching the next rowset when the current one is used up.
+// Returns false when there are no more rows.
+func (rows *rows) nextBoundRow() (bool, error) {
+	rows.rowsetPos++
+	if rows.rowsetPos >= int(rows.rowsFetched) {
+		ret := rows.api.SQLFetchScroll(rows.handle, odbc.SQL_FETCH_NEXT, 0)
+		if ret == odbc.SQL_NO_DATA {
+			return false, nil
+		} else if isError(ret) {
+			return false, errorStatement(rows.api, rows.handle, rows.sqlStmt)
+		}
+		rows.collectMessages(ret)
+		rows.rowsetPos = 0
+		if rows.rowsFetched == 0 {
+			return false, nil
+		}
+	}
+	if rows.rowStatus[rows.rowsetPos] == odbc.SQL_ROW_ERROR {
+		return false, errorStatement(rows.api, rows.handle, fmt.Sprintf("%v\nRow %v of rowset", rows.sqlStmt, rows.rowsetPos+1))
+	}
+	return true, nil
+}
+
+// Decodes the value of row in the rowset
+func (column *boundColumn) value(row int, ctx ConvertContext) (driver.Value, error) {
+	ind := column.ind[row]
+	if ind == odbc.SQL_NULL_DATA {
+		return nil, nil
+	}
+	data := column.data[row*column.elementSize : (row+1)*column.elementSize]
+	switch column.decoder.CType {
+	case odbc.SQL_C_WCHAR:
+		if ind < 0 || int(ind) > column.elementSize-2 {
+			return nil, fmt.Errorf("Column %v was truncated: %v bytes do not fit the %v byte buffer", column.def.Name, ind, column.elementSize-2)
+		}
+		data = []byte(utf16BytesToString(data[:ind]))
+	case odbc.SQL_C_BINARY:
+		if ind < 0 || int(ind) > column.elementSize {
+			return nil, fmt.Errorf("Column %v was truncated: %v bytes do not fit the %v byte buffer", column.def.Name, ind, column.elementSize)
+		}
+		data = append([]byte{}, data[:ind]...)
+	}
+	return column.decoder.Decode(data, ctx)
+}
+
+// Allocates a zeroed buffer of size bytes, aligned for any ODBC C type
+func alignedBuffer(size int) []byte {
+	words := make([]uint64, (size+7)/8)
+	return unsafe.Slice((*byte)(unsafe.Pointer(&words[0])), size)
+}
@@ -0,0 +1,46 @@
+package lodbc_test
+
+import (
+	"bytes"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"github.com/LukeMauldin/lodbc/odbc/fake"
+	"strings"
+	"testing"
+)
+
+func TestGetDataReadsLongValuesInChunks(t *testing.T) {
+	text := strings.Repeat("ü€", 6000)
+	data := bytes.Repeat([]byte{0, 1, 2, 3, 4}, 5000)
+	dm := fake.New()
+	dm.SetResponse("select long", &fake.Response{ResultSets: []*fake.ResultSet{{
+		Columns: []fake.Column{{Name: "text", Type: odbc.SQL_WLONGVARCHAR}, {Name: "data", Type: odbc.SQL_LONGVARBINARY}},
+		Rows:    [][]interface{}{{text, data}, {"", []byte{}}},
+	}}})
+	db := openDB(t, dm, nil)
+
+	rows, err := db.Query("select long")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var gotText string
+	var gotData []byte
+	if !rows.Next() {
+		t.Fatal(rows.Err())
+	}
+	if err := rows.Scan(&gotText, &gotData); err != nil {
+		t.Fatal(err)
+	}
+	if gotText != text || !bytes.Equal(gotData, data) {
+		t.Errorf("got %v characters and %v bytes, want %v and %v", len([]rune(gotText)), len(gotData), len([]rune(text)), len(data))
+	}
+	if !rows.Next() {
+		t.Fatal(rows.Err())
+	}
+	if err := rows.Scan(&gotText, &gotData); err != nil {
+		t.Fatal(err)
+	}
+	if gotText != "" || gotData == nil || len(gotData) != 0 {
+		t.Errorf("empty values read as %q and %#v", gotText, gotData)
+	}
+}
@@ -2,51 +2,35 @@ package lodbc
 
 import (
 	"database/sql"
+	"fmt"
 	"github.com/LukeMauldin/lodbc/odbc"
+	"sync"
 	"time"
 )
 
+// Guards the package level settings, which may be changed while connections are opened on other goroutines
+var settingsMu sync.RWMutex
+
 //Global variables
 var (
-	queryTimeout = 240 * time.Second // Query timeout
+	queryTimeout      = 240 * time.Second // Query timeout
+	lastInsertIdQuery = ""                // Query returning the last inserted identity, appended to Exec of INSERT statements when set
+	pingQuery         = "SELECT 1"        // Query run by Ping to reach the data source
 )
 
-// Shared global environment
-var envHandle odbc.SQLHandle
+// Driver registered as "lodbc", using the platform driver manager
+var defaultDriver = &lodbcDriver{api: odbc.System}
 
-// Allocates shared environment
+// Registers the driver -- the environment is allocated when the first connection is opened,
+// so importing the package does not require a driver manager
 func init() {
-
-	// Set environment handle for connection pooling
-	ret := odbc.SQLSetEnvAttr(envHandle, odbc.SQL_ATTR_CONNECTION_POOLING, odbc.SQL_CP_ONE_PER_DRIVER, 0)
-	if isError(ret) {
-		panic(errorEnvironment(envHandle))
-	}
-
-	// Allocate the environment handle
-	ret = odbc.SQLAllocHandle(odbc.SQL_HANDLE_ENV, 0, &envHandle)
-	if isError(ret) {
-		panic(errorEnvironment(envHandle))
-	}
-
-	// Set the environment handle to use ODBC v3
-	ret = odbc.SQLSetEnvAttr(envHandle, odbc.SQL_ATTR_ODBC_VERSION, odbc.SQL_OV_ODBC3, 0)
-	if isError(ret) {
-		panic(errorEnvironment(envHandle))
-	}
-
-	// Register with the SQL package
-	d := &lodbcDriver{}
-	sql.Register("lodbc", d)
+	sql.Register("lodbc", defaultDriver)
 }
 
-// Frees environment handle -- calling this will make the lodbc package unusable because all setup is performed in init()
+// Frees the environment handle of the driver registered as "lodbc".  Every connection must be closed first;
+// the next connection opened allocates a new environment with the current version and pooling settings.
 func FreeEnvironment() error {
-	ret := odbc.SQLFreeHandle(odbc.SQL_HANDLE_ENV, envHandle)
-	if isError(ret) {
-		return errorEnvironment(envHandle)
-	}
-	return nil
+	return defaultDriver.freeEnvironment()
 }
 
 // Enumeration for supported ODBC version
@@ -57,25 +41,111 @@ const (
 	ODBCVersion_380 ODBCVersion = 2
 )
 
-// Sets the ODBC version for the environment
-func SetODBCVersion(version ODBCVersion) {
-	switch version {
-	case ODBCVersion_3:
-		ret := odbc.SQLSetEnvAttr(envHandle, odbc.SQL_ATTR_ODBC_VERSION, odbc.SQL_OV_ODBC3, 0)
-		if isError(ret) {
-			panic(errorEnvironment(envHandle))
-		}
-		break
-	case ODBCVersion_380:
-		ret := odbc.SQLSetEnvAttr(envHandle, odbc.SQL_ATTR_ODBC_VERSION, odbc.SQL_OV_ODBC3_80, 0)
-		if isError(ret) {
-			panic(errorEnvironment(envHandle))
-		}
-		break
+// Enumeration for driver manager connection pooling modes
+type ConnectionPooling int
+
+const (
+	PoolingOff               ConnectionPooling = iota // SQL_CP_OFF
+	PoolingOnePerDriver                               // SQL_CP_ONE_PER_DRIVER -- a pool for each driver
+	PoolingOnePerEnvironment                          // SQL_CP_ONE_PER_HENV -- a pool for each environment
+)
+
+// Enumeration for how pooled connections are matched to connection requests
+type PoolMatch int
+
+const (
+	PoolMatchStrict  PoolMatch = iota // SQL_CP_STRICT_MATCH -- the connection string and attributes must match exactly
+	PoolMatchRelaxed                  // SQL_CP_RELAXED_MATCH -- only the connection string keywords must match
+)
+
+// Environment settings, applied when the environment is allocated
+var (
+	odbcVersion       = ODBCVersion_3
+	connectionPooling = PoolingOnePerDriver
+	poolMatch         = PoolMatchStrict
+)
+
+// Returns the SQL_ATTR_ODBC_VERSION value
+func (version ODBCVersion) attrValue() odbc.SQLPOINTER {
+	if version == ODBCVersion_380 {
+		return odbc.SQL_OV_ODBC3_80
 	}
+	return odbc.SQL_OV_ODBC3
 }
 
-//Sets the global query timeout
+// Returns the SQL_ATTR_CONNECTION_POOLING value
+func (pooling ConnectionPooling) attrValue() odbc.SQLPOINTER {
+	switch pooling {
+	case PoolingOnePerDriver:
+		return odbc.SQL_CP_ONE_PER_DRIVER
+	case PoolingOnePerEnvironment:
+		return odbc.SQL_CP_ONE_PER_HENV
+	}
+	return odbc.SQL_CP_OFF
+}
+
+// Returns the SQL_ATTR_CP_MATCH value
+func (match PoolMatch) attrValue() odbc.SQLPOINTER {
+	if match == PoolMatchRelaxed {
+		return odbc.SQL_CP_RELAXED_MATCH
+	}
+	return odbc.SQL_CP_STRICT_MATCH
+}
+
+//Sets the ODBC version of the environment.  Must be called before the first connection is opened, or after FreeEnvironment.
+func SetODBCVersion(version ODBCVersion) error {
+	if version != ODBCVersion_3 && version != ODBCVersion_380 {
+		return fmt.Errorf("Unsupported ODBC version %v", version)
+	}
+	return defaultDriver.changeEnvironment(func() {
+		odbcVersion = version
+	})
+}
+
+//Sets the driver manager connection pooling mode.  Must be called before the first connection is opened, or after FreeEnvironment.
+func SetConnectionPooling(pooling ConnectionPooling) error {
+	if pooling < PoolingOff || pooling > PoolingOnePerEnvironment {
+		return fmt.Errorf("Unsupported connection pooling mode %v", pooling)
+	}
+	return defaultDriver.changeEnvironment(func() {
+		connectionPooling = pooling
+	})
+}
+
+//Sets how pooled connections are matched, SQL_ATTR_CP_MATCH.  Must be called before the first connection is opened, or after FreeEnvironment.
+func SetPoolMatch(match PoolMatch) error {
+	if match != PoolMatchStrict && match != PoolMatchRelaxed {
+		return fmt.Errorf("Unsupported pool match %v", match)
+	}
+	return defaultDriver.changeEnvironment(func() {
+		poolMatch = match
+	})
+}
+
+//Sets the query timeout for connections opened afterwards with sql.Open or a Config from NewConfig
 func SetQueryTimeout(timeout time.Duration) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
 	queryTimeout = timeout
 }
+
+//Sets the query appended to each Exec of an INSERT statement to populate Result.LastInsertId, for example "SELECT SCOPE_IDENTITY()"
+//for SQL Server or "SELECT last_insert_rowid()" for SQLite.  It runs after a semicolon in the same batch as the
+//statement, so SCOPE_IDENTITY() sees the statement's insert, and the data source must accept several statements
+//in one SQLExecDirect.  Only statements starting with INSERT after any comments are recognized; for others, such as
+//WITH ... INSERT or SET NOCOUNT ON; INSERT, LastInsertId returns an error.  An empty query, the default, disables LastInsertId.
+//Applies to connections opened afterwards with sql.Open or a Config from NewConfig.
+func SetLastInsertIdQuery(query string) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	lastInsertIdQuery = query
+}
+
+//Sets the query Ping runs to check that the data source can still be reached, for example "SELECT 1 FROM DUAL"
+//for Oracle.  An empty query makes Ping only check SQL_ATTR_CONNECTION_DEAD, which most drivers update only after
+//a call on the connection fails.
+func SetPingQuery(query string) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	pingQuery = query
+}
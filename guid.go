@@ -0,0 +1,69 @@
+package lodbc
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"strings"
+)
+
+// Unique identifier in RFC 4122 byte order.  GUID columns are returned as strings such as
+// "6F9619FF-8B86-D011-B42D-00C04FC964FF"; scan them into a GUID to get the 16 bytes.
+type GUID [16]byte
+
+// Parses a GUID written as 32 hex digits, with or without hyphens and braces
+func ParseGUID(s string) (GUID, error) {
+	var g GUID
+	digits := strings.NewReplacer("-", "", "{", "", "}", "").Replace(s)
+	if len(digits) != 32 {
+		return g, fmt.Errorf("Invalid GUID: %v", s)
+	}
+	if _, err := hex.Decode(g[:], []byte(digits)); err != nil {
+		return g, fmt.Errorf("Invalid GUID: %v", s)
+	}
+	return g, nil
+}
+
+// Returns the GUID in upper case with hyphens, the way SQL Server converts it to text
+func (g GUID) String() string {
+	s := strings.ToUpper(hex.EncodeToString(g[:]))
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+// Implements sql.Scanner -- accepts the text of a GUID or its 16 bytes
+func (g *GUID) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case string:
+		parsed, err := ParseGUID(value)
+		if err != nil {
+			return err
+		}
+		*g = parsed
+	case []byte:
+		if len(value) == len(g) {
+			copy(g[:], value)
+			return nil
+		}
+		return g.Scan(string(value))
+	default:
+		return fmt.Errorf("Cannot scan %T into GUID", src)
+	}
+	return nil
+}
+
+// Implements driver.Valuer -- binds the GUID as text, which data sources convert to their GUID type
+func (g GUID) Value() (driver.Value, error) {
+	return g.String(), nil
+}
+
+// Converts a SQL_C_GUID structure, whose first three fields are in native byte order
+func guidFromStruct(value odbc.SQLGUID) GUID {
+	var g GUID
+	binary.BigEndian.PutUint32(g[0:4], value.Data1)
+	binary.BigEndian.PutUint16(g[4:6], value.Data2)
+	binary.BigEndian.PutUint16(g[6:8], value.Data3)
+	copy(g[8:], value.Data4[:])
+	return g
+}
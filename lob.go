@@ -0,0 +1,183 @@
+package lodbc
+
+import (
+	"errors"
+	"fmt"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"io"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// Bytes read per SQLGetData call by the LOB readers of new connections -- 0 reads LOB columns into a string or []byte
+var lobChunkSize = 0
+
+// Smallest chunk read from a LOB column, enough for one UTF-16 character and the terminating NUL
+const minLOBChunkSize = 4
+
+// Returned by LOB.Read once the rows have moved past the row the value belongs to
+var ErrLOBNotCurrent = errors.New("LOB value is no longer readable: the rows moved to another row or were closed")
+
+// Sets the number of bytes read per SQLGetData call when streaming LOB columns, for connections opened afterwards.
+// With a size above 0, an unbounded or long column at the end of the select list, such as varbinary(max) or
+// nvarchar(max), is returned as a *LOB reader instead of being read whole.  0 disables streaming.
+// Use the LOBChunkSize query option to override it for a single query.
+func SetLOBChunkSize(size int) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	lobChunkSize = size
+}
+
+// Value of a LOB column read lazily with SQLGetData, one chunk at a time.  Implements io.Reader:
+// binary columns return their bytes and text columns return UTF-8.  A LOB is only readable while its
+// row is current, so it must be read before the next call to Next, NextResultSet or Close on the rows.
+// Scan into a *lodbc.LOB, which is set to nil for NULL values, or an io.Reader.
+type LOB struct {
+	rows   *rows
+	column odbc.SQLUSMALLINT
+	cType  odbc.CDataType
+
+	// Row the value belongs to, compared with the rows' current row
+	rowNumber int64
+
+	// Buffer passed to SQLGetData and the data read but not yet returned by Read
+	chunk   []byte
+	pending []byte
+
+	// Trailing high surrogate of a text chunk, decoded with the first unit of the next one
+	surrogate []uint16
+
+	// Length in bytes reported by the driver for binary values, -1 if unknown
+	size int64
+
+	// The first chunk was read, the value is NULL and the driver returned the last chunk
+	isStarted bool
+	isNull    bool
+	isLast    bool
+
+	// Error returned once pending is empty, io.EOF at the end of the value
+	err error
+}
+
+// Reads up to len(p) bytes of the value, calling SQLGetData when the data already read is used up
+func (lob *LOB) Read(p []byte) (int, error) {
+	lob.rows.mu.Lock()
+	defer lob.rows.mu.Unlock()
+	if lob.rows.isClosed || lob.rows.rowNumber != lob.rowNumber {
+		return 0, ErrLOBNotCurrent
+	}
+	for len(lob.pending) == 0 {
+		if lob.err != nil {
+			return 0, lob.err
+		}
+		lob.err = lob.rows.conn.checkLink(lob.readChunk())
+	}
+	n := copy(p, lob.pending)
+	lob.pending = lob.pending[n:]
+	return n, nil
+}
+
+// Returns the length in bytes of a binary value, if the driver reported it with the first chunk, or -1.
+// Always -1 for text values, which the driver measures in UTF-16 rather than the UTF-8 Read returns.
+func (lob *LOB) Size() int64 {
+	return lob.size
+}
+
+// Returns column index of the current row as a LOB, reading the first chunk so NULL values are returned as nil
+func (rows *rows) newLOB(index int, cType odbc.CDataType) (interface{}, error) {
+	chunkSize := rows.lobChunkSize &^ 1
+	if chunkSize < minLOBChunkSize {
+		chunkSize = minLOBChunkSize
+	}
+	lob := &LOB{rows: rows, column: odbc.SQLUSMALLINT(index), cType: cType, rowNumber: rows.rowNumber, chunk: alignedBuffer(chunkSize), size: -1}
+	err := lob.readChunk()
+	if lob.isNull {
+		return nil, nil
+	} else if err != nil && err != io.EOF {
+		return nil, err
+	}
+	lob.err = err
+	return lob, nil
+}
+
+// Reads the next chunk of the value into pending.  Returns io.EOF once the whole value has been read.
+func (lob *LOB) readChunk() error {
+	if lob.isLast {
+		return io.EOF
+	}
+
+	var ind odbc.SQLLEN
+	ret := lob.rows.api.SQLGetData(lob.rows.handle, lob.column, lob.cType, unsafe.Pointer(&lob.chunk[0]), odbc.SQLLEN(len(lob.chunk)), &ind)
+	if ret == odbc.SQL_NO_DATA {
+		lob.isLast = true
+		return io.EOF
+	} else if isError(ret) {
+		return errorStatement(lob.rows.api, lob.rows.handle, fmt.Sprintf("%v\nLOB column: %v", lob.rows.sqlStmt, lob.column))
+	} else if ind == odbc.SQL_NULL_DATA {
+		lob.isNull = true
+		lob.isLast = true
+		return io.EOF
+	}
+	if ret == odbc.SQL_SUCCESS {
+		lob.isLast = true
+	}
+	isFirst := !lob.isStarted
+	lob.isStarted = true
+
+	//A full chunk holds the buffer less the NUL terminator of text -- the last one holds what remained
+	n := len(lob.chunk)
+	if lob.cType == odbc.SQL_C_WCHAR {
+		n -= 2
+	}
+	if ind != odbc.SQL_NO_TOTAL && int(ind) < n {
+		n = int(ind)
+	}
+	if lob.cType == odbc.SQL_C_BINARY {
+		if isFirst && ind != odbc.SQL_NO_TOTAL {
+			lob.size = int64(ind)
+		}
+		lob.pending = lob.chunk[:n]
+		return nil
+	}
+
+	//Decode the UTF-16 chunk, holding back a high surrogate whose pair is in the next chunk
+	units := append(lob.surrogate, unsafe.Slice((*uint16)(unsafe.Pointer(&lob.chunk[0])), n/2)...)
+	lob.surrogate = nil
+	if last := len(units) - 1; !lob.isLast && last >= 0 && units[last] >= 0xd800 && units[last] < 0xdc00 {
+		lob.surrogate = []uint16{units[last]}
+		units = units[:last]
+	}
+	lob.pending = []byte(string(utf16.Decode(units)))
+	return nil
+}
+
+// Returns the C type to stream a column with, or false if it is not a LOB column.  LOB columns are the long
+// types and string and binary columns too long to bind, such as nvarchar(max) and varbinary(max).
+func lobColumnType(def resultColumnDef) (odbc.CDataType, bool) {
+	switch def.DataType {
+	case odbc.SQL_LONGVARCHAR, odbc.SQL_WLONGVARCHAR, odbc.SQL_SS_XML:
+		return odbc.SQL_C_WCHAR, true
+	case odbc.SQL_LONGVARBINARY:
+		return odbc.SQL_C_BINARY, true
+	case odbc.SQL_CHAR, odbc.SQL_VARCHAR, odbc.SQL_WCHAR, odbc.SQL_WVARCHAR:
+		return odbc.SQL_C_WCHAR, def.Length <= 0 || def.Length > maxBoundStringLength
+	case odbc.SQL_BINARY, odbc.SQL_VARBINARY:
+		return odbc.SQL_C_BINARY, def.Length <= 0 || def.Length > maxBoundBinaryLength
+	}
+	return 0, false
+}
+
+// Returns the C type to stream the last column of the result set with, or 0 if it is not streamed.
+// Only the last column can be streamed: SQLGetData reads columns in order, so reading a later
+// column would discard the rest of a value still being streamed.
+func (rows *rows) lobColumn() odbc.CDataType {
+	last := len(rows.resultColumnDefs) - 1
+	if rows.lobChunkSize <= 0 || last < 0 || (rows.columnDecoders[last] != nil && !rows.columnDecoders[last].isBuiltin) {
+		return 0
+	}
+	cType, ok := lobColumnType(rows.resultColumnDefs[last])
+	if !ok {
+		return 0
+	}
+	return cType
+}
@@ -0,0 +1,181 @@
+package lodbc_test
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"github.com/LukeMauldin/lodbc"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"github.com/LukeMauldin/lodbc/odbc/fake"
+	"io"
+	"strings"
+	"testing"
+)
+
+// Result set of an id and a LOB column of type lobType
+func lobResult(lobType odbc.SQLDataType, values ...interface{}) *fake.Response {
+	rs := &fake.ResultSet{Columns: []fake.Column{{Name: "id", Type: odbc.SQL_INTEGER}, {Name: "value", Type: lobType, Nullable: true}}}
+	for index, value := range values {
+		rs.Rows = append(rs.Rows, []interface{}{index, value})
+	}
+	return &fake.Response{ResultSets: []*fake.ResultSet{rs}}
+}
+
+// Returns the number of SQLGetData calls made on dm
+func getDataCalls(dm *fake.DriverManager) int {
+	count := 0
+	for _, call := range dm.Calls() {
+		if call.Function == "SQLGetData" {
+			count++
+		}
+	}
+	return count
+}
+
+func TestLOBColumnsAreStreamed(t *testing.T) {
+	text := strings.Repeat("Grüße € ", 100)
+	data := bytes.Repeat([]byte{0, 1, 2, 3, 4, 5, 6}, 100)
+	dm := fake.New()
+	dm.SetResponse("select text", lobResult(odbc.SQL_WLONGVARCHAR, text, nil, ""))
+	dm.SetResponse("select data", lobResult(odbc.SQL_LONGVARBINARY, data, nil))
+	db := openDB(t, dm, func(config *lodbc.Config) {
+		config.LOBChunkSize = 64
+	})
+
+	rows, err := db.Query("select text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []interface{}
+	for rows.Next() {
+		var id int
+		var lob *lodbc.LOB
+		if err := rows.Scan(&id, &lob); err != nil {
+			t.Fatal(err)
+		}
+		if lob == nil {
+			got = append(got, nil)
+			continue
+		}
+		if lob.Size() != -1 {
+			t.Errorf("text LOB has size %v", lob.Size())
+		}
+		value, err := io.ReadAll(lob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(value))
+	}
+	if len(got) != 3 || got[0] != text || got[1] != nil || got[2] != "" {
+		t.Errorf("read %.40q", got)
+	}
+
+	//Binary values report their size, and are no longer readable once the rows move on
+	rows, err = db.Query("select data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		t.Fatal(rows.Err())
+	}
+	var id int
+	var lob *lodbc.LOB
+	if err := rows.Scan(&id, &lob); err != nil {
+		t.Fatal(err)
+	}
+	if lob.Size() != int64(len(data)) {
+		t.Errorf("binary LOB has size %v, want %v", lob.Size(), len(data))
+	}
+	first := make([]byte, 10)
+	if _, err := io.ReadFull(lob, first); err != nil || !bytes.Equal(first, data[:10]) {
+		t.Errorf("read %v, %v", first, err)
+	}
+	if !rows.Next() {
+		t.Fatal(rows.Err())
+	}
+	if _, err := lob.Read(first); !errors.Is(err, lodbc.ErrLOBNotCurrent) {
+		t.Errorf("got %v reading a LOB of a previous row, want ErrLOBNotCurrent", err)
+	}
+}
+
+// Runs query and reads its LOB column, which is returned as a string if it is not streamed
+func readLOBColumn(t *testing.T, db *sql.DB, query string) interface{} {
+	t.Helper()
+	rows, err := db.Query(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		t.Fatal(rows.Err())
+	}
+	var id int
+	var value interface{}
+	if err := rows.Scan(&id, &value); err != nil {
+		t.Fatal(err)
+	}
+	reader, ok := value.(io.Reader)
+	if !ok {
+		return value
+	}
+	read, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return read
+}
+
+func TestLOBChunkSizeQueryOption(t *testing.T) {
+	text := strings.Repeat("x", 100)
+	dm := fake.New()
+	dm.SetResponse("select text", lobResult(odbc.SQL_WLONGVARCHAR, text))
+	db := openDB(t, dm, nil)
+
+	//100 characters read 3 and 31 at a time -- the last chunk returns SQL_SUCCESS, so no call returns SQL_NO_DATA
+	tests := []struct {
+		chunkSize int
+		calls     int
+	}{
+		{8, 34},
+		{64, 4},
+	}
+	for _, test := range tests {
+		query, err := lodbc.AddQueryOption("select text", lodbc.NewQueryOption(lodbc.LOBChunkSize, test.chunkSize))
+		if err != nil {
+			t.Fatal(err)
+		}
+		before := getDataCalls(dm)
+		value, ok := readLOBColumn(t, db, query).([]byte)
+		if !ok || string(value) != text {
+			t.Errorf("chunk size %v read %q", test.chunkSize, value)
+		}
+		if calls := getDataCalls(dm) - before; calls != test.calls {
+			t.Errorf("chunk size %v made %v SQLGetData calls, want %v", test.chunkSize, calls, test.calls)
+		}
+	}
+
+	//Without a chunk size the value is read whole
+	if value := readLOBColumn(t, db, "select text"); value != text {
+		t.Errorf("read %T %.20q, want the text", value, value)
+	}
+}
+
+func TestLOBDecodesSurrogatePairsSplitAcrossChunks(t *testing.T) {
+	text := "a😀b😀😀c"
+	dm := fake.New()
+	dm.SetResponse("select text", lobResult(odbc.SQL_WLONGVARCHAR, text))
+	db := openDB(t, dm, nil)
+
+	//Chunks of 4 and 6 bytes hold one and two UTF-16 units besides the NUL, so each pair is split at least once
+	for _, chunkSize := range []int{4, 6} {
+		query, err := lodbc.AddQueryOption("select text", lodbc.NewQueryOption(lodbc.LOBChunkSize, chunkSize))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, ok := readLOBColumn(t, db, query).([]byte); !ok || string(got) != text {
+			t.Errorf("chunk size %v read %q, want %q", chunkSize, got, text)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package lodbc
+
+import (
+	"github.com/LukeMauldin/lodbc/odbc"
+)
+
+// Receives the informational messages and warnings the driver returns with SQL_SUCCESS_WITH_INFO, such as
+// PRINT output, RAISERROR messages with severity 10 or less, truncation warnings and "changed database context" notices
+type MessageHandler func(messages []StatusRecord)
+
+// Message handler for new connections
+var messageHandler MessageHandler
+
+// Sets the handler called with the informational messages of connections opened afterwards -- nil discards them.
+// The handler runs on the goroutine using the connection, while the statement is executing or fetching.
+func SetMessageHandler(handler MessageHandler) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	messageHandler = handler
+}
+
+// Reads the diagnostic records of handle if ret is SQL_SUCCESS_WITH_INFO and passes them to the connection's message handler
+func (c *connection) infoMessages(ret odbc.SQLReturn, handleType odbc.SQLSMALLINT, handle odbc.SQLHandle, driverInfo string) []StatusRecord {
+	if ret != odbc.SQL_SUCCESS_WITH_INFO {
+		return nil
+	}
+	messages := diagRecords(c.api, handleType, handle, driverInfo)
+	if len(messages) > 0 && c.messageHandler != nil {
+		c.messageHandler(messages)
+	}
+	return messages
+}
+
+// Collects the informational messages returned by the last call on the statement
+func (stmt *statement) collectMessages(ret odbc.SQLReturn) {
+	stmt.messages = append(stmt.messages, stmt.conn.infoMessages(ret, odbc.SQL_HANDLE_STMT, stmt.handle, stmt.sqlStmt)...)
+}
+
+// Collects the informational messages returned by the last call on the rows' statement
+func (rows *rows) collectMessages(ret odbc.SQLReturn) {
+	rows.messages = append(rows.messages, rows.conn.infoMessages(ret, odbc.SQL_HANDLE_STMT, rows.handle, rows.sqlStmt)...)
+}
+
+// Returns the informational messages returned while executing the statement and reading its results
+// so far.  Available on the driver's rows, for example through sql.Conn.Raw -- see SetMessageHandler.
+func (rows *rows) Messages() []StatusRecord {
+	rows.mu.Lock()
+	defer rows.mu.Unlock()
+	return rows.messages
+}
+
+// Returns the informational messages returned while executing the statement
+func (r *result) Messages() []StatusRecord {
+	return r.messages
+}
@@ -16,16 +16,32 @@ import ()
 //sys   SQLFetch(statementHandle SQLHandle) (ret SQLReturn) = odbc32.SQLFetch
 //sys   SQLFetchScroll(statementHandle SQLHandle, fetchOrientation SQLSMALLINT, fetchOffset  SQLLEN) (ret SQLReturn)  = odbc32.SQLFetchScroll
 //sys   SQLSetStmtAttr(statementHandle SQLHandle, attribute SQLINTEGER, valuePtr SQLPOINTER, stringLength SQLINTEGER) (ret SQLReturn) = odbc32.SQLSetStmtAttr
-//sys   SQLBindCol(statementHandle SQLHandle, columnNumber SQLUSMALLINT, targetType SQLSMALLINT, targetValuePtr SQLPOINTER, bufferLength SQLLEN, ind *SQLLEN) (ret SQLReturn) = odbc32.SQLBindCol
+//sys   SQLBindCol(statementHandle SQLHandle, columnNumber SQLUSMALLINT, targetType SQLSMALLINT, targetValuePtr unsafe.Pointer, bufferLength SQLLEN, ind *SQLLEN) (ret SQLReturn) = odbc32.SQLBindCol
 //sys   SQLSetConnectAttr(connectionHandle SQLHandle, attribute SQLINTEGER, valuePtr SQLPOINTER, bufferLength SQLINTEGER, stringLengthPtr *SQLINTEGER) (ret SQLReturn) = odbc32.SQLSetConnectAttrW
 //sys   SQLEndTran(handleType SQLSMALLINT, handle SQLHandle, completionType SQLSMALLINT) (ret SQLReturn) = odbc32.SQLEndTran
-//sys   SQLBindParameter(statementHandle SQLHandle, parameterNumber SQLUSMALLINT, inputOutputType SQLSMALLINT, valueType CDataType, parameterType SQLDataType, columnSize SQLULEN, decimalDigits SQLSMALLINT, parameterValue SQLPOINTER, bufferLength SQLLEN, ind *SQLLEN) (ret SQLReturn) = odbc32.SQLBindParameter
+//sys   SQLBindParameter(statementHandle SQLHandle, parameterNumber SQLUSMALLINT, inputOutputType SQLSMALLINT, valueType CDataType, parameterType SQLDataType, columnSize SQLULEN, decimalDigits SQLSMALLINT, parameterValue unsafe.Pointer, bufferLength SQLLEN, ind *SQLLEN) (ret SQLReturn) = odbc32.SQLBindParameter
 //sys   SQLMoreResults(statementHandle SQLHandle) (ret SQLReturn) = odbc32.SQLMoreResults
-//sys   SQLGetDescField(descriptorHandle SQLHandle, recNumber SQLSMALLINT, fieldIdentifier SQLSMALLINT, valuePtr SQLPOINTER, bufferLength SQLINTEGER, lengthPtr *SQLINTEGER) (ret SQLReturn) = odbc32.SQLGetDescField
+//sys   SQLGetDescField(descriptorHandle SQLHandle, recNumber SQLSMALLINT, fieldIdentifier SQLSMALLINT, valuePtr unsafe.Pointer, bufferLength SQLINTEGER, lengthPtr *SQLINTEGER) (ret SQLReturn) = odbc32.SQLGetDescField
 //sys   SQLGetDescRec(descriptorHandle SQLHandle, recNumber SQLSMALLINT, name *SQLCHAR, bufferLength SQLSMALLINT, stringLengthPtr *SQLSMALLINT, typePtr *SQLSMALLINT, subTypePtr *SQLSMALLINT, lengthPtr *SQLLEN, precisionPtr *SQLSMALLINT, scalePtr *SQLSMALLINT, nullablePtr *SQLSMALLINT) (ret SQLReturn) = odbc32.SQLGetDescRecW
-//sys   SQLGetDiagRec(handleType SQLSMALLINT, inputHandle SQLHandle, recNumber SQLSMALLINT, sqlState uintptr, nativeErrorPtr *SQLINTEGER, messageText uintptr, bufferLength SQLSMALLINT, textLengthPtr *SQLSMALLINT) (ret SQLReturn) = odbc32.SQLGetDiagRecW
-//sys   SQLColAttribute(statementHandle SQLHandle, columnNumber SQLUSMALLINT, fieldIdentifier SQLColAttributeType, characterAttribute uintptr, bufferLength SQLSMALLINT, stringLengthPtr *SQLSMALLINT, numericAttributePtr *SQLLEN) (ret SQLReturn) = odbc32.SQLColAttributeW
+//sys   SQLGetDiagRec(handleType SQLSMALLINT, inputHandle SQLHandle, recNumber SQLSMALLINT, sqlState unsafe.Pointer, nativeErrorPtr *SQLINTEGER, messageText unsafe.Pointer, bufferLength SQLSMALLINT, textLengthPtr *SQLSMALLINT) (ret SQLReturn) = odbc32.SQLGetDiagRecW
+//sys   SQLColAttribute(statementHandle SQLHandle, columnNumber SQLUSMALLINT, fieldIdentifier SQLColAttributeType, characterAttribute unsafe.Pointer, bufferLength SQLSMALLINT, stringLengthPtr *SQLSMALLINT, numericAttributePtr *SQLLEN) (ret SQLReturn) = odbc32.SQLColAttributeW
 //sys   SQLNumResultCols(statementHandle SQLHandle, columnCount *SQLSMALLINT)  (ret SQLReturn) = odbc32.SQLNumResultCols
-//sys   SQLGetData(statementHandle SQLHandle, colNum SQLUSMALLINT, targetType CDataType, targetValuePtr uintptr, bufferLength SQLLEN, ind *SQLLEN) (ret SQLReturn) = odbc32.SQLGetData
-//sys   SQLGetStmtAttr(statementHandle SQLHandle, attribute SQLINTEGER, valuePtr uintptr, bufferLength SQLINTEGER, stringLengthPtr *SQLINTEGER) (ret SQLReturn) = odbc32.SQLGetStmtAttr
-//sys   SQLSetDescField(descriptorHandle SQLHandle, recNum SQLSMALLINT, fieldIdentifier SQLSMALLINT, valuePtr uintptr, bufferLength SQLINTEGER) (ret SQLReturn) = odbc32.SQLSetDescFieldW
+//sys   SQLGetData(statementHandle SQLHandle, colNum SQLUSMALLINT, targetType CDataType, targetValuePtr unsafe.Pointer, bufferLength SQLLEN, ind *SQLLEN) (ret SQLReturn) = odbc32.SQLGetData
+//sys   SQLGetStmtAttr(statementHandle SQLHandle, attribute SQLINTEGER, valuePtr unsafe.Pointer, bufferLength SQLINTEGER, stringLengthPtr *SQLINTEGER) (ret SQLReturn) = odbc32.SQLGetStmtAttr
+//sys   SQLSetDescField(descriptorHandle SQLHandle, recNum SQLSMALLINT, fieldIdentifier SQLSMALLINT, valuePtr unsafe.Pointer, bufferLength SQLINTEGER) (ret SQLReturn) = odbc32.SQLSetDescFieldW
+//sys   SQLPrepare(statementHandle SQLHandle, statementText *SQLCHAR, textLength SQLINTEGER) (ret SQLReturn) = odbc32.SQLPrepareW
+//sys   SQLExecute(statementHandle SQLHandle) (ret SQLReturn) = odbc32.SQLExecute
+//sys   SQLNumParams(statementHandle SQLHandle, parameterCountPtr *SQLSMALLINT) (ret SQLReturn) = odbc32.SQLNumParams
+//sys   SQLGetConnectAttr(connectionHandle SQLHandle, attribute SQLINTEGER, valuePtr unsafe.Pointer, bufferLength SQLINTEGER, stringLengthPtr *SQLINTEGER) (ret SQLReturn) = odbc32.SQLGetConnectAttrW
+//sys   SQLRowCount(statementHandle SQLHandle, rowCountPtr *SQLLEN) (ret SQLReturn) = odbc32.SQLRowCount
+//sys   SQLFreeStmt(statementHandle SQLHandle, option SQLUSMALLINT) (ret SQLReturn) = odbc32.SQLFreeStmt
+//sys   SQLTables(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT, tableTypeName *SQLCHAR, tableTypeNameLength SQLSMALLINT) (ret SQLReturn) = odbc32.SQLTablesW
+//sys   SQLColumns(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT, columnName *SQLCHAR, columnNameLength SQLSMALLINT) (ret SQLReturn) = odbc32.SQLColumnsW
+//sys   SQLPrimaryKeys(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT) (ret SQLReturn) = odbc32.SQLPrimaryKeysW
+//sys   SQLForeignKeys(statementHandle SQLHandle, pkCatalogName *SQLCHAR, pkCatalogNameLength SQLSMALLINT, pkSchemaName *SQLCHAR, pkSchemaNameLength SQLSMALLINT, pkTableName *SQLCHAR, pkTableNameLength SQLSMALLINT, fkCatalogName *SQLCHAR, fkCatalogNameLength SQLSMALLINT, fkSchemaName *SQLCHAR, fkSchemaNameLength SQLSMALLINT, fkTableName *SQLCHAR, fkTableNameLength SQLSMALLINT) (ret SQLReturn) = odbc32.SQLForeignKeysW
+//sys   SQLStatistics(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT, unique SQLUSMALLINT, reserved SQLUSMALLINT) (ret SQLReturn) = odbc32.SQLStatisticsW
+//sys   SQLProcedures(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, procName *SQLCHAR, procNameLength SQLSMALLINT) (ret SQLReturn) = odbc32.SQLProceduresW
+//sys   SQLProcedureColumns(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, procName *SQLCHAR, procNameLength SQLSMALLINT, columnName *SQLCHAR, columnNameLength SQLSMALLINT) (ret SQLReturn) = odbc32.SQLProcedureColumnsW
+//sys   SQLSpecialColumns(statementHandle SQLHandle, identifierType SQLUSMALLINT, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT, scope SQLUSMALLINT, nullable SQLUSMALLINT) (ret SQLReturn) = odbc32.SQLSpecialColumnsW
+//sys   SQLParamData(statementHandle SQLHandle, valuePtrPtr *SQLPOINTER) (ret SQLReturn) = odbc32.SQLParamData
+//sys   SQLPutData(statementHandle SQLHandle, dataPtr unsafe.Pointer, strLenOrInd SQLLEN) (ret SQLReturn) = odbc32.SQLPutData
@@ -87,7 +87,15 @@ const (
 	SQL_BIT            SQLDataType = -7
 	SQL_WCHAR          SQLDataType = -8
 	SQL_WVARCHAR       SQLDataType = -9
+	SQL_WLONGVARCHAR   SQLDataType = -10
+	SQL_GUID           SQLDataType = -11
+	SQL_SS_VARIANT     SQLDataType = -150
+	SQL_SS_UDT         SQLDataType = -151
 	SQL_SS_XML         SQLDataType = -152
+
+	//SQL Server time(n) and datetimeoffset(n)
+	SQL_SS_TIME2           SQLDataType = -154
+	SQL_SS_TIMESTAMPOFFSET SQLDataType = -155
 )
 
 //C data types
@@ -106,7 +114,34 @@ const (
 	SQL_C_BINARY    CDataType = CDataType(SQL_BINARY)
 	SQL_C_BIT       CDataType = CDataType(SQL_BIT)
 	SQL_C_WCHAR     CDataType = CDataType(SQL_WCHAR)
+	SQL_C_GUID      CDataType = CDataType(SQL_GUID)
+	SQL_C_SBIGINT   CDataType = CDataType(SQL_BIGINT) + SQL_SIGNED_OFFSET
 	SQL_C_DEFAULT   CDataType = CDataType(99)
+
+	SQL_SIGNED_OFFSET CDataType = -20
+
+	//SQL Server C types for SQL_SS_TIME2 and SQL_SS_TIMESTAMPOFFSET
+	SQL_C_SS_TIME2           CDataType = 0x4000
+	SQL_C_SS_TIMESTAMPOFFSET CDataType = 0x4001
+)
+
+//SQLFreeStmt options
+const (
+	SQL_CLOSE        SQLUSMALLINT = 0
+	SQL_DROP         SQLUSMALLINT = 1
+	SQL_UNBIND       SQLUSMALLINT = 2
+	SQL_RESET_PARAMS SQLUSMALLINT = 3
+)
+
+//Row status values for SQL_ATTR_ROW_STATUS_PTR
+const (
+	SQL_ROW_SUCCESS           SQLUSMALLINT = 0
+	SQL_ROW_DELETED           SQLUSMALLINT = 1
+	SQL_ROW_UPDATED           SQLUSMALLINT = 2
+	SQL_ROW_NOROW             SQLUSMALLINT = 3
+	SQL_ROW_ADDED             SQLUSMALLINT = 4
+	SQL_ROW_ERROR             SQLUSMALLINT = 5
+	SQL_ROW_SUCCESS_WITH_INFO SQLUSMALLINT = 6
 )
 
 //Misc flags
@@ -154,19 +189,33 @@ const (
 type SQLColAttributeType SQLUSMALLINT
 
 const (
-	SQL_COLUMN_TYPE      SQLColAttributeType = 2
-	SQL_COLUMN_LENGTH    SQLColAttributeType = 3
-	SQL_COLUMN_PRECISION SQLColAttributeType = 4
-	SQL_COLUMN_SCALE     SQLColAttributeType = 5
-	SQL_COLUMN_NULLABLE  SQLColAttributeType = 7
-	SQL_COLUMN_LABEL     SQLColAttributeType = 18
-	SQL_DESC_LABEL       SQLColAttributeType = SQL_COLUMN_LABEL
+	SQL_COLUMN_TYPE           SQLColAttributeType = 2
+	SQL_COLUMN_LENGTH         SQLColAttributeType = 3
+	SQL_COLUMN_PRECISION      SQLColAttributeType = 4
+	SQL_COLUMN_SCALE          SQLColAttributeType = 5
+	SQL_COLUMN_NULLABLE       SQLColAttributeType = 7
+	SQL_COLUMN_LABEL          SQLColAttributeType = 18
+	SQL_DESC_LABEL            SQLColAttributeType = SQL_COLUMN_LABEL
+	SQL_DESC_TYPE_NAME        SQLColAttributeType = 14
+	SQL_DESC_BASE_COLUMN_NAME SQLColAttributeType = 22
+	SQL_DESC_BASE_TABLE_NAME  SQLColAttributeType = 23
+)
+
+//Nullability of columns and parameters
+const (
+	SQL_NO_NULLS         SQLLEN = 0
+	SQL_NULLABLE         SQLLEN = 1
+	SQL_NULLABLE_UNKNOWN SQLLEN = 2
 )
 
 //Special length/indicator values
 const (
 	SQL_NULL_DATA    SQLLEN = -1
 	SQL_DATA_AT_EXEC SQLLEN = -2
+	SQL_NO_TOTAL     SQLLEN = -4
+
+	//SQL_LEN_DATA_AT_EXEC(length) is SQL_LEN_DATA_AT_EXEC_OFFSET - length
+	SQL_LEN_DATA_AT_EXEC_OFFSET SQLLEN = -100
 )
 
 type SQL_NUMERIC_STRUCT struct {
@@ -189,13 +238,42 @@ type SQL_TIME_STRUCT struct {
 }
 
 type SQL_TIMESTAMP_STRUCT struct {
-	Year    SQLSMALLINT
-	Month   SQLUSMALLINT
-	Day     SQLUSMALLINT
-	Hour    SQLUSMALLINT
-	Minute  SQLUSMALLINT
-	Second  SQLUSMALLINT
-	Faction SQLUINTEGER
+	Year     SQLSMALLINT
+	Month    SQLUSMALLINT
+	Day      SQLUSMALLINT
+	Hour     SQLUSMALLINT
+	Minute   SQLUSMALLINT
+	Second   SQLUSMALLINT
+	Fraction SQLUINTEGER
+}
+
+//SQL_C_GUID -- the first three fields are in native byte order
+type SQLGUID struct {
+	Data1 uint32
+	Data2 uint16
+	Data3 uint16
+	Data4 [8]byte
+}
+
+//SQL_C_SS_TIME2 -- Fraction is in nanoseconds
+type SQL_SS_TIME2_STRUCT struct {
+	Hour     SQLUSMALLINT
+	Minute   SQLUSMALLINT
+	Second   SQLUSMALLINT
+	Fraction SQLUINTEGER
+}
+
+//SQL_C_SS_TIMESTAMPOFFSET -- the offset from UTC has the same sign in both fields
+type SQL_SS_TIMESTAMPOFFSET_STRUCT struct {
+	Year           SQLSMALLINT
+	Month          SQLUSMALLINT
+	Day            SQLUSMALLINT
+	Hour           SQLUSMALLINT
+	Minute         SQLUSMALLINT
+	Second         SQLUSMALLINT
+	Fraction       SQLUINTEGER
+	TimezoneHour   SQLSMALLINT
+	TimezoneMinute SQLSMALLINT
 }
 
 //Connection attributes
@@ -204,20 +282,64 @@ const (
 	SQL_AUTOCOMMIT_OFF     SQLINTEGER = 0
 	SQL_AUTOCOMMIT_ON      SQLINTEGER = 1
 	SQL_AUTOCOMMIT_DEFAULT SQLINTEGER = SQL_AUTOCOMMIT_ON
+	SQL_ATTR_ACCESS_MODE   SQLINTEGER = 101
+	SQL_MODE_READ_WRITE    SQLINTEGER = 0
+	SQL_MODE_READ_ONLY     SQLINTEGER = 1
+	SQL_ATTR_TXN_ISOLATION SQLINTEGER = 108
+	SQL_ATTR_LOGIN_TIMEOUT SQLINTEGER = 103
+	SQL_ATTR_PACKET_SIZE   SQLINTEGER = 112
+)
+
+const (
+	SQL_ATTR_CONNECTION_DEAD SQLINTEGER = 1209
+	SQL_CD_TRUE              SQLINTEGER = 1
+	SQL_CD_FALSE             SQLINTEGER = 0
+)
+
+//Transaction isolation levels
+const (
+	SQL_TXN_READ_UNCOMMITTED SQLINTEGER = 1
+	SQL_TXN_READ_COMMITTED   SQLINTEGER = 2
+	SQL_TXN_REPEATABLE_READ  SQLINTEGER = 4
+	SQL_TXN_SERIALIZABLE     SQLINTEGER = 8
+	SQL_TXN_SS_SNAPSHOT      SQLINTEGER = 32 // SQL Server snapshot isolation
 )
 
 //Statement attributes
 const (
-	SQL_QUERY_TIMEOUT           SQLINTEGER = 0
-	SQL_MAX_ROWS                SQLINTEGER = 1
-	SQL_NOSCAN                  SQLINTEGER = 2
-	SQL_ATTR_QUERY_TIMEOUT      SQLINTEGER = SQL_QUERY_TIMEOUT
-	SQL_ATTR_APP_ROW_DESC       SQLINTEGER = 10010
-	SQL_ATTR_APP_PARAM_DESC     SQLINTEGER = 10011
-	SQL_ATTR_IMP_ROW_DESC       SQLINTEGER = 10012
-	SQL_ATTR_IMP_PARAM_DESC     SQLINTEGER = 10013
-	SQL_ATTR_CURSOR_SCROLLABLE  SQLINTEGER = -1
-	SQL_ATTR_CURSOR_SENSITIVITY SQLINTEGER = -2
+	SQL_QUERY_TIMEOUT             SQLINTEGER = 0
+	SQL_MAX_ROWS                  SQLINTEGER = 1
+	SQL_NOSCAN                    SQLINTEGER = 2
+	SQL_ATTR_QUERY_TIMEOUT        SQLINTEGER = SQL_QUERY_TIMEOUT
+	SQL_ATTR_ROW_BIND_TYPE        SQLINTEGER = 5
+	SQL_ATTR_PARAM_BIND_TYPE      SQLINTEGER = 18
+	SQL_ATTR_PARAM_STATUS_PTR     SQLINTEGER = 20
+	SQL_ATTR_PARAMS_PROCESSED_PTR SQLINTEGER = 21
+	SQL_ATTR_PARAMSET_SIZE        SQLINTEGER = 22
+	SQL_ATTR_ROW_STATUS_PTR       SQLINTEGER = 25
+	SQL_ATTR_ROWS_FETCHED_PTR     SQLINTEGER = 26
+	SQL_ATTR_ROW_ARRAY_SIZE       SQLINTEGER = 27
+	SQL_ATTR_APP_ROW_DESC         SQLINTEGER = 10010
+	SQL_ATTR_APP_PARAM_DESC       SQLINTEGER = 10011
+	SQL_ATTR_IMP_ROW_DESC         SQLINTEGER = 10012
+	SQL_ATTR_IMP_PARAM_DESC       SQLINTEGER = 10013
+	SQL_ATTR_CURSOR_SCROLLABLE    SQLINTEGER = -1
+	SQL_ATTR_CURSOR_SENSITIVITY   SQLINTEGER = -2
+)
+
+//Values for SQL_ATTR_ROW_BIND_TYPE and SQL_ATTR_PARAM_BIND_TYPE
+const (
+	SQL_BIND_BY_COLUMN       = 0
+	SQL_PARAM_BIND_BY_COLUMN = 0
+)
+
+//Parameter status values for SQL_ATTR_PARAM_STATUS_PTR
+const (
+	SQL_PARAM_SUCCESS           SQLUSMALLINT = 0
+	SQL_PARAM_DIAG_UNAVAILABLE  SQLUSMALLINT = 1
+	SQL_PARAM_ERROR             SQLUSMALLINT = 5
+	SQL_PARAM_SUCCESS_WITH_INFO SQLUSMALLINT = 6
+	SQL_PARAM_UNUSED            SQLUSMALLINT = 7
 )
 
 //Code indicating that the application row descriptor specifies the data type
@@ -233,6 +355,29 @@ const (
 	SQL_CP_DEFAULT        = SQL_CP_OFF
 )
 
+//SQLSpecialColumns identifier types and scopes
+const (
+	SQL_BEST_ROWID        SQLUSMALLINT = 1
+	SQL_ROWVER            SQLUSMALLINT = 2
+	SQL_SCOPE_CURROW      SQLUSMALLINT = 0
+	SQL_SCOPE_TRANSACTION SQLUSMALLINT = 1
+	SQL_SCOPE_SESSION     SQLUSMALLINT = 2
+)
+
+//SQLStatistics options
+const (
+	SQL_INDEX_UNIQUE SQLUSMALLINT = 0
+	SQL_INDEX_ALL    SQLUSMALLINT = 1
+	SQL_QUICK        SQLUSMALLINT = 0
+	SQL_ENSURE       SQLUSMALLINT = 1
+)
+
+//Values for SQL_ATTR_CP_MATCH
+const (
+	SQL_CP_STRICT_MATCH  = 0
+	SQL_CP_RELAXED_MATCH = 1
+)
+
 //Whether an attribute is a pointer or not
 const (
 	SQL_IS_POINTER   = -4
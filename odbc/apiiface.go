@@ -0,0 +1,241 @@
+package odbc
+
+import "unsafe"
+
+// API is the ODBC surface used by the lodbc driver.  Each method calls the
+// package level function of the same name, except that buffer arguments are
+// unsafe.Pointer rather than uintptr so the memory they reference stays on the
+// heap and alive when the implementation is Go code.  SQLSetDescField takes
+// integer fields through a pointer to an SQLLEN made with IntegerField, and
+// passes the value itself to the driver manager as ODBC requires.  System calls the
+// platform driver manager; the odbc/fake package provides an in-memory
+// implementation for testing without one.
+type API interface {
+	SQLAllocHandle(handleType SQLSMALLINT, inputHandle SQLHandle, outputHandle *SQLHandle) SQLReturn
+	SQLSetEnvAttr(environmentHandle SQLHandle, attribute SQLINTEGER, valuePtr SQLPOINTER, stringLength SQLINTEGER) SQLReturn
+	SQLDriverConnect(connectionHandle SQLHandle, windowHandle int, inConnString *SQLCHAR, inConnStringLength SQLSMALLINT, outConnString *SQLCHAR, outConnStringLength SQLSMALLINT, outConnStringPtr *SQLSMALLINT, driverCompletion SQLUSMALLINT) SQLReturn
+	SQLFreeHandle(handleType SQLSMALLINT, handle SQLHandle) SQLReturn
+	SQLDisconnect(handle SQLHandle) SQLReturn
+	SQLCancel(statementHandle SQLHandle) SQLReturn
+	SQLExecDirect(statementHandle SQLHandle, statementText *SQLCHAR, textLength SQLINTEGER) SQLReturn
+	SQLCloseCursor(statementHandle SQLHandle) SQLReturn
+	SQLFetch(statementHandle SQLHandle) SQLReturn
+	SQLFetchScroll(statementHandle SQLHandle, fetchOrientation SQLSMALLINT, fetchOffset SQLLEN) SQLReturn
+	SQLSetStmtAttr(statementHandle SQLHandle, attribute SQLINTEGER, valuePtr SQLPOINTER, stringLength SQLINTEGER) SQLReturn
+	SQLBindCol(statementHandle SQLHandle, columnNumber SQLUSMALLINT, targetType SQLSMALLINT, targetValuePtr unsafe.Pointer, bufferLength SQLLEN, ind *SQLLEN) SQLReturn
+	SQLSetConnectAttr(connectionHandle SQLHandle, attribute SQLINTEGER, valuePtr SQLPOINTER, bufferLength SQLINTEGER, stringLengthPtr *SQLINTEGER) SQLReturn
+	SQLEndTran(handleType SQLSMALLINT, handle SQLHandle, completionType SQLSMALLINT) SQLReturn
+	SQLBindParameter(statementHandle SQLHandle, parameterNumber SQLUSMALLINT, inputOutputType SQLSMALLINT, valueType CDataType, parameterType SQLDataType, columnSize SQLULEN, decimalDigits SQLSMALLINT, parameterValue unsafe.Pointer, bufferLength SQLLEN, ind *SQLLEN) SQLReturn
+	SQLMoreResults(statementHandle SQLHandle) SQLReturn
+	SQLGetDescField(descriptorHandle SQLHandle, recNumber SQLSMALLINT, fieldIdentifier SQLSMALLINT, valuePtr unsafe.Pointer, bufferLength SQLINTEGER, lengthPtr *SQLINTEGER) SQLReturn
+	SQLGetDescRec(descriptorHandle SQLHandle, recNumber SQLSMALLINT, name *SQLCHAR, bufferLength SQLSMALLINT, stringLengthPtr *SQLSMALLINT, typePtr *SQLSMALLINT, subTypePtr *SQLSMALLINT, lengthPtr *SQLLEN, precisionPtr *SQLSMALLINT, scalePtr *SQLSMALLINT, nullablePtr *SQLSMALLINT) SQLReturn
+	SQLGetDiagRec(handleType SQLSMALLINT, inputHandle SQLHandle, recNumber SQLSMALLINT, sqlState unsafe.Pointer, nativeErrorPtr *SQLINTEGER, messageText unsafe.Pointer, bufferLength SQLSMALLINT, textLengthPtr *SQLSMALLINT) SQLReturn
+	SQLColAttribute(statementHandle SQLHandle, columnNumber SQLUSMALLINT, fieldIdentifier SQLColAttributeType, characterAttribute unsafe.Pointer, bufferLength SQLSMALLINT, stringLengthPtr *SQLSMALLINT, numericAttributePtr *SQLLEN) SQLReturn
+	SQLNumResultCols(statementHandle SQLHandle, columnCount *SQLSMALLINT) SQLReturn
+	SQLGetData(statementHandle SQLHandle, colNum SQLUSMALLINT, targetType CDataType, targetValuePtr unsafe.Pointer, bufferLength SQLLEN, ind *SQLLEN) SQLReturn
+	SQLGetStmtAttr(statementHandle SQLHandle, attribute SQLINTEGER, valuePtr unsafe.Pointer, bufferLength SQLINTEGER, stringLengthPtr *SQLINTEGER) SQLReturn
+	SQLSetDescField(descriptorHandle SQLHandle, recNum SQLSMALLINT, fieldIdentifier SQLSMALLINT, valuePtr unsafe.Pointer, bufferLength SQLINTEGER) SQLReturn
+	SQLPrepare(statementHandle SQLHandle, statementText *SQLCHAR, textLength SQLINTEGER) SQLReturn
+	SQLExecute(statementHandle SQLHandle) SQLReturn
+	SQLNumParams(statementHandle SQLHandle, parameterCountPtr *SQLSMALLINT) SQLReturn
+	SQLGetConnectAttr(connectionHandle SQLHandle, attribute SQLINTEGER, valuePtr unsafe.Pointer, bufferLength SQLINTEGER, stringLengthPtr *SQLINTEGER) SQLReturn
+	SQLRowCount(statementHandle SQLHandle, rowCountPtr *SQLLEN) SQLReturn
+	SQLFreeStmt(statementHandle SQLHandle, option SQLUSMALLINT) SQLReturn
+	SQLTables(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT, tableTypeName *SQLCHAR, tableTypeNameLength SQLSMALLINT) SQLReturn
+	SQLColumns(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT, columnName *SQLCHAR, columnNameLength SQLSMALLINT) SQLReturn
+	SQLPrimaryKeys(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT) SQLReturn
+	SQLForeignKeys(statementHandle SQLHandle, pkCatalogName *SQLCHAR, pkCatalogNameLength SQLSMALLINT, pkSchemaName *SQLCHAR, pkSchemaNameLength SQLSMALLINT, pkTableName *SQLCHAR, pkTableNameLength SQLSMALLINT, fkCatalogName *SQLCHAR, fkCatalogNameLength SQLSMALLINT, fkSchemaName *SQLCHAR, fkSchemaNameLength SQLSMALLINT, fkTableName *SQLCHAR, fkTableNameLength SQLSMALLINT) SQLReturn
+	SQLStatistics(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT, unique SQLUSMALLINT, reserved SQLUSMALLINT) SQLReturn
+	SQLProcedures(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, procName *SQLCHAR, procNameLength SQLSMALLINT) SQLReturn
+	SQLProcedureColumns(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, procName *SQLCHAR, procNameLength SQLSMALLINT, columnName *SQLCHAR, columnNameLength SQLSMALLINT) SQLReturn
+	SQLSpecialColumns(statementHandle SQLHandle, identifierType SQLUSMALLINT, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT, scope SQLUSMALLINT, nullable SQLUSMALLINT) SQLReturn
+	SQLParamData(statementHandle SQLHandle, valuePtrPtr *SQLPOINTER) SQLReturn
+	SQLPutData(statementHandle SQLHandle, dataPtr unsafe.Pointer, strLenOrInd SQLLEN) SQLReturn
+}
+
+// API implementation that calls the platform driver manager
+var System API = systemAPI{}
+
+type systemAPI struct{}
+
+// Loads the driver manager, returning an error if it is not installed.  The functions
+// of the API panic when called without one.
+func (systemAPI) Load() error {
+	return loadDriverManager()
+}
+
+func (systemAPI) SQLAllocHandle(handleType SQLSMALLINT, inputHandle SQLHandle, outputHandle *SQLHandle) SQLReturn {
+	return SQLAllocHandle(handleType, inputHandle, outputHandle)
+}
+
+func (systemAPI) SQLSetEnvAttr(environmentHandle SQLHandle, attribute SQLINTEGER, valuePtr SQLPOINTER, stringLength SQLINTEGER) SQLReturn {
+	return SQLSetEnvAttr(environmentHandle, attribute, valuePtr, stringLength)
+}
+
+func (systemAPI) SQLDriverConnect(connectionHandle SQLHandle, windowHandle int, inConnString *SQLCHAR, inConnStringLength SQLSMALLINT, outConnString *SQLCHAR, outConnStringLength SQLSMALLINT, outConnStringPtr *SQLSMALLINT, driverCompletion SQLUSMALLINT) SQLReturn {
+	return SQLDriverConnect(connectionHandle, windowHandle, inConnString, inConnStringLength, outConnString, outConnStringLength, outConnStringPtr, driverCompletion)
+}
+
+func (systemAPI) SQLFreeHandle(handleType SQLSMALLINT, handle SQLHandle) SQLReturn {
+	return SQLFreeHandle(handleType, handle)
+}
+
+func (systemAPI) SQLDisconnect(handle SQLHandle) SQLReturn {
+	return SQLDisconnect(handle)
+}
+
+func (systemAPI) SQLCancel(statementHandle SQLHandle) SQLReturn {
+	return SQLCancel(statementHandle)
+}
+
+func (systemAPI) SQLExecDirect(statementHandle SQLHandle, statementText *SQLCHAR, textLength SQLINTEGER) SQLReturn {
+	return SQLExecDirect(statementHandle, statementText, textLength)
+}
+
+func (systemAPI) SQLCloseCursor(statementHandle SQLHandle) SQLReturn {
+	return SQLCloseCursor(statementHandle)
+}
+
+func (systemAPI) SQLFetch(statementHandle SQLHandle) SQLReturn {
+	return SQLFetch(statementHandle)
+}
+
+func (systemAPI) SQLFetchScroll(statementHandle SQLHandle, fetchOrientation SQLSMALLINT, fetchOffset SQLLEN) SQLReturn {
+	return SQLFetchScroll(statementHandle, fetchOrientation, fetchOffset)
+}
+
+func (systemAPI) SQLSetStmtAttr(statementHandle SQLHandle, attribute SQLINTEGER, valuePtr SQLPOINTER, stringLength SQLINTEGER) SQLReturn {
+	return SQLSetStmtAttr(statementHandle, attribute, valuePtr, stringLength)
+}
+
+func (systemAPI) SQLBindCol(statementHandle SQLHandle, columnNumber SQLUSMALLINT, targetType SQLSMALLINT, targetValuePtr unsafe.Pointer, bufferLength SQLLEN, ind *SQLLEN) SQLReturn {
+	return SQLBindCol(statementHandle, columnNumber, targetType, targetValuePtr, bufferLength, ind)
+}
+
+func (systemAPI) SQLSetConnectAttr(connectionHandle SQLHandle, attribute SQLINTEGER, valuePtr SQLPOINTER, bufferLength SQLINTEGER, stringLengthPtr *SQLINTEGER) SQLReturn {
+	return SQLSetConnectAttr(connectionHandle, attribute, valuePtr, bufferLength, stringLengthPtr)
+}
+
+func (systemAPI) SQLEndTran(handleType SQLSMALLINT, handle SQLHandle, completionType SQLSMALLINT) SQLReturn {
+	return SQLEndTran(handleType, handle, completionType)
+}
+
+func (systemAPI) SQLBindParameter(statementHandle SQLHandle, parameterNumber SQLUSMALLINT, inputOutputType SQLSMALLINT, valueType CDataType, parameterType SQLDataType, columnSize SQLULEN, decimalDigits SQLSMALLINT, parameterValue unsafe.Pointer, bufferLength SQLLEN, ind *SQLLEN) SQLReturn {
+	return SQLBindParameter(statementHandle, parameterNumber, inputOutputType, valueType, parameterType, columnSize, decimalDigits, parameterValue, bufferLength, ind)
+}
+
+func (systemAPI) SQLMoreResults(statementHandle SQLHandle) SQLReturn {
+	return SQLMoreResults(statementHandle)
+}
+
+func (systemAPI) SQLGetDescField(descriptorHandle SQLHandle, recNumber SQLSMALLINT, fieldIdentifier SQLSMALLINT, valuePtr unsafe.Pointer, bufferLength SQLINTEGER, lengthPtr *SQLINTEGER) SQLReturn {
+	return SQLGetDescField(descriptorHandle, recNumber, fieldIdentifier, valuePtr, bufferLength, lengthPtr)
+}
+
+func (systemAPI) SQLGetDescRec(descriptorHandle SQLHandle, recNumber SQLSMALLINT, name *SQLCHAR, bufferLength SQLSMALLINT, stringLengthPtr *SQLSMALLINT, typePtr *SQLSMALLINT, subTypePtr *SQLSMALLINT, lengthPtr *SQLLEN, precisionPtr *SQLSMALLINT, scalePtr *SQLSMALLINT, nullablePtr *SQLSMALLINT) SQLReturn {
+	return SQLGetDescRec(descriptorHandle, recNumber, name, bufferLength, stringLengthPtr, typePtr, subTypePtr, lengthPtr, precisionPtr, scalePtr, nullablePtr)
+}
+
+func (systemAPI) SQLGetDiagRec(handleType SQLSMALLINT, inputHandle SQLHandle, recNumber SQLSMALLINT, sqlState unsafe.Pointer, nativeErrorPtr *SQLINTEGER, messageText unsafe.Pointer, bufferLength SQLSMALLINT, textLengthPtr *SQLSMALLINT) SQLReturn {
+	return SQLGetDiagRec(handleType, inputHandle, recNumber, sqlState, nativeErrorPtr, messageText, bufferLength, textLengthPtr)
+}
+
+func (systemAPI) SQLColAttribute(statementHandle SQLHandle, columnNumber SQLUSMALLINT, fieldIdentifier SQLColAttributeType, characterAttribute unsafe.Pointer, bufferLength SQLSMALLINT, stringLengthPtr *SQLSMALLINT, numericAttributePtr *SQLLEN) SQLReturn {
+	return SQLColAttribute(statementHandle, columnNumber, fieldIdentifier, characterAttribute, bufferLength, stringLengthPtr, numericAttributePtr)
+}
+
+func (systemAPI) SQLNumResultCols(statementHandle SQLHandle, columnCount *SQLSMALLINT) SQLReturn {
+	return SQLNumResultCols(statementHandle, columnCount)
+}
+
+func (systemAPI) SQLGetData(statementHandle SQLHandle, colNum SQLUSMALLINT, targetType CDataType, targetValuePtr unsafe.Pointer, bufferLength SQLLEN, ind *SQLLEN) SQLReturn {
+	return SQLGetData(statementHandle, colNum, targetType, targetValuePtr, bufferLength, ind)
+}
+
+func (systemAPI) SQLGetStmtAttr(statementHandle SQLHandle, attribute SQLINTEGER, valuePtr unsafe.Pointer, bufferLength SQLINTEGER, stringLengthPtr *SQLINTEGER) SQLReturn {
+	return SQLGetStmtAttr(statementHandle, attribute, valuePtr, bufferLength, stringLengthPtr)
+}
+
+func (systemAPI) SQLSetDescField(descriptorHandle SQLHandle, recNum SQLSMALLINT, fieldIdentifier SQLSMALLINT, valuePtr unsafe.Pointer, bufferLength SQLINTEGER) SQLReturn {
+	return SQLSetDescField(descriptorHandle, recNum, fieldIdentifier, valuePtr, bufferLength)
+}
+
+// Returns v as the valuePtr of an integer descriptor field, such as SQL_DESC_PRECISION, for API.SQLSetDescField.
+// Holding small integers in a pointer would break the garbage collector, so the value is passed by reference.
+func IntegerField(v int) unsafe.Pointer {
+	value := SQLLEN(v)
+	return unsafe.Pointer(&value)
+}
+
+// Reports whether the descriptor field is a pointer or a string, rather than an integer passed in place of a pointer
+func IsPointerDescField(fieldIdentifier SQLSMALLINT) bool {
+	switch fieldIdentifier {
+	case SQL_DESC_DATA_PTR, SQL_DESC_INDICATOR_PTR, SQL_DESC_OCTET_LENGTH_PTR, SQL_DESC_NAME:
+		return true
+	}
+	return false
+}
+
+func (systemAPI) SQLPrepare(statementHandle SQLHandle, statementText *SQLCHAR, textLength SQLINTEGER) SQLReturn {
+	return SQLPrepare(statementHandle, statementText, textLength)
+}
+
+func (systemAPI) SQLExecute(statementHandle SQLHandle) SQLReturn {
+	return SQLExecute(statementHandle)
+}
+
+func (systemAPI) SQLNumParams(statementHandle SQLHandle, parameterCountPtr *SQLSMALLINT) SQLReturn {
+	return SQLNumParams(statementHandle, parameterCountPtr)
+}
+
+func (systemAPI) SQLGetConnectAttr(connectionHandle SQLHandle, attribute SQLINTEGER, valuePtr unsafe.Pointer, bufferLength SQLINTEGER, stringLengthPtr *SQLINTEGER) SQLReturn {
+	return SQLGetConnectAttr(connectionHandle, attribute, valuePtr, bufferLength, stringLengthPtr)
+}
+
+func (systemAPI) SQLRowCount(statementHandle SQLHandle, rowCountPtr *SQLLEN) SQLReturn {
+	return SQLRowCount(statementHandle, rowCountPtr)
+}
+
+func (systemAPI) SQLFreeStmt(statementHandle SQLHandle, option SQLUSMALLINT) SQLReturn {
+	return SQLFreeStmt(statementHandle, option)
+}
+
+func (systemAPI) SQLTables(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT, tableTypeName *SQLCHAR, tableTypeNameLength SQLSMALLINT) SQLReturn {
+	return SQLTables(statementHandle, catalogName, catalogNameLength, schemaName, schemaNameLength, tableName, tableNameLength, tableTypeName, tableTypeNameLength)
+}
+
+func (systemAPI) SQLColumns(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT, columnName *SQLCHAR, columnNameLength SQLSMALLINT) SQLReturn {
+	return SQLColumns(statementHandle, catalogName, catalogNameLength, schemaName, schemaNameLength, tableName, tableNameLength, columnName, columnNameLength)
+}
+
+func (systemAPI) SQLPrimaryKeys(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT) SQLReturn {
+	return SQLPrimaryKeys(statementHandle, catalogName, catalogNameLength, schemaName, schemaNameLength, tableName, tableNameLength)
+}
+
+func (systemAPI) SQLForeignKeys(statementHandle SQLHandle, pkCatalogName *SQLCHAR, pkCatalogNameLength SQLSMALLINT, pkSchemaName *SQLCHAR, pkSchemaNameLength SQLSMALLINT, pkTableName *SQLCHAR, pkTableNameLength SQLSMALLINT, fkCatalogName *SQLCHAR, fkCatalogNameLength SQLSMALLINT, fkSchemaName *SQLCHAR, fkSchemaNameLength SQLSMALLINT, fkTableName *SQLCHAR, fkTableNameLength SQLSMALLINT) SQLReturn {
+	return SQLForeignKeys(statementHandle, pkCatalogName, pkCatalogNameLength, pkSchemaName, pkSchemaNameLength, pkTableName, pkTableNameLength, fkCatalogName, fkCatalogNameLength, fkSchemaName, fkSchemaNameLength, fkTableName, fkTableNameLength)
+}
+
+func (systemAPI) SQLStatistics(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT, unique SQLUSMALLINT, reserved SQLUSMALLINT) SQLReturn {
+	return SQLStatistics(statementHandle, catalogName, catalogNameLength, schemaName, schemaNameLength, tableName, tableNameLength, unique, reserved)
+}
+
+func (systemAPI) SQLProcedures(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, procName *SQLCHAR, procNameLength SQLSMALLINT) SQLReturn {
+	return SQLProcedures(statementHandle, catalogName, catalogNameLength, schemaName, schemaNameLength, procName, procNameLength)
+}
+
+func (systemAPI) SQLProcedureColumns(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, procName *SQLCHAR, procNameLength SQLSMALLINT, columnName *SQLCHAR, columnNameLength SQLSMALLINT) SQLReturn {
+	return SQLProcedureColumns(statementHandle, catalogName, catalogNameLength, schemaName, schemaNameLength, procName, procNameLength, columnName, columnNameLength)
+}
+
+func (systemAPI) SQLSpecialColumns(statementHandle SQLHandle, identifierType SQLUSMALLINT, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT, scope SQLUSMALLINT, nullable SQLUSMALLINT) SQLReturn {
+	return SQLSpecialColumns(statementHandle, identifierType, catalogName, catalogNameLength, schemaName, schemaNameLength, tableName, tableNameLength, scope, nullable)
+}
+
+func (systemAPI) SQLParamData(statementHandle SQLHandle, valuePtrPtr *SQLPOINTER) SQLReturn {
+	return SQLParamData(statementHandle, valuePtrPtr)
+}
+
+func (systemAPI) SQLPutData(statementHandle SQLHandle, dataPtr unsafe.Pointer, strLenOrInd SQLLEN) SQLReturn {
+	return SQLPutData(statementHandle, dataPtr, strLenOrInd)
+}
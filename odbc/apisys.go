@@ -1,5 +1,7 @@
-// mksyscall_windows.pl api.go
-// MACHINE GENERATED BY THE COMMAND ABOVE; DO NOT EDIT
+//go:build windows
+
+// Calls the functions declared in api.go in odbc32.dll.  First generated by
+// mksyscall_windows.pl from api.go and since edited by hand.
 
 package odbc
 
@@ -9,30 +11,46 @@ import "syscall"
 var (
 	mododbc32 = syscall.NewLazyDLL("odbc32.dll")
 
-	procSQLAllocHandle     = mododbc32.NewProc("SQLAllocHandle")
-	procSQLSetEnvAttr      = mododbc32.NewProc("SQLSetEnvAttr")
-	procSQLDriverConnectW  = mododbc32.NewProc("SQLDriverConnectW")
-	procSQLFreeHandle      = mododbc32.NewProc("SQLFreeHandle")
-	procSQLDisconnect      = mododbc32.NewProc("SQLDisconnect")
-	procSQLCancel          = mododbc32.NewProc("SQLCancel")
-	procSQLExecDirectW     = mododbc32.NewProc("SQLExecDirectW")
-	procSQLCloseCursor     = mododbc32.NewProc("SQLCloseCursor")
-	procSQLFetch           = mododbc32.NewProc("SQLFetch")
-	procSQLFetchScroll     = mododbc32.NewProc("SQLFetchScroll")
-	procSQLSetStmtAttr     = mododbc32.NewProc("SQLSetStmtAttr")
-	procSQLBindCol         = mododbc32.NewProc("SQLBindCol")
-	procSQLSetConnectAttrW = mododbc32.NewProc("SQLSetConnectAttrW")
-	procSQLEndTran         = mododbc32.NewProc("SQLEndTran")
-	procSQLBindParameter   = mododbc32.NewProc("SQLBindParameter")
-	procSQLMoreResults     = mododbc32.NewProc("SQLMoreResults")
-	procSQLGetDescField    = mododbc32.NewProc("SQLGetDescField")
-	procSQLGetDescRecW     = mododbc32.NewProc("SQLGetDescRecW")
-	procSQLGetDiagRecW     = mododbc32.NewProc("SQLGetDiagRecW")
-	procSQLColAttributeW   = mododbc32.NewProc("SQLColAttributeW")
-	procSQLNumResultCols   = mododbc32.NewProc("SQLNumResultCols")
-	procSQLGetData         = mododbc32.NewProc("SQLGetData")
-	procSQLGetStmtAttr     = mododbc32.NewProc("SQLGetStmtAttr")
-	procSQLSetDescFieldW   = mododbc32.NewProc("SQLSetDescFieldW")
+	procSQLAllocHandle       = mododbc32.NewProc("SQLAllocHandle")
+	procSQLSetEnvAttr        = mododbc32.NewProc("SQLSetEnvAttr")
+	procSQLDriverConnectW    = mododbc32.NewProc("SQLDriverConnectW")
+	procSQLFreeHandle        = mododbc32.NewProc("SQLFreeHandle")
+	procSQLDisconnect        = mododbc32.NewProc("SQLDisconnect")
+	procSQLCancel            = mododbc32.NewProc("SQLCancel")
+	procSQLExecDirectW       = mododbc32.NewProc("SQLExecDirectW")
+	procSQLCloseCursor       = mododbc32.NewProc("SQLCloseCursor")
+	procSQLFetch             = mododbc32.NewProc("SQLFetch")
+	procSQLFetchScroll       = mododbc32.NewProc("SQLFetchScroll")
+	procSQLSetStmtAttr       = mododbc32.NewProc("SQLSetStmtAttr")
+	procSQLBindCol           = mododbc32.NewProc("SQLBindCol")
+	procSQLSetConnectAttrW   = mododbc32.NewProc("SQLSetConnectAttrW")
+	procSQLEndTran           = mododbc32.NewProc("SQLEndTran")
+	procSQLBindParameter     = mododbc32.NewProc("SQLBindParameter")
+	procSQLMoreResults       = mododbc32.NewProc("SQLMoreResults")
+	procSQLGetDescField      = mododbc32.NewProc("SQLGetDescField")
+	procSQLGetDescRecW       = mododbc32.NewProc("SQLGetDescRecW")
+	procSQLGetDiagRecW       = mododbc32.NewProc("SQLGetDiagRecW")
+	procSQLColAttributeW     = mododbc32.NewProc("SQLColAttributeW")
+	procSQLNumResultCols     = mododbc32.NewProc("SQLNumResultCols")
+	procSQLGetData           = mododbc32.NewProc("SQLGetData")
+	procSQLGetStmtAttr       = mododbc32.NewProc("SQLGetStmtAttr")
+	procSQLSetDescFieldW     = mododbc32.NewProc("SQLSetDescFieldW")
+	procSQLPrepareW          = mododbc32.NewProc("SQLPrepareW")
+	procSQLExecute           = mododbc32.NewProc("SQLExecute")
+	procSQLNumParams         = mododbc32.NewProc("SQLNumParams")
+	procSQLGetConnectAttrW   = mododbc32.NewProc("SQLGetConnectAttrW")
+	procSQLRowCount          = mododbc32.NewProc("SQLRowCount")
+	procSQLFreeStmt          = mododbc32.NewProc("SQLFreeStmt")
+	procSQLTablesW           = mododbc32.NewProc("SQLTablesW")
+	procSQLColumnsW          = mododbc32.NewProc("SQLColumnsW")
+	procSQLPrimaryKeysW      = mododbc32.NewProc("SQLPrimaryKeysW")
+	procSQLForeignKeysW      = mododbc32.NewProc("SQLForeignKeysW")
+	procSQLStatisticsW       = mododbc32.NewProc("SQLStatisticsW")
+	procSQLProceduresW       = mododbc32.NewProc("SQLProceduresW")
+	procSQLProcedureColumnsW = mododbc32.NewProc("SQLProcedureColumnsW")
+	procSQLSpecialColumnsW   = mododbc32.NewProc("SQLSpecialColumnsW")
+	procSQLParamData         = mododbc32.NewProc("SQLParamData")
+	procSQLPutData           = mododbc32.NewProc("SQLPutData")
 )
 
 func SQLAllocHandle(handleType SQLSMALLINT, inputHandle SQLHandle, outputHandle *SQLHandle) (ret SQLReturn) {
@@ -101,7 +119,7 @@ func SQLSetStmtAttr(statementHandle SQLHandle, attribute SQLINTEGER, valuePtr SQ
 	return
 }
 
-func SQLBindCol(statementHandle SQLHandle, columnNumber SQLUSMALLINT, targetType SQLSMALLINT, targetValuePtr SQLPOINTER, bufferLength SQLLEN, ind *SQLLEN) (ret SQLReturn) {
+func SQLBindCol(statementHandle SQLHandle, columnNumber SQLUSMALLINT, targetType SQLSMALLINT, targetValuePtr unsafe.Pointer, bufferLength SQLLEN, ind *SQLLEN) (ret SQLReturn) {
 	r0, _, _ := syscall.Syscall6(procSQLBindCol.Addr(), 6, uintptr(statementHandle), uintptr(columnNumber), uintptr(targetType), uintptr(targetValuePtr), uintptr(bufferLength), uintptr(unsafe.Pointer(ind)))
 	ret = SQLReturn(r0)
 	return
@@ -119,7 +137,7 @@ func SQLEndTran(handleType SQLSMALLINT, handle SQLHandle, completionType SQLSMAL
 	return
 }
 
-func SQLBindParameter(statementHandle SQLHandle, parameterNumber SQLUSMALLINT, inputOutputType SQLSMALLINT, valueType CDataType, parameterType SQLDataType, columnSize SQLULEN, decimalDigits SQLSMALLINT, parameterValue SQLPOINTER, bufferLength SQLLEN, ind *SQLLEN) (ret SQLReturn) {
+func SQLBindParameter(statementHandle SQLHandle, parameterNumber SQLUSMALLINT, inputOutputType SQLSMALLINT, valueType CDataType, parameterType SQLDataType, columnSize SQLULEN, decimalDigits SQLSMALLINT, parameterValue unsafe.Pointer, bufferLength SQLLEN, ind *SQLLEN) (ret SQLReturn) {
 	r0, _, _ := syscall.Syscall12(procSQLBindParameter.Addr(), 10, uintptr(statementHandle), uintptr(parameterNumber), uintptr(inputOutputType), uintptr(valueType), uintptr(parameterType), uintptr(columnSize), uintptr(decimalDigits), uintptr(parameterValue), uintptr(bufferLength), uintptr(unsafe.Pointer(ind)), 0, 0)
 	ret = SQLReturn(r0)
 	return
@@ -131,7 +149,7 @@ func SQLMoreResults(statementHandle SQLHandle) (ret SQLReturn) {
 	return
 }
 
-func SQLGetDescField(descriptorHandle SQLHandle, recNumber SQLSMALLINT, fieldIdentifier SQLSMALLINT, valuePtr SQLPOINTER, bufferLength SQLINTEGER, lengthPtr *SQLINTEGER) (ret SQLReturn) {
+func SQLGetDescField(descriptorHandle SQLHandle, recNumber SQLSMALLINT, fieldIdentifier SQLSMALLINT, valuePtr unsafe.Pointer, bufferLength SQLINTEGER, lengthPtr *SQLINTEGER) (ret SQLReturn) {
 	r0, _, _ := syscall.Syscall6(procSQLGetDescField.Addr(), 6, uintptr(descriptorHandle), uintptr(recNumber), uintptr(fieldIdentifier), uintptr(valuePtr), uintptr(bufferLength), uintptr(unsafe.Pointer(lengthPtr)))
 	ret = SQLReturn(r0)
 	return
@@ -143,13 +161,13 @@ func SQLGetDescRec(descriptorHandle SQLHandle, recNumber SQLSMALLINT, name *SQLC
 	return
 }
 
-func SQLGetDiagRec(handleType SQLSMALLINT, inputHandle SQLHandle, recNumber SQLSMALLINT, sqlState uintptr, nativeErrorPtr *SQLINTEGER, messageText uintptr, bufferLength SQLSMALLINT, textLengthPtr *SQLSMALLINT) (ret SQLReturn) {
+func SQLGetDiagRec(handleType SQLSMALLINT, inputHandle SQLHandle, recNumber SQLSMALLINT, sqlState unsafe.Pointer, nativeErrorPtr *SQLINTEGER, messageText unsafe.Pointer, bufferLength SQLSMALLINT, textLengthPtr *SQLSMALLINT) (ret SQLReturn) {
 	r0, _, _ := syscall.Syscall9(procSQLGetDiagRecW.Addr(), 8, uintptr(handleType), uintptr(inputHandle), uintptr(recNumber), uintptr(sqlState), uintptr(unsafe.Pointer(nativeErrorPtr)), uintptr(messageText), uintptr(bufferLength), uintptr(unsafe.Pointer(textLengthPtr)), 0)
 	ret = SQLReturn(r0)
 	return
 }
 
-func SQLColAttribute(statementHandle SQLHandle, columnNumber SQLUSMALLINT, fieldIdentifier SQLColAttributeType, characterAttribute uintptr, bufferLength SQLSMALLINT, stringLengthPtr *SQLSMALLINT, numericAttributePtr *SQLLEN) (ret SQLReturn) {
+func SQLColAttribute(statementHandle SQLHandle, columnNumber SQLUSMALLINT, fieldIdentifier SQLColAttributeType, characterAttribute unsafe.Pointer, bufferLength SQLSMALLINT, stringLengthPtr *SQLSMALLINT, numericAttributePtr *SQLLEN) (ret SQLReturn) {
 	r0, _, _ := syscall.Syscall9(procSQLColAttributeW.Addr(), 7, uintptr(statementHandle), uintptr(columnNumber), uintptr(fieldIdentifier), uintptr(characterAttribute), uintptr(bufferLength), uintptr(unsafe.Pointer(stringLengthPtr)), uintptr(unsafe.Pointer(numericAttributePtr)), 0, 0)
 	ret = SQLReturn(r0)
 	return
@@ -161,20 +179,122 @@ func SQLNumResultCols(statementHandle SQLHandle, columnCount *SQLSMALLINT) (ret
 	return
 }
 
-func SQLGetData(statementHandle SQLHandle, colNum SQLUSMALLINT, targetType CDataType, targetValuePtr uintptr, bufferLength SQLLEN, ind *SQLLEN) (ret SQLReturn) {
+func SQLGetData(statementHandle SQLHandle, colNum SQLUSMALLINT, targetType CDataType, targetValuePtr unsafe.Pointer, bufferLength SQLLEN, ind *SQLLEN) (ret SQLReturn) {
 	r0, _, _ := syscall.Syscall6(procSQLGetData.Addr(), 6, uintptr(statementHandle), uintptr(colNum), uintptr(targetType), uintptr(targetValuePtr), uintptr(bufferLength), uintptr(unsafe.Pointer(ind)))
 	ret = SQLReturn(r0)
 	return
 }
 
-func SQLGetStmtAttr(statementHandle SQLHandle, attribute SQLINTEGER, valuePtr uintptr, bufferLength SQLINTEGER, stringLengthPtr *SQLINTEGER) (ret SQLReturn) {
+func SQLGetStmtAttr(statementHandle SQLHandle, attribute SQLINTEGER, valuePtr unsafe.Pointer, bufferLength SQLINTEGER, stringLengthPtr *SQLINTEGER) (ret SQLReturn) {
 	r0, _, _ := syscall.Syscall6(procSQLGetStmtAttr.Addr(), 5, uintptr(statementHandle), uintptr(attribute), uintptr(valuePtr), uintptr(bufferLength), uintptr(unsafe.Pointer(stringLengthPtr)), 0)
 	ret = SQLReturn(r0)
 	return
 }
 
-func SQLSetDescField(descriptorHandle SQLHandle, recNum SQLSMALLINT, fieldIdentifier SQLSMALLINT, valuePtr uintptr, bufferLength SQLINTEGER) (ret SQLReturn) {
+func SQLSetDescField(descriptorHandle SQLHandle, recNum SQLSMALLINT, fieldIdentifier SQLSMALLINT, valuePtr unsafe.Pointer, bufferLength SQLINTEGER) (ret SQLReturn) {
+	//Integer fields are passed in place of the pointer
+	if !IsPointerDescField(fieldIdentifier) {
+		r0, _, _ := syscall.Syscall6(procSQLSetDescFieldW.Addr(), 5, uintptr(descriptorHandle), uintptr(recNum), uintptr(fieldIdentifier), uintptr(*(*SQLLEN)(valuePtr)), uintptr(bufferLength), 0)
+		ret = SQLReturn(r0)
+		return
+	}
 	r0, _, _ := syscall.Syscall6(procSQLSetDescFieldW.Addr(), 5, uintptr(descriptorHandle), uintptr(recNum), uintptr(fieldIdentifier), uintptr(valuePtr), uintptr(bufferLength), 0)
 	ret = SQLReturn(r0)
 	return
 }
+
+func SQLPrepare(statementHandle SQLHandle, statementText *SQLCHAR, textLength SQLINTEGER) (ret SQLReturn) {
+	r0, _, _ := syscall.Syscall(procSQLPrepareW.Addr(), 3, uintptr(statementHandle), uintptr(unsafe.Pointer(statementText)), uintptr(textLength))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLExecute(statementHandle SQLHandle) (ret SQLReturn) {
+	r0, _, _ := syscall.Syscall(procSQLExecute.Addr(), 1, uintptr(statementHandle), 0, 0)
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLNumParams(statementHandle SQLHandle, parameterCountPtr *SQLSMALLINT) (ret SQLReturn) {
+	r0, _, _ := syscall.Syscall(procSQLNumParams.Addr(), 2, uintptr(statementHandle), uintptr(unsafe.Pointer(parameterCountPtr)), 0)
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLGetConnectAttr(connectionHandle SQLHandle, attribute SQLINTEGER, valuePtr unsafe.Pointer, bufferLength SQLINTEGER, stringLengthPtr *SQLINTEGER) (ret SQLReturn) {
+	r0, _, _ := syscall.Syscall6(procSQLGetConnectAttrW.Addr(), 5, uintptr(connectionHandle), uintptr(attribute), uintptr(valuePtr), uintptr(bufferLength), uintptr(unsafe.Pointer(stringLengthPtr)), 0)
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLRowCount(statementHandle SQLHandle, rowCountPtr *SQLLEN) (ret SQLReturn) {
+	r0, _, _ := syscall.Syscall(procSQLRowCount.Addr(), 2, uintptr(statementHandle), uintptr(unsafe.Pointer(rowCountPtr)), 0)
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLFreeStmt(statementHandle SQLHandle, option SQLUSMALLINT) (ret SQLReturn) {
+	r0, _, _ := syscall.Syscall(procSQLFreeStmt.Addr(), 2, uintptr(statementHandle), uintptr(option), 0)
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLTables(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT, tableTypeName *SQLCHAR, tableTypeNameLength SQLSMALLINT) (ret SQLReturn) {
+	r0, _, _ := syscall.Syscall9(procSQLTablesW.Addr(), 9, uintptr(statementHandle), uintptr(unsafe.Pointer(catalogName)), uintptr(catalogNameLength), uintptr(unsafe.Pointer(schemaName)), uintptr(schemaNameLength), uintptr(unsafe.Pointer(tableName)), uintptr(tableNameLength), uintptr(unsafe.Pointer(tableTypeName)), uintptr(tableTypeNameLength))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLColumns(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT, columnName *SQLCHAR, columnNameLength SQLSMALLINT) (ret SQLReturn) {
+	r0, _, _ := syscall.Syscall9(procSQLColumnsW.Addr(), 9, uintptr(statementHandle), uintptr(unsafe.Pointer(catalogName)), uintptr(catalogNameLength), uintptr(unsafe.Pointer(schemaName)), uintptr(schemaNameLength), uintptr(unsafe.Pointer(tableName)), uintptr(tableNameLength), uintptr(unsafe.Pointer(columnName)), uintptr(columnNameLength))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLPrimaryKeys(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT) (ret SQLReturn) {
+	r0, _, _ := syscall.Syscall9(procSQLPrimaryKeysW.Addr(), 7, uintptr(statementHandle), uintptr(unsafe.Pointer(catalogName)), uintptr(catalogNameLength), uintptr(unsafe.Pointer(schemaName)), uintptr(schemaNameLength), uintptr(unsafe.Pointer(tableName)), uintptr(tableNameLength), 0, 0)
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLForeignKeys(statementHandle SQLHandle, pkCatalogName *SQLCHAR, pkCatalogNameLength SQLSMALLINT, pkSchemaName *SQLCHAR, pkSchemaNameLength SQLSMALLINT, pkTableName *SQLCHAR, pkTableNameLength SQLSMALLINT, fkCatalogName *SQLCHAR, fkCatalogNameLength SQLSMALLINT, fkSchemaName *SQLCHAR, fkSchemaNameLength SQLSMALLINT, fkTableName *SQLCHAR, fkTableNameLength SQLSMALLINT) (ret SQLReturn) {
+	r0, _, _ := syscall.Syscall15(procSQLForeignKeysW.Addr(), 13, uintptr(statementHandle), uintptr(unsafe.Pointer(pkCatalogName)), uintptr(pkCatalogNameLength), uintptr(unsafe.Pointer(pkSchemaName)), uintptr(pkSchemaNameLength), uintptr(unsafe.Pointer(pkTableName)), uintptr(pkTableNameLength), uintptr(unsafe.Pointer(fkCatalogName)), uintptr(fkCatalogNameLength), uintptr(unsafe.Pointer(fkSchemaName)), uintptr(fkSchemaNameLength), uintptr(unsafe.Pointer(fkTableName)), uintptr(fkTableNameLength), 0, 0)
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLStatistics(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT, unique SQLUSMALLINT, reserved SQLUSMALLINT) (ret SQLReturn) {
+	r0, _, _ := syscall.Syscall9(procSQLStatisticsW.Addr(), 9, uintptr(statementHandle), uintptr(unsafe.Pointer(catalogName)), uintptr(catalogNameLength), uintptr(unsafe.Pointer(schemaName)), uintptr(schemaNameLength), uintptr(unsafe.Pointer(tableName)), uintptr(tableNameLength), uintptr(unique), uintptr(reserved))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLProcedures(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, procName *SQLCHAR, procNameLength SQLSMALLINT) (ret SQLReturn) {
+	r0, _, _ := syscall.Syscall9(procSQLProceduresW.Addr(), 7, uintptr(statementHandle), uintptr(unsafe.Pointer(catalogName)), uintptr(catalogNameLength), uintptr(unsafe.Pointer(schemaName)), uintptr(schemaNameLength), uintptr(unsafe.Pointer(procName)), uintptr(procNameLength), 0, 0)
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLProcedureColumns(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, procName *SQLCHAR, procNameLength SQLSMALLINT, columnName *SQLCHAR, columnNameLength SQLSMALLINT) (ret SQLReturn) {
+	r0, _, _ := syscall.Syscall9(procSQLProcedureColumnsW.Addr(), 9, uintptr(statementHandle), uintptr(unsafe.Pointer(catalogName)), uintptr(catalogNameLength), uintptr(unsafe.Pointer(schemaName)), uintptr(schemaNameLength), uintptr(unsafe.Pointer(procName)), uintptr(procNameLength), uintptr(unsafe.Pointer(columnName)), uintptr(columnNameLength))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLSpecialColumns(statementHandle SQLHandle, identifierType SQLUSMALLINT, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT, scope SQLUSMALLINT, nullable SQLUSMALLINT) (ret SQLReturn) {
+	r0, _, _ := syscall.Syscall12(procSQLSpecialColumnsW.Addr(), 10, uintptr(statementHandle), uintptr(identifierType), uintptr(unsafe.Pointer(catalogName)), uintptr(catalogNameLength), uintptr(unsafe.Pointer(schemaName)), uintptr(schemaNameLength), uintptr(unsafe.Pointer(tableName)), uintptr(tableNameLength), uintptr(scope), uintptr(nullable), 0, 0)
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLParamData(statementHandle SQLHandle, valuePtrPtr *SQLPOINTER) (ret SQLReturn) {
+	r0, _, _ := syscall.Syscall(procSQLParamData.Addr(), 2, uintptr(statementHandle), uintptr(unsafe.Pointer(valuePtrPtr)), 0)
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLPutData(statementHandle SQLHandle, dataPtr unsafe.Pointer, strLenOrInd SQLLEN) (ret SQLReturn) {
+	r0, _, _ := syscall.Syscall(procSQLPutData.Addr(), 3, uintptr(statementHandle), uintptr(dataPtr), uintptr(strLenOrInd))
+	ret = SQLReturn(r0)
+	return
+}
@@ -0,0 +1,300 @@
+//go:build !windows
+
+// Counterpart to apisys.go for other systems, calling the same functions in the
+// unixODBC driver manager (libodbc.so) through lazyLib.  lazyLib loads it with
+// dlopen on Linux with cgo, and elsewhere fails to load.
+
+package odbc
+
+import "unsafe"
+
+var (
+	mododbc = newLazyLib(driverManagerNames)
+
+	procSQLAllocHandle       = mododbc.NewProc("SQLAllocHandle")
+	procSQLSetEnvAttr        = mododbc.NewProc("SQLSetEnvAttr")
+	procSQLDriverConnectW    = mododbc.NewProc("SQLDriverConnectW")
+	procSQLFreeHandle        = mododbc.NewProc("SQLFreeHandle")
+	procSQLDisconnect        = mododbc.NewProc("SQLDisconnect")
+	procSQLCancel            = mododbc.NewProc("SQLCancel")
+	procSQLExecDirectW       = mododbc.NewProc("SQLExecDirectW")
+	procSQLCloseCursor       = mododbc.NewProc("SQLCloseCursor")
+	procSQLFetch             = mododbc.NewProc("SQLFetch")
+	procSQLFetchScroll       = mododbc.NewProc("SQLFetchScroll")
+	procSQLSetStmtAttr       = mododbc.NewProc("SQLSetStmtAttr")
+	procSQLBindCol           = mododbc.NewProc("SQLBindCol")
+	procSQLSetConnectAttrW   = mododbc.NewProc("SQLSetConnectAttrW")
+	procSQLEndTran           = mododbc.NewProc("SQLEndTran")
+	procSQLBindParameter     = mododbc.NewProc("SQLBindParameter")
+	procSQLMoreResults       = mododbc.NewProc("SQLMoreResults")
+	procSQLGetDescField      = mododbc.NewProc("SQLGetDescField")
+	procSQLGetDescRecW       = mododbc.NewProc("SQLGetDescRecW")
+	procSQLGetDiagRecW       = mododbc.NewProc("SQLGetDiagRecW")
+	procSQLColAttributeW     = mododbc.NewProc("SQLColAttributeW")
+	procSQLNumResultCols     = mododbc.NewProc("SQLNumResultCols")
+	procSQLGetData           = mododbc.NewProc("SQLGetData")
+	procSQLGetStmtAttr       = mododbc.NewProc("SQLGetStmtAttr")
+	procSQLSetDescFieldW     = mododbc.NewProc("SQLSetDescFieldW")
+	procSQLPrepareW          = mododbc.NewProc("SQLPrepareW")
+	procSQLExecute           = mododbc.NewProc("SQLExecute")
+	procSQLNumParams         = mododbc.NewProc("SQLNumParams")
+	procSQLGetConnectAttrW   = mododbc.NewProc("SQLGetConnectAttrW")
+	procSQLRowCount          = mododbc.NewProc("SQLRowCount")
+	procSQLFreeStmt          = mododbc.NewProc("SQLFreeStmt")
+	procSQLTablesW           = mododbc.NewProc("SQLTablesW")
+	procSQLColumnsW          = mododbc.NewProc("SQLColumnsW")
+	procSQLPrimaryKeysW      = mododbc.NewProc("SQLPrimaryKeysW")
+	procSQLForeignKeysW      = mododbc.NewProc("SQLForeignKeysW")
+	procSQLStatisticsW       = mododbc.NewProc("SQLStatisticsW")
+	procSQLProceduresW       = mododbc.NewProc("SQLProceduresW")
+	procSQLProcedureColumnsW = mododbc.NewProc("SQLProcedureColumnsW")
+	procSQLSpecialColumnsW   = mododbc.NewProc("SQLSpecialColumnsW")
+	procSQLParamData         = mododbc.NewProc("SQLParamData")
+	procSQLPutData           = mododbc.NewProc("SQLPutData")
+)
+
+func SQLAllocHandle(handleType SQLSMALLINT, inputHandle SQLHandle, outputHandle *SQLHandle) (ret SQLReturn) {
+	r0 := procSQLAllocHandle.Call(uintptr(handleType), uintptr(inputHandle), uintptr(unsafe.Pointer(outputHandle)))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLSetEnvAttr(environmentHandle SQLHandle, attribute SQLINTEGER, valuePtr SQLPOINTER, stringLength SQLINTEGER) (ret SQLReturn) {
+	r0 := procSQLSetEnvAttr.Call(uintptr(environmentHandle), uintptr(attribute), uintptr(valuePtr), uintptr(stringLength))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLDriverConnect(connectionHandle SQLHandle, windowHandle int, inConnString *SQLCHAR, inConnStringLength SQLSMALLINT, outConnString *SQLCHAR, outConnStringLength SQLSMALLINT, outConnStringPtr *SQLSMALLINT, driverCompletion SQLUSMALLINT) (ret SQLReturn) {
+	r0 := procSQLDriverConnectW.Call(uintptr(connectionHandle), uintptr(windowHandle), uintptr(unsafe.Pointer(inConnString)), uintptr(inConnStringLength), uintptr(unsafe.Pointer(outConnString)), uintptr(outConnStringLength), uintptr(unsafe.Pointer(outConnStringPtr)), uintptr(driverCompletion))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLFreeHandle(handleType SQLSMALLINT, handle SQLHandle) (ret SQLReturn) {
+	r0 := procSQLFreeHandle.Call(uintptr(handleType), uintptr(handle))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLDisconnect(handle SQLHandle) (ret SQLReturn) {
+	r0 := procSQLDisconnect.Call(uintptr(handle))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLCancel(statementHandle SQLHandle) (ret SQLReturn) {
+	r0 := procSQLCancel.Call(uintptr(statementHandle))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLExecDirect(statementHandle SQLHandle, statementText *SQLCHAR, textLength SQLINTEGER) (ret SQLReturn) {
+	r0 := procSQLExecDirectW.Call(uintptr(statementHandle), uintptr(unsafe.Pointer(statementText)), uintptr(textLength))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLCloseCursor(statementHandle SQLHandle) (ret SQLReturn) {
+	r0 := procSQLCloseCursor.Call(uintptr(statementHandle))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLFetch(statementHandle SQLHandle) (ret SQLReturn) {
+	r0 := procSQLFetch.Call(uintptr(statementHandle))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLFetchScroll(statementHandle SQLHandle, fetchOrientation SQLSMALLINT, fetchOffset SQLLEN) (ret SQLReturn) {
+	r0 := procSQLFetchScroll.Call(uintptr(statementHandle), uintptr(fetchOrientation), uintptr(fetchOffset))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLSetStmtAttr(statementHandle SQLHandle, attribute SQLINTEGER, valuePtr SQLPOINTER, stringLength SQLINTEGER) (ret SQLReturn) {
+	r0 := procSQLSetStmtAttr.Call(uintptr(statementHandle), uintptr(attribute), uintptr(valuePtr), uintptr(stringLength))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLBindCol(statementHandle SQLHandle, columnNumber SQLUSMALLINT, targetType SQLSMALLINT, targetValuePtr unsafe.Pointer, bufferLength SQLLEN, ind *SQLLEN) (ret SQLReturn) {
+	r0 := procSQLBindCol.Call(uintptr(statementHandle), uintptr(columnNumber), uintptr(targetType), uintptr(targetValuePtr), uintptr(bufferLength), uintptr(unsafe.Pointer(ind)))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLSetConnectAttr(connectionHandle SQLHandle, attribute SQLINTEGER, valuePtr SQLPOINTER, bufferLength SQLINTEGER, stringLengthPtr *SQLINTEGER) (ret SQLReturn) {
+	r0 := procSQLSetConnectAttrW.Call(uintptr(connectionHandle), uintptr(attribute), uintptr(valuePtr), uintptr(bufferLength), uintptr(unsafe.Pointer(stringLengthPtr)))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLEndTran(handleType SQLSMALLINT, handle SQLHandle, completionType SQLSMALLINT) (ret SQLReturn) {
+	r0 := procSQLEndTran.Call(uintptr(handleType), uintptr(handle), uintptr(completionType))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLBindParameter(statementHandle SQLHandle, parameterNumber SQLUSMALLINT, inputOutputType SQLSMALLINT, valueType CDataType, parameterType SQLDataType, columnSize SQLULEN, decimalDigits SQLSMALLINT, parameterValue unsafe.Pointer, bufferLength SQLLEN, ind *SQLLEN) (ret SQLReturn) {
+	r0 := procSQLBindParameter.Call(uintptr(statementHandle), uintptr(parameterNumber), uintptr(inputOutputType), uintptr(valueType), uintptr(parameterType), uintptr(columnSize), uintptr(decimalDigits), uintptr(parameterValue), uintptr(bufferLength), uintptr(unsafe.Pointer(ind)))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLMoreResults(statementHandle SQLHandle) (ret SQLReturn) {
+	r0 := procSQLMoreResults.Call(uintptr(statementHandle))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLGetDescField(descriptorHandle SQLHandle, recNumber SQLSMALLINT, fieldIdentifier SQLSMALLINT, valuePtr unsafe.Pointer, bufferLength SQLINTEGER, lengthPtr *SQLINTEGER) (ret SQLReturn) {
+	r0 := procSQLGetDescField.Call(uintptr(descriptorHandle), uintptr(recNumber), uintptr(fieldIdentifier), uintptr(valuePtr), uintptr(bufferLength), uintptr(unsafe.Pointer(lengthPtr)))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLGetDescRec(descriptorHandle SQLHandle, recNumber SQLSMALLINT, name *SQLCHAR, bufferLength SQLSMALLINT, stringLengthPtr *SQLSMALLINT, typePtr *SQLSMALLINT, subTypePtr *SQLSMALLINT, lengthPtr *SQLLEN, precisionPtr *SQLSMALLINT, scalePtr *SQLSMALLINT, nullablePtr *SQLSMALLINT) (ret SQLReturn) {
+	r0 := procSQLGetDescRecW.Call(uintptr(descriptorHandle), uintptr(recNumber), uintptr(unsafe.Pointer(name)), uintptr(bufferLength), uintptr(unsafe.Pointer(stringLengthPtr)), uintptr(unsafe.Pointer(typePtr)), uintptr(unsafe.Pointer(subTypePtr)), uintptr(unsafe.Pointer(lengthPtr)), uintptr(unsafe.Pointer(precisionPtr)), uintptr(unsafe.Pointer(scalePtr)), uintptr(unsafe.Pointer(nullablePtr)))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLGetDiagRec(handleType SQLSMALLINT, inputHandle SQLHandle, recNumber SQLSMALLINT, sqlState unsafe.Pointer, nativeErrorPtr *SQLINTEGER, messageText unsafe.Pointer, bufferLength SQLSMALLINT, textLengthPtr *SQLSMALLINT) (ret SQLReturn) {
+	r0 := procSQLGetDiagRecW.Call(uintptr(handleType), uintptr(inputHandle), uintptr(recNumber), uintptr(sqlState), uintptr(unsafe.Pointer(nativeErrorPtr)), uintptr(messageText), uintptr(bufferLength), uintptr(unsafe.Pointer(textLengthPtr)))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLColAttribute(statementHandle SQLHandle, columnNumber SQLUSMALLINT, fieldIdentifier SQLColAttributeType, characterAttribute unsafe.Pointer, bufferLength SQLSMALLINT, stringLengthPtr *SQLSMALLINT, numericAttributePtr *SQLLEN) (ret SQLReturn) {
+	r0 := procSQLColAttributeW.Call(uintptr(statementHandle), uintptr(columnNumber), uintptr(fieldIdentifier), uintptr(characterAttribute), uintptr(bufferLength), uintptr(unsafe.Pointer(stringLengthPtr)), uintptr(unsafe.Pointer(numericAttributePtr)))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLNumResultCols(statementHandle SQLHandle, columnCount *SQLSMALLINT) (ret SQLReturn) {
+	r0 := procSQLNumResultCols.Call(uintptr(statementHandle), uintptr(unsafe.Pointer(columnCount)))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLGetData(statementHandle SQLHandle, colNum SQLUSMALLINT, targetType CDataType, targetValuePtr unsafe.Pointer, bufferLength SQLLEN, ind *SQLLEN) (ret SQLReturn) {
+	r0 := procSQLGetData.Call(uintptr(statementHandle), uintptr(colNum), uintptr(targetType), uintptr(targetValuePtr), uintptr(bufferLength), uintptr(unsafe.Pointer(ind)))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLGetStmtAttr(statementHandle SQLHandle, attribute SQLINTEGER, valuePtr unsafe.Pointer, bufferLength SQLINTEGER, stringLengthPtr *SQLINTEGER) (ret SQLReturn) {
+	r0 := procSQLGetStmtAttr.Call(uintptr(statementHandle), uintptr(attribute), uintptr(valuePtr), uintptr(bufferLength), uintptr(unsafe.Pointer(stringLengthPtr)))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLSetDescField(descriptorHandle SQLHandle, recNum SQLSMALLINT, fieldIdentifier SQLSMALLINT, valuePtr unsafe.Pointer, bufferLength SQLINTEGER) (ret SQLReturn) {
+	//Integer fields are passed in place of the pointer
+	if !IsPointerDescField(fieldIdentifier) {
+		r0 := procSQLSetDescFieldW.Call(uintptr(descriptorHandle), uintptr(recNum), uintptr(fieldIdentifier), uintptr(*(*SQLLEN)(valuePtr)), uintptr(bufferLength))
+		ret = SQLReturn(r0)
+		return
+	}
+	r0 := procSQLSetDescFieldW.Call(uintptr(descriptorHandle), uintptr(recNum), uintptr(fieldIdentifier), uintptr(valuePtr), uintptr(bufferLength))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLPrepare(statementHandle SQLHandle, statementText *SQLCHAR, textLength SQLINTEGER) (ret SQLReturn) {
+	r0 := procSQLPrepareW.Call(uintptr(statementHandle), uintptr(unsafe.Pointer(statementText)), uintptr(textLength))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLExecute(statementHandle SQLHandle) (ret SQLReturn) {
+	r0 := procSQLExecute.Call(uintptr(statementHandle))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLNumParams(statementHandle SQLHandle, parameterCountPtr *SQLSMALLINT) (ret SQLReturn) {
+	r0 := procSQLNumParams.Call(uintptr(statementHandle), uintptr(unsafe.Pointer(parameterCountPtr)))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLGetConnectAttr(connectionHandle SQLHandle, attribute SQLINTEGER, valuePtr unsafe.Pointer, bufferLength SQLINTEGER, stringLengthPtr *SQLINTEGER) (ret SQLReturn) {
+	r0 := procSQLGetConnectAttrW.Call(uintptr(connectionHandle), uintptr(attribute), uintptr(valuePtr), uintptr(bufferLength), uintptr(unsafe.Pointer(stringLengthPtr)))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLRowCount(statementHandle SQLHandle, rowCountPtr *SQLLEN) (ret SQLReturn) {
+	r0 := procSQLRowCount.Call(uintptr(statementHandle), uintptr(unsafe.Pointer(rowCountPtr)))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLFreeStmt(statementHandle SQLHandle, option SQLUSMALLINT) (ret SQLReturn) {
+	r0 := procSQLFreeStmt.Call(uintptr(statementHandle), uintptr(option))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLTables(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT, tableTypeName *SQLCHAR, tableTypeNameLength SQLSMALLINT) (ret SQLReturn) {
+	r0 := procSQLTablesW.Call(uintptr(statementHandle), uintptr(unsafe.Pointer(catalogName)), uintptr(catalogNameLength), uintptr(unsafe.Pointer(schemaName)), uintptr(schemaNameLength), uintptr(unsafe.Pointer(tableName)), uintptr(tableNameLength), uintptr(unsafe.Pointer(tableTypeName)), uintptr(tableTypeNameLength))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLColumns(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT, columnName *SQLCHAR, columnNameLength SQLSMALLINT) (ret SQLReturn) {
+	r0 := procSQLColumnsW.Call(uintptr(statementHandle), uintptr(unsafe.Pointer(catalogName)), uintptr(catalogNameLength), uintptr(unsafe.Pointer(schemaName)), uintptr(schemaNameLength), uintptr(unsafe.Pointer(tableName)), uintptr(tableNameLength), uintptr(unsafe.Pointer(columnName)), uintptr(columnNameLength))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLPrimaryKeys(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT) (ret SQLReturn) {
+	r0 := procSQLPrimaryKeysW.Call(uintptr(statementHandle), uintptr(unsafe.Pointer(catalogName)), uintptr(catalogNameLength), uintptr(unsafe.Pointer(schemaName)), uintptr(schemaNameLength), uintptr(unsafe.Pointer(tableName)), uintptr(tableNameLength))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLForeignKeys(statementHandle SQLHandle, pkCatalogName *SQLCHAR, pkCatalogNameLength SQLSMALLINT, pkSchemaName *SQLCHAR, pkSchemaNameLength SQLSMALLINT, pkTableName *SQLCHAR, pkTableNameLength SQLSMALLINT, fkCatalogName *SQLCHAR, fkCatalogNameLength SQLSMALLINT, fkSchemaName *SQLCHAR, fkSchemaNameLength SQLSMALLINT, fkTableName *SQLCHAR, fkTableNameLength SQLSMALLINT) (ret SQLReturn) {
+	r0 := procSQLForeignKeysW.Call(uintptr(statementHandle), uintptr(unsafe.Pointer(pkCatalogName)), uintptr(pkCatalogNameLength), uintptr(unsafe.Pointer(pkSchemaName)), uintptr(pkSchemaNameLength), uintptr(unsafe.Pointer(pkTableName)), uintptr(pkTableNameLength), uintptr(unsafe.Pointer(fkCatalogName)), uintptr(fkCatalogNameLength), uintptr(unsafe.Pointer(fkSchemaName)), uintptr(fkSchemaNameLength), uintptr(unsafe.Pointer(fkTableName)), uintptr(fkTableNameLength))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLStatistics(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT, unique SQLUSMALLINT, reserved SQLUSMALLINT) (ret SQLReturn) {
+	r0 := procSQLStatisticsW.Call(uintptr(statementHandle), uintptr(unsafe.Pointer(catalogName)), uintptr(catalogNameLength), uintptr(unsafe.Pointer(schemaName)), uintptr(schemaNameLength), uintptr(unsafe.Pointer(tableName)), uintptr(tableNameLength), uintptr(unique), uintptr(reserved))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLProcedures(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, procName *SQLCHAR, procNameLength SQLSMALLINT) (ret SQLReturn) {
+	r0 := procSQLProceduresW.Call(uintptr(statementHandle), uintptr(unsafe.Pointer(catalogName)), uintptr(catalogNameLength), uintptr(unsafe.Pointer(schemaName)), uintptr(schemaNameLength), uintptr(unsafe.Pointer(procName)), uintptr(procNameLength))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLProcedureColumns(statementHandle SQLHandle, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, procName *SQLCHAR, procNameLength SQLSMALLINT, columnName *SQLCHAR, columnNameLength SQLSMALLINT) (ret SQLReturn) {
+	r0 := procSQLProcedureColumnsW.Call(uintptr(statementHandle), uintptr(unsafe.Pointer(catalogName)), uintptr(catalogNameLength), uintptr(unsafe.Pointer(schemaName)), uintptr(schemaNameLength), uintptr(unsafe.Pointer(procName)), uintptr(procNameLength), uintptr(unsafe.Pointer(columnName)), uintptr(columnNameLength))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLSpecialColumns(statementHandle SQLHandle, identifierType SQLUSMALLINT, catalogName *SQLCHAR, catalogNameLength SQLSMALLINT, schemaName *SQLCHAR, schemaNameLength SQLSMALLINT, tableName *SQLCHAR, tableNameLength SQLSMALLINT, scope SQLUSMALLINT, nullable SQLUSMALLINT) (ret SQLReturn) {
+	r0 := procSQLSpecialColumnsW.Call(uintptr(statementHandle), uintptr(identifierType), uintptr(unsafe.Pointer(catalogName)), uintptr(catalogNameLength), uintptr(unsafe.Pointer(schemaName)), uintptr(schemaNameLength), uintptr(unsafe.Pointer(tableName)), uintptr(tableNameLength), uintptr(scope), uintptr(nullable))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLParamData(statementHandle SQLHandle, valuePtrPtr *SQLPOINTER) (ret SQLReturn) {
+	r0 := procSQLParamData.Call(uintptr(statementHandle), uintptr(unsafe.Pointer(valuePtrPtr)))
+	ret = SQLReturn(r0)
+	return
+}
+
+func SQLPutData(statementHandle SQLHandle, dataPtr unsafe.Pointer, strLenOrInd SQLLEN) (ret SQLReturn) {
+	r0 := procSQLPutData.Call(uintptr(statementHandle), uintptr(dataPtr), uintptr(strLenOrInd))
+	ret = SQLReturn(r0)
+	return
+}
@@ -0,0 +1,1345 @@
+package fake
+
+import (
+	"github.com/LukeMauldin/lodbc/odbc"
+	"time"
+	"unsafe"
+)
+
+func (dm *DriverManager) SQLAllocHandle(handleType odbc.SQLSMALLINT, inputHandle odbc.SQLHandle, outputHandle *odbc.SQLHandle) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	dm.calls = append(dm.calls, Call{Function: "SQLAllocHandle", Handle: inputHandle})
+	parent := dm.lookup(inputHandle)
+	if parent != nil {
+		parent.diags = nil
+	}
+
+	switch handleType {
+	case odbc.SQL_HANDLE_ENV:
+		if inputHandle != 0 {
+			return odbc.SQL_INVALID_HANDLE
+		}
+		*outputHandle = dm.newHandle(odbc.SQL_HANDLE_ENV, nil).id
+		return odbc.SQL_SUCCESS
+	case odbc.SQL_HANDLE_DBC:
+		env := parent
+		if env == nil || env.kind != odbc.SQL_HANDLE_ENV {
+			return odbc.SQL_INVALID_HANDLE
+		}
+		if _, ok := env.attrs[odbc.SQL_ATTR_ODBC_VERSION]; !ok {
+			return env.fail("HY010", "Function sequence error: ODBC version not set")
+		}
+		*outputHandle = dm.newHandle(odbc.SQL_HANDLE_DBC, env).id
+		return odbc.SQL_SUCCESS
+	case odbc.SQL_HANDLE_STMT:
+		conn := parent
+		if conn == nil || conn.kind != odbc.SQL_HANDLE_DBC {
+			return odbc.SQL_INVALID_HANDLE
+		}
+		if !conn.connected {
+			return conn.fail("08003", "Connection not open")
+		}
+		h := dm.newHandle(odbc.SQL_HANDLE_STMT, conn)
+		h.stmt = &statement{params: make(map[odbc.SQLUSMALLINT]*parameter), rowIndex: -1}
+		h.stmt.apd = dm.newHandle(odbc.SQL_HANDLE_DESC, h)
+		h.stmt.apd.implicit = true
+		h.stmt.ard = dm.newHandle(odbc.SQL_HANDLE_DESC, h)
+		h.stmt.ard.implicit = true
+		*outputHandle = h.id
+		return odbc.SQL_SUCCESS
+	case odbc.SQL_HANDLE_DESC:
+		conn := parent
+		if conn == nil || conn.kind != odbc.SQL_HANDLE_DBC {
+			return odbc.SQL_INVALID_HANDLE
+		}
+		return conn.fail("HYC00", "Optional feature not implemented: explicit descriptors")
+	}
+	return odbc.SQL_ERROR
+}
+
+func (dm *DriverManager) SQLSetEnvAttr(environmentHandle odbc.SQLHandle, attribute odbc.SQLINTEGER, valuePtr odbc.SQLPOINTER, stringLength odbc.SQLINTEGER) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	// Connection pooling is a process level attribute set with a null handle
+	if environmentHandle == 0 {
+		dm.calls = append(dm.calls, Call{Function: "SQLSetEnvAttr"})
+		if attribute != odbc.SQL_ATTR_CONNECTION_POOLING {
+			return odbc.SQL_INVALID_HANDLE
+		}
+		dm.envAttrs[attribute] = valuePtr
+		return odbc.SQL_SUCCESS
+	}
+
+	env := dm.begin("SQLSetEnvAttr", odbc.SQL_HANDLE_ENV, environmentHandle)
+	if env == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	if attribute == odbc.SQL_ATTR_ODBC_VERSION {
+		for _, h := range dm.handles {
+			if h.parent == env {
+				return env.fail("HY010", "Function sequence error: connections already allocated")
+			}
+		}
+	}
+	env.attrs[attribute] = valuePtr
+	return odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLDriverConnect(connectionHandle odbc.SQLHandle, windowHandle int, inConnString *odbc.SQLCHAR, inConnStringLength odbc.SQLSMALLINT, outConnString *odbc.SQLCHAR, outConnStringLength odbc.SQLSMALLINT, outConnStringPtr *odbc.SQLSMALLINT, driverCompletion odbc.SQLUSMALLINT) odbc.SQLReturn {
+	dm.mu.Lock()
+	conn := dm.begin("SQLDriverConnect", odbc.SQL_HANDLE_DBC, connectionHandle)
+	if conn == nil {
+		dm.mu.Unlock()
+		return odbc.SQL_INVALID_HANDLE
+	}
+	if conn.connected {
+		ret := conn.fail("08002", "Connection name in use")
+		dm.mu.Unlock()
+		return ret
+	}
+	connString := readUTF16(unsafe.Pointer(inConnString), int(inConnStringLength))
+	connectHandler := dm.connectHandler
+	dm.mu.Unlock()
+
+	var diags []Diagnostic
+	if connectHandler != nil {
+		diags = connectHandler(connString)
+	}
+
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	if len(diags) > 0 {
+		conn.diags = diags
+		return odbc.SQL_ERROR
+	}
+	conn.connected = true
+	conn.connString = connString
+	if outConnString != nil {
+		length, truncated := writeUTF16(unsafe.Pointer(outConnString), int(outConnStringLength)*2, connString)
+		if outConnStringPtr != nil {
+			*outConnStringPtr = odbc.SQLSMALLINT(length)
+		}
+		if truncated {
+			return conn.warn("01004", "String data, right truncated")
+		}
+	}
+	return odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLFreeHandle(handleType odbc.SQLSMALLINT, h odbc.SQLHandle) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	freed := dm.begin("SQLFreeHandle", handleType, h)
+	if freed == nil || freed.implicit {
+		return odbc.SQL_INVALID_HANDLE
+	}
+
+	switch handleType {
+	case odbc.SQL_HANDLE_ENV:
+		for _, child := range dm.handles {
+			if child.parent == freed {
+				return freed.fail("HY010", "Function sequence error: connections still allocated")
+			}
+		}
+	case odbc.SQL_HANDLE_DBC:
+		if freed.connected {
+			return freed.fail("HY010", "Function sequence error: connection still open")
+		}
+	case odbc.SQL_HANDLE_STMT:
+		if freed.stmt.running != nil {
+			return freed.fail("HY010", "Function sequence error: statement still executing")
+		}
+		delete(dm.handles, freed.stmt.apd.id)
+		delete(dm.handles, freed.stmt.ard.id)
+	}
+	delete(dm.handles, freed.id)
+	return odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLDisconnect(h odbc.SQLHandle) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	conn := dm.begin("SQLDisconnect", odbc.SQL_HANDLE_DBC, h)
+	if conn == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	if !conn.connected {
+		return conn.fail("08003", "Connection not open")
+	}
+
+	// Disconnecting frees the statements of the connection
+	for id, child := range dm.handles {
+		if child.parent == conn && child.kind == odbc.SQL_HANDLE_STMT {
+			if child.stmt.running != nil {
+				return conn.fail("HY010", "Function sequence error: statement still executing")
+			}
+			delete(dm.handles, child.stmt.apd.id)
+			delete(dm.handles, child.stmt.ard.id)
+			delete(dm.handles, id)
+		}
+	}
+	conn.connected = false
+	return odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLCancel(statementHandle odbc.SQLHandle) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	stmt := dm.begin("SQLCancel", odbc.SQL_HANDLE_STMT, statementHandle)
+	if stmt == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+
+	// Cancelling while data at execution parameters are being sent ends the execution
+	stmt.stmt.dataAtExec = nil
+
+	// Cancelling a statement that is not executing has no effect
+	if running := stmt.stmt.running; running != nil {
+		select {
+		case <-running.cancel:
+		default:
+			close(running.cancel)
+		}
+	}
+	return odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLExecDirect(statementHandle odbc.SQLHandle, statementText *odbc.SQLCHAR, textLength odbc.SQLINTEGER) odbc.SQLReturn {
+	dm.mu.Lock()
+	stmt := dm.begin("SQLExecDirect", odbc.SQL_HANDLE_STMT, statementHandle)
+	if stmt == nil {
+		dm.mu.Unlock()
+		return odbc.SQL_INVALID_HANDLE
+	}
+	query := readUTF16(unsafe.Pointer(statementText), int(textLength))
+	stmt.stmt.prepared = false
+	dm.mu.Unlock()
+
+	return dm.execute(stmt, query, false, nil)
+}
+
+func (dm *DriverManager) SQLPrepare(statementHandle odbc.SQLHandle, statementText *odbc.SQLCHAR, textLength odbc.SQLINTEGER) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	stmt := dm.begin("SQLPrepare", odbc.SQL_HANDLE_STMT, statementHandle)
+	if stmt == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	if stmt.stmt.running != nil {
+		return stmt.fail("HY010", "Function sequence error: statement still executing")
+	}
+	if stmt.stmt.cursorOpen() {
+		return stmt.fail("24000", "Invalid cursor state")
+	}
+	stmt.stmt.closeCursor()
+	stmt.stmt.executed = false
+	stmt.stmt.prepared = true
+	stmt.stmt.query = readUTF16(unsafe.Pointer(statementText), int(textLength))
+	return odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLExecute(statementHandle odbc.SQLHandle) odbc.SQLReturn {
+	dm.mu.Lock()
+	stmt := dm.begin("SQLExecute", odbc.SQL_HANDLE_STMT, statementHandle)
+	if stmt == nil {
+		dm.mu.Unlock()
+		return odbc.SQL_INVALID_HANDLE
+	}
+	if !stmt.stmt.prepared {
+		ret := stmt.fail("HY010", "Function sequence error: statement not prepared")
+		dm.mu.Unlock()
+		return ret
+	}
+	query := stmt.stmt.query
+	dm.mu.Unlock()
+
+	return dm.execute(stmt, query, true, nil)
+}
+
+func (dm *DriverManager) SQLParamData(statementHandle odbc.SQLHandle, valuePtrPtr *odbc.SQLPOINTER) odbc.SQLReturn {
+	dm.mu.Lock()
+	stmt := dm.begin("SQLParamData", odbc.SQL_HANDLE_STMT, statementHandle)
+	if stmt == nil {
+		dm.mu.Unlock()
+		return odbc.SQL_INVALID_HANDLE
+	}
+	d := stmt.stmt.dataAtExec
+	if d == nil {
+		ret := stmt.fail("HY010", "Function sequence error: no data at execution parameters")
+		dm.mu.Unlock()
+		return ret
+	}
+
+	// Move past the parameter that was being sent, and return the token of the next one
+	if d.current != 0 {
+		d.pending = d.pending[1:]
+		d.current = 0
+	}
+	if len(d.pending) > 0 {
+		d.current = d.pending[0]
+		d.values[d.current] = []byte{}
+		*valuePtrPtr = odbc.SQLPOINTER(stmt.stmt.params[d.current].value)
+		dm.mu.Unlock()
+		return odbc.SQL_NEED_DATA
+	}
+	query, prepared := d.query, d.prepared
+	dm.mu.Unlock()
+
+	// Every parameter was sent: run the execution
+	return dm.execute(stmt, query, prepared, nil)
+}
+
+func (dm *DriverManager) SQLPutData(statementHandle odbc.SQLHandle, dataPtr unsafe.Pointer, strLenOrInd odbc.SQLLEN) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	stmt := dm.begin("SQLPutData", odbc.SQL_HANDLE_STMT, statementHandle)
+	if stmt == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	d := stmt.stmt.dataAtExec
+	if d == nil || d.current == 0 {
+		return stmt.fail("HY010", "Function sequence error: no parameter is being sent")
+	}
+	if strLenOrInd == odbc.SQL_NULL_DATA {
+		d.values[d.current] = nil
+		return odbc.SQL_SUCCESS
+	}
+	if strLenOrInd < 0 {
+		return stmt.fail("HY090", "Invalid string or buffer length: %v", strLenOrInd)
+	}
+	if strLenOrInd > 0 {
+		d.values[d.current] = append(d.values[d.current], unsafe.Slice((*byte)(dataPtr), int(strLenOrInd))...)
+	}
+	return odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLNumParams(statementHandle odbc.SQLHandle, parameterCountPtr *odbc.SQLSMALLINT) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	stmt := dm.begin("SQLNumParams", odbc.SQL_HANDLE_STMT, statementHandle)
+	if stmt == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	if !stmt.stmt.prepared {
+		return stmt.fail("HY010", "Function sequence error: statement not prepared")
+	}
+	*parameterCountPtr = odbc.SQLSMALLINT(countParameterMarkers(stmt.stmt.query))
+	return odbc.SQL_SUCCESS
+}
+
+// Executes query on stmt by calling the registered handler.  Catalog functions pass their
+// arguments in catalogArgs, which replace the bound parameters.
+func (dm *DriverManager) execute(stmt *handle, query string, prepared bool, catalogArgs []interface{}) odbc.SQLReturn {
+	dm.mu.Lock()
+	if stmt.stmt.running != nil {
+		ret := stmt.fail("HY010", "Function sequence error: statement still executing")
+		dm.mu.Unlock()
+		return ret
+	}
+	if stmt.stmt.cursorOpen() {
+		ret := stmt.fail("24000", "Invalid cursor state")
+		dm.mu.Unlock()
+		return ret
+	}
+	if stmt.parent.dead {
+		ret := stmt.fail("08S01", "Communication link failure")
+		dm.mu.Unlock()
+		return ret
+	}
+	stmt.stmt.closeCursor()
+	stmt.stmt.executed = false
+	stmt.stmt.outputParams = nil
+
+	// Ask for the data at execution parameters, unless SQLParamData is resuming the execution once they were sent
+	sent := stmt.stmt.dataAtExec
+	stmt.stmt.dataAtExec = nil
+	if sent == nil && catalogArgs == nil {
+		if pending := stmt.stmt.dataAtExecParams(); len(pending) > 0 {
+			stmt.stmt.dataAtExec = &dataAtExec{query: query, prepared: prepared, pending: pending, values: make(map[odbc.SQLUSMALLINT][]byte)}
+			dm.mu.Unlock()
+			return odbc.SQL_NEED_DATA
+		}
+	}
+
+	// Read the bound parameters, one set for each row of the parameter arrays
+	paramsetSize := 1
+	if size := int(stmt.attrs[odbc.SQL_ATTR_PARAMSET_SIZE]); size > 1 && catalogArgs == nil {
+		paramsetSize = size
+	}
+	cancel := make(chan struct{})
+	execs := make([]*Execution, paramsetSize)
+	for row := range execs {
+		params := make([]interface{}, 0, len(stmt.stmt.params))
+		var paramTypes []ParamType
+		if catalogArgs != nil {
+			params = catalogArgs
+		}
+		for number := odbc.SQLUSMALLINT(1); catalogArgs == nil && len(params) < len(stmt.stmt.params); number++ {
+			param, ok := stmt.stmt.params[number]
+			if !ok {
+				ret := stmt.fail("07002", "COUNT field incorrect: parameter %v not bound", number)
+				dm.mu.Unlock()
+				return ret
+			}
+			paramTypes = append(paramTypes, ParamType{InputOutputType: param.ioType, CType: param.cType, SQLType: param.sqlType, ColumnSize: int(param.size), DecimalDigits: int(param.digits)})
+			if param.ioType == odbc.SQL_PARAM_OUTPUT {
+				params = append(params, nil)
+				continue
+			}
+			if sent != nil {
+				if data, ok := sent.values[number]; ok {
+					params = append(params, param.decode(data))
+					continue
+				}
+			}
+			value, err := param.read(row)
+			if err != nil {
+				ret := stmt.fail("HY003", "Parameter %v: %v", number, err)
+				dm.mu.Unlock()
+				return ret
+			}
+			params = append(params, value)
+		}
+		execs[row] = &Execution{Query: query, Prepared: prepared, Params: params, ParamTypes: paramTypes, StmtAttrs: copyAttrs(stmt.attrs), ConnAttrs: copyAttrs(stmt.parent.attrs), cancel: cancel}
+		dm.executions = append(dm.executions, *execs[row])
+	}
+
+	handler, ok := dm.handlers[query]
+	if !ok {
+		handler = dm.defaultHandler
+	}
+	stmt.stmt.running = execs[0]
+	dm.mu.Unlock()
+
+	responses := make([]*Response, paramsetSize)
+	if handler != nil {
+		for row, exec := range execs {
+			responses[row] = handler(exec)
+		}
+	}
+
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	stmt.stmt.running = nil
+	select {
+	case <-cancel:
+		return stmt.fail("HY008", "Operation canceled")
+	default:
+	}
+	if handler == nil {
+		return stmt.fail("42000", "No response registered for query: %v", query)
+	}
+	if paramsetSize > 1 {
+		return stmt.paramsetResults(responses)
+	}
+
+	response := responses[0]
+	if response == nil {
+		response = &Response{}
+	}
+	if len(response.Errors) > 0 {
+		stmt.diags = append(stmt.diags, response.Errors...)
+		for _, diag := range response.Errors {
+			if isLinkFailure(diag.State) {
+				stmt.parent.dead = true
+			}
+		}
+		return odbc.SQL_ERROR
+	}
+
+	stmt.stmt.executed = true
+	stmt.stmt.resultSets = response.ResultSets
+	stmt.stmt.outputParams = response.OutputParams
+	if len(response.Warnings) > 0 {
+		stmt.diags = append(stmt.diags, response.Warnings...)
+		return odbc.SQL_SUCCESS_WITH_INFO
+	}
+	return odbc.SQL_SUCCESS
+}
+
+// Writes the values of the output parameters once every result set has been
+// consumed, as drivers only return them after SQLMoreResults returns SQL_NO_DATA
+func (h *handle) writeOutputParams() odbc.SQLReturn {
+	outputs := h.stmt.outputParams
+	h.stmt.outputParams = nil
+	for index, value := range outputs {
+		param, ok := h.stmt.params[odbc.SQLUSMALLINT(index+1)]
+		if !ok || param.ioType == odbc.SQL_PARAM_INPUT {
+			continue
+		}
+		_, ret := h.writeValue(value, param.cType, param.value, param.bufLen, param.ind, 0, int(param.size), int(param.digits))
+		if ret == odbc.SQL_ERROR {
+			return ret
+		}
+	}
+	return odbc.SQL_SUCCESS
+}
+
+// Combines the responses to each row of the parameter arrays, reporting the
+// status of each row through SQL_ATTR_PARAM_STATUS_PTR.  Every row is executed
+// even if some fail; the results of the successful rows are returned in order.
+func (h *handle) paramsetResults(responses []*Response) odbc.SQLReturn {
+	var status []odbc.SQLUSMALLINT
+	if statusPtr := h.attrs[odbc.SQL_ATTR_PARAM_STATUS_PTR]; statusPtr != 0 {
+		status = unsafe.Slice((*odbc.SQLUSMALLINT)(attrPointer(statusPtr)), len(responses))
+	}
+	if processed := h.attrs[odbc.SQL_ATTR_PARAMS_PROCESSED_PTR]; processed != 0 {
+		*(*odbc.SQLULEN)(attrPointer(processed)) = odbc.SQLULEN(len(responses))
+	}
+
+	failed := 0
+	withInfo := false
+	var resultSets []*ResultSet
+	for row, response := range responses {
+		rowStatus := odbc.SQL_PARAM_SUCCESS
+		if response == nil {
+			response = &Response{}
+		}
+		if len(response.Errors) > 0 {
+			h.diags = append(h.diags, response.Errors...)
+			rowStatus = odbc.SQL_PARAM_ERROR
+			failed++
+		} else {
+			resultSets = append(resultSets, response.ResultSets...)
+			if len(response.Warnings) > 0 {
+				h.diags = append(h.diags, response.Warnings...)
+				rowStatus = odbc.SQL_PARAM_SUCCESS_WITH_INFO
+				withInfo = true
+			}
+		}
+		if status != nil {
+			status[row] = rowStatus
+		}
+	}
+
+	if failed == len(responses) {
+		return odbc.SQL_ERROR
+	}
+	h.stmt.executed = true
+	h.stmt.resultSets = resultSets
+	if failed > 0 || withInfo {
+		return odbc.SQL_SUCCESS_WITH_INFO
+	}
+	return odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLTables(statementHandle odbc.SQLHandle, catalogName *odbc.SQLCHAR, catalogNameLength odbc.SQLSMALLINT, schemaName *odbc.SQLCHAR, schemaNameLength odbc.SQLSMALLINT, tableName *odbc.SQLCHAR, tableNameLength odbc.SQLSMALLINT, tableTypeName *odbc.SQLCHAR, tableTypeNameLength odbc.SQLSMALLINT) odbc.SQLReturn {
+	return dm.catalog("SQLTables", statementHandle,
+		catalogArg(catalogName, catalogNameLength), catalogArg(schemaName, schemaNameLength),
+		catalogArg(tableName, tableNameLength), catalogArg(tableTypeName, tableTypeNameLength))
+}
+
+func (dm *DriverManager) SQLColumns(statementHandle odbc.SQLHandle, catalogName *odbc.SQLCHAR, catalogNameLength odbc.SQLSMALLINT, schemaName *odbc.SQLCHAR, schemaNameLength odbc.SQLSMALLINT, tableName *odbc.SQLCHAR, tableNameLength odbc.SQLSMALLINT, columnName *odbc.SQLCHAR, columnNameLength odbc.SQLSMALLINT) odbc.SQLReturn {
+	return dm.catalog("SQLColumns", statementHandle,
+		catalogArg(catalogName, catalogNameLength), catalogArg(schemaName, schemaNameLength),
+		catalogArg(tableName, tableNameLength), catalogArg(columnName, columnNameLength))
+}
+
+func (dm *DriverManager) SQLPrimaryKeys(statementHandle odbc.SQLHandle, catalogName *odbc.SQLCHAR, catalogNameLength odbc.SQLSMALLINT, schemaName *odbc.SQLCHAR, schemaNameLength odbc.SQLSMALLINT, tableName *odbc.SQLCHAR, tableNameLength odbc.SQLSMALLINT) odbc.SQLReturn {
+	return dm.catalog("SQLPrimaryKeys", statementHandle,
+		catalogArg(catalogName, catalogNameLength), catalogArg(schemaName, schemaNameLength),
+		catalogArg(tableName, tableNameLength))
+}
+
+func (dm *DriverManager) SQLForeignKeys(statementHandle odbc.SQLHandle, pkCatalogName *odbc.SQLCHAR, pkCatalogNameLength odbc.SQLSMALLINT, pkSchemaName *odbc.SQLCHAR, pkSchemaNameLength odbc.SQLSMALLINT, pkTableName *odbc.SQLCHAR, pkTableNameLength odbc.SQLSMALLINT, fkCatalogName *odbc.SQLCHAR, fkCatalogNameLength odbc.SQLSMALLINT, fkSchemaName *odbc.SQLCHAR, fkSchemaNameLength odbc.SQLSMALLINT, fkTableName *odbc.SQLCHAR, fkTableNameLength odbc.SQLSMALLINT) odbc.SQLReturn {
+	return dm.catalog("SQLForeignKeys", statementHandle,
+		catalogArg(pkCatalogName, pkCatalogNameLength), catalogArg(pkSchemaName, pkSchemaNameLength),
+		catalogArg(pkTableName, pkTableNameLength), catalogArg(fkCatalogName, fkCatalogNameLength),
+		catalogArg(fkSchemaName, fkSchemaNameLength), catalogArg(fkTableName, fkTableNameLength))
+}
+
+func (dm *DriverManager) SQLStatistics(statementHandle odbc.SQLHandle, catalogName *odbc.SQLCHAR, catalogNameLength odbc.SQLSMALLINT, schemaName *odbc.SQLCHAR, schemaNameLength odbc.SQLSMALLINT, tableName *odbc.SQLCHAR, tableNameLength odbc.SQLSMALLINT, unique odbc.SQLUSMALLINT, reserved odbc.SQLUSMALLINT) odbc.SQLReturn {
+	return dm.catalog("SQLStatistics", statementHandle,
+		catalogArg(catalogName, catalogNameLength), catalogArg(schemaName, schemaNameLength),
+		catalogArg(tableName, tableNameLength), int64(unique), int64(reserved))
+}
+
+func (dm *DriverManager) SQLProcedures(statementHandle odbc.SQLHandle, catalogName *odbc.SQLCHAR, catalogNameLength odbc.SQLSMALLINT, schemaName *odbc.SQLCHAR, schemaNameLength odbc.SQLSMALLINT, procName *odbc.SQLCHAR, procNameLength odbc.SQLSMALLINT) odbc.SQLReturn {
+	return dm.catalog("SQLProcedures", statementHandle,
+		catalogArg(catalogName, catalogNameLength), catalogArg(schemaName, schemaNameLength),
+		catalogArg(procName, procNameLength))
+}
+
+func (dm *DriverManager) SQLProcedureColumns(statementHandle odbc.SQLHandle, catalogName *odbc.SQLCHAR, catalogNameLength odbc.SQLSMALLINT, schemaName *odbc.SQLCHAR, schemaNameLength odbc.SQLSMALLINT, procName *odbc.SQLCHAR, procNameLength odbc.SQLSMALLINT, columnName *odbc.SQLCHAR, columnNameLength odbc.SQLSMALLINT) odbc.SQLReturn {
+	return dm.catalog("SQLProcedureColumns", statementHandle,
+		catalogArg(catalogName, catalogNameLength), catalogArg(schemaName, schemaNameLength),
+		catalogArg(procName, procNameLength), catalogArg(columnName, columnNameLength))
+}
+
+func (dm *DriverManager) SQLSpecialColumns(statementHandle odbc.SQLHandle, identifierType odbc.SQLUSMALLINT, catalogName *odbc.SQLCHAR, catalogNameLength odbc.SQLSMALLINT, schemaName *odbc.SQLCHAR, schemaNameLength odbc.SQLSMALLINT, tableName *odbc.SQLCHAR, tableNameLength odbc.SQLSMALLINT, scope odbc.SQLUSMALLINT, nullable odbc.SQLUSMALLINT) odbc.SQLReturn {
+	return dm.catalog("SQLSpecialColumns", statementHandle, int64(identifierType),
+		catalogArg(catalogName, catalogNameLength), catalogArg(schemaName, schemaNameLength),
+		catalogArg(tableName, tableNameLength), int64(scope), int64(nullable))
+}
+
+// Runs a catalog function by calling the handler registered for its name, such as "SQLTables",
+// with the function's arguments as the Execution parameters
+func (dm *DriverManager) catalog(function string, statementHandle odbc.SQLHandle, args ...interface{}) odbc.SQLReturn {
+	dm.mu.Lock()
+	stmt := dm.begin(function, odbc.SQL_HANDLE_STMT, statementHandle)
+	if stmt == nil {
+		dm.mu.Unlock()
+		return odbc.SQL_INVALID_HANDLE
+	}
+	stmt.stmt.prepared = false
+	dm.mu.Unlock()
+
+	return dm.execute(stmt, function, false, args)
+}
+
+// Returns a catalog function string argument, or nil for a null pointer
+func catalogArg(name *odbc.SQLCHAR, length odbc.SQLSMALLINT) interface{} {
+	if name == nil {
+		return nil
+	}
+	return readUTF16(unsafe.Pointer(name), int(length))
+}
+
+func (dm *DriverManager) SQLCloseCursor(statementHandle odbc.SQLHandle) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	stmt := dm.begin("SQLCloseCursor", odbc.SQL_HANDLE_STMT, statementHandle)
+	if stmt == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	if !stmt.stmt.cursorOpen() {
+		return stmt.fail("24000", "Invalid cursor state")
+	}
+	stmt.stmt.closeCursor()
+	stmt.stmt.outputParams = nil
+	return odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLFetch(statementHandle odbc.SQLHandle) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	stmt := dm.begin("SQLFetch", odbc.SQL_HANDLE_STMT, statementHandle)
+	if stmt == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	return stmt.fetchNext()
+}
+
+func (dm *DriverManager) SQLFetchScroll(statementHandle odbc.SQLHandle, fetchOrientation odbc.SQLSMALLINT, fetchOffset odbc.SQLLEN) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	stmt := dm.begin("SQLFetchScroll", odbc.SQL_HANDLE_STMT, statementHandle)
+	if stmt == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	if fetchOrientation != odbc.SQL_FETCH_NEXT {
+		return stmt.fail("HY106", "Fetch type out of range")
+	}
+	return stmt.fetchNext()
+}
+
+// Moves the cursor to the next rowset, writing the rows to the bound columns
+func (h *handle) fetchNext() odbc.SQLReturn {
+	rs := h.stmt.resultSet()
+	if !h.stmt.executed {
+		return h.fail("HY010", "Function sequence error: statement not executed")
+	}
+	if rs == nil || len(rs.Columns) == 0 {
+		return h.fail("24000", "Invalid cursor state")
+	}
+	h.stmt.getDataOffsets = make(map[odbc.SQLUSMALLINT]int)
+
+	// The rowset starts after the rows of the previous rowset
+	start := 0
+	if h.stmt.rowIndex >= 0 {
+		start = h.stmt.rowIndex + h.stmt.rowsetRows
+	}
+	size := h.rowArraySize()
+	count := len(rs.Rows) - start
+	if count > size {
+		count = size
+	}
+	if count < 0 {
+		count = 0
+	}
+	if rowsFetched := h.attrs[odbc.SQL_ATTR_ROWS_FETCHED_PTR]; rowsFetched != 0 {
+		*(*odbc.SQLULEN)(attrPointer(rowsFetched)) = odbc.SQLULEN(count)
+	}
+	var rowStatus []odbc.SQLUSMALLINT
+	if statusPtr := h.attrs[odbc.SQL_ATTR_ROW_STATUS_PTR]; statusPtr != 0 {
+		rowStatus = unsafe.Slice((*odbc.SQLUSMALLINT)(attrPointer(statusPtr)), size)
+		for i := range rowStatus {
+			rowStatus[i] = odbc.SQL_ROW_NOROW
+		}
+	}
+	if count == 0 {
+		h.stmt.rowIndex = len(rs.Rows)
+		h.stmt.rowsetRows = 0
+		return odbc.SQL_NO_DATA
+	}
+	h.stmt.rowIndex = start
+	h.stmt.rowsetRows = count
+
+	// Write the rowset to the bound columns, bound column-wise
+	ret := odbc.SQL_SUCCESS
+	for i := 0; i < count; i++ {
+		rowRet := odbc.SQL_SUCCESS
+		for colNum, b := range h.stmt.bindings {
+			if int(colNum) > len(rs.Columns) {
+				return h.fail("07009", "Invalid descriptor index: %v", colNum)
+			}
+			precision, scale := rs.Columns[colNum-1].Precision, rs.Columns[colNum-1].Scale
+			if record := h.stmt.ard.records[odbc.SQLSMALLINT(colNum)]; record != nil {
+				if p, ok := record[odbc.SQL_DESC_PRECISION]; ok {
+					precision = int(p)
+				}
+				if s, ok := record[odbc.SQL_DESC_SCALE]; ok {
+					scale = int(s)
+				}
+			}
+			elementSize := b.elementSize()
+			var ind *odbc.SQLLEN
+			if b.ind != nil {
+				ind = (*odbc.SQLLEN)(unsafe.Add(unsafe.Pointer(b.ind), i*int(unsafe.Sizeof(odbc.SQLLEN(0)))))
+			}
+			_, valueRet := h.writeValue(rs.Rows[start+i][colNum-1], b.cType, unsafe.Add(b.value, i*elementSize), b.bufLen, ind, 0, precision, scale)
+			if valueRet == odbc.SQL_ERROR {
+				if size == 1 {
+					return odbc.SQL_ERROR
+				}
+				rowRet = odbc.SQL_ERROR
+			} else if valueRet == odbc.SQL_SUCCESS_WITH_INFO && rowRet == odbc.SQL_SUCCESS {
+				rowRet = odbc.SQL_SUCCESS_WITH_INFO
+			}
+		}
+		if rowRet != odbc.SQL_SUCCESS {
+			ret = odbc.SQL_SUCCESS_WITH_INFO
+		}
+		if rowStatus != nil {
+			switch rowRet {
+			case odbc.SQL_SUCCESS:
+				rowStatus[i] = odbc.SQL_ROW_SUCCESS
+			case odbc.SQL_SUCCESS_WITH_INFO:
+				rowStatus[i] = odbc.SQL_ROW_SUCCESS_WITH_INFO
+			default:
+				rowStatus[i] = odbc.SQL_ROW_ERROR
+			}
+		}
+	}
+	return ret
+}
+
+// Number of rows in a rowset, set with SQL_ATTR_ROW_ARRAY_SIZE
+func (h *handle) rowArraySize() int {
+	if size := int(h.attrs[odbc.SQL_ATTR_ROW_ARRAY_SIZE]); size > 1 {
+		return size
+	}
+	return 1
+}
+
+func (dm *DriverManager) SQLSetStmtAttr(statementHandle odbc.SQLHandle, attribute odbc.SQLINTEGER, valuePtr odbc.SQLPOINTER, stringLength odbc.SQLINTEGER) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	stmt := dm.begin("SQLSetStmtAttr", odbc.SQL_HANDLE_STMT, statementHandle)
+	if stmt == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	if attribute == odbc.SQL_ATTR_ROW_BIND_TYPE && valuePtr != odbc.SQL_BIND_BY_COLUMN {
+		return stmt.fail("HYC00", "Optional feature not implemented: row-wise binding")
+	}
+	if attribute == odbc.SQL_ATTR_PARAM_BIND_TYPE && valuePtr != odbc.SQL_PARAM_BIND_BY_COLUMN {
+		return stmt.fail("HYC00", "Optional feature not implemented: row-wise parameter binding")
+	}
+	stmt.attrs[attribute] = valuePtr
+	return odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLBindCol(statementHandle odbc.SQLHandle, columnNumber odbc.SQLUSMALLINT, targetType odbc.SQLSMALLINT, targetValuePtr unsafe.Pointer, bufferLength odbc.SQLLEN, ind *odbc.SQLLEN) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	stmt := dm.begin("SQLBindCol", odbc.SQL_HANDLE_STMT, statementHandle)
+	if stmt == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	if columnNumber < 1 {
+		return stmt.fail("07009", "Invalid descriptor index: bookmark columns are not supported")
+	}
+
+	// A null buffer unbinds the column
+	if targetValuePtr == nil {
+		delete(stmt.stmt.bindings, columnNumber)
+		return odbc.SQL_SUCCESS
+	}
+	b := &binding{cType: odbc.CDataType(targetType), value: targetValuePtr, bufLen: bufferLength, ind: ind}
+	if b.elementSize() <= 0 {
+		return stmt.fail("HY090", "Invalid string or buffer length")
+	}
+	if stmt.stmt.bindings == nil {
+		stmt.stmt.bindings = make(map[odbc.SQLUSMALLINT]*binding)
+	}
+	stmt.stmt.bindings[columnNumber] = b
+	return odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLFreeStmt(statementHandle odbc.SQLHandle, option odbc.SQLUSMALLINT) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	stmt := dm.begin("SQLFreeStmt", odbc.SQL_HANDLE_STMT, statementHandle)
+	if stmt == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	switch option {
+	case odbc.SQL_CLOSE:
+		// Unlike SQLCloseCursor, closing without an open cursor is not an error
+		stmt.stmt.closeCursor()
+		stmt.stmt.outputParams = nil
+	case odbc.SQL_UNBIND:
+		stmt.stmt.bindings = nil
+	case odbc.SQL_RESET_PARAMS:
+		stmt.stmt.params = make(map[odbc.SQLUSMALLINT]*parameter)
+	case odbc.SQL_DROP:
+		return stmt.fail("HY092", "Invalid attribute/option identifier: use SQLFreeHandle")
+	default:
+		return stmt.fail("HY092", "Invalid attribute/option identifier: %v", option)
+	}
+	return odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLSetConnectAttr(connectionHandle odbc.SQLHandle, attribute odbc.SQLINTEGER, valuePtr odbc.SQLPOINTER, bufferLength odbc.SQLINTEGER, stringLengthPtr *odbc.SQLINTEGER) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	conn := dm.begin("SQLSetConnectAttr", odbc.SQL_HANDLE_DBC, connectionHandle)
+	if conn == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	if attribute == odbc.SQL_ATTR_PACKET_SIZE && conn.connected {
+		return conn.fail("HY011", "Attribute cannot be set now")
+	}
+	conn.attrs[attribute] = valuePtr
+	return odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLGetConnectAttr(connectionHandle odbc.SQLHandle, attribute odbc.SQLINTEGER, valuePtr unsafe.Pointer, bufferLength odbc.SQLINTEGER, stringLengthPtr *odbc.SQLINTEGER) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	conn := dm.begin("SQLGetConnectAttr", odbc.SQL_HANDLE_DBC, connectionHandle)
+	if conn == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	value, ok := conn.attrs[attribute]
+	if !ok {
+		value = defaultConnAttrs[attribute]
+	}
+	if attribute == odbc.SQL_ATTR_CONNECTION_DEAD {
+		value = odbc.SQLPOINTER(odbc.SQL_CD_FALSE)
+		if conn.dead {
+			value = odbc.SQLPOINTER(odbc.SQL_CD_TRUE)
+		}
+	}
+	// Integer attributes are returned as SQLUINTEGER
+	*(*odbc.SQLUINTEGER)(valuePtr) = odbc.SQLUINTEGER(value)
+	return odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLEndTran(handleType odbc.SQLSMALLINT, h odbc.SQLHandle, completionType odbc.SQLSMALLINT) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	conn := dm.begin("SQLEndTran", handleType, h)
+	if conn == nil || handleType != odbc.SQL_HANDLE_DBC {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	if !conn.connected {
+		return conn.fail("08003", "Connection not open")
+	}
+	if conn.dead {
+		return conn.fail("08S01", "Communication link failure")
+	}
+	if completionType != odbc.SQL_COMMIT && completionType != odbc.SQL_ROLLBACK {
+		return conn.fail("HY012", "Invalid transaction operation code")
+	}
+	return odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLBindParameter(statementHandle odbc.SQLHandle, parameterNumber odbc.SQLUSMALLINT, inputOutputType odbc.SQLSMALLINT, valueType odbc.CDataType, parameterType odbc.SQLDataType, columnSize odbc.SQLULEN, decimalDigits odbc.SQLSMALLINT, parameterValue unsafe.Pointer, bufferLength odbc.SQLLEN, ind *odbc.SQLLEN) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	stmt := dm.begin("SQLBindParameter", odbc.SQL_HANDLE_STMT, statementHandle)
+	if stmt == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	if parameterNumber < 1 {
+		return stmt.fail("07009", "Invalid descriptor index")
+	}
+	switch inputOutputType {
+	case odbc.SQL_PARAM_INPUT, odbc.SQL_PARAM_INPUT_OUTPUT, odbc.SQL_PARAM_OUTPUT:
+	default:
+		return stmt.fail("HY105", "Invalid parameter type: %v", inputOutputType)
+	}
+	stmt.stmt.params[parameterNumber] = &parameter{ioType: inputOutputType, cType: valueType, sqlType: parameterType, size: columnSize, digits: decimalDigits, value: parameterValue, bufLen: bufferLength, ind: ind}
+	return odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLMoreResults(statementHandle odbc.SQLHandle) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	stmt := dm.begin("SQLMoreResults", odbc.SQL_HANDLE_STMT, statementHandle)
+	if stmt == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	if stmt.stmt.setIndex+1 >= len(stmt.stmt.resultSets) {
+		stmt.stmt.closeCursor()
+		if ret := stmt.writeOutputParams(); ret == odbc.SQL_ERROR {
+			return ret
+		}
+		return odbc.SQL_NO_DATA
+	}
+	stmt.stmt.setIndex++
+	stmt.stmt.rowIndex = -1
+	stmt.stmt.rowsetRows = 0
+	stmt.stmt.getDataOffsets = nil
+	if warnings := stmt.stmt.resultSets[stmt.stmt.setIndex].Warnings; len(warnings) > 0 {
+		stmt.diags = append(stmt.diags, warnings...)
+		return odbc.SQL_SUCCESS_WITH_INFO
+	}
+	return odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLRowCount(statementHandle odbc.SQLHandle, rowCountPtr *odbc.SQLLEN) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	stmt := dm.begin("SQLRowCount", odbc.SQL_HANDLE_STMT, statementHandle)
+	if stmt == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	if !stmt.stmt.executed {
+		return stmt.fail("HY010", "Function sequence error: statement not executed")
+	}
+
+	// The count is only available for a result that does not return rows
+	*rowCountPtr = -1
+	if rs := stmt.stmt.resultSet(); rs != nil && len(rs.Columns) == 0 {
+		*rowCountPtr = odbc.SQLLEN(rs.RowsAffected)
+	}
+	return odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLGetDescField(descriptorHandle odbc.SQLHandle, recNumber odbc.SQLSMALLINT, fieldIdentifier odbc.SQLSMALLINT, valuePtr unsafe.Pointer, bufferLength odbc.SQLINTEGER, lengthPtr *odbc.SQLINTEGER) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	desc := dm.begin("SQLGetDescField", odbc.SQL_HANDLE_DESC, descriptorHandle)
+	if desc == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	return desc.fail("HYC00", "Optional feature not implemented: SQLGetDescField")
+}
+
+func (dm *DriverManager) SQLGetDescRec(descriptorHandle odbc.SQLHandle, recNumber odbc.SQLSMALLINT, name *odbc.SQLCHAR, bufferLength odbc.SQLSMALLINT, stringLengthPtr *odbc.SQLSMALLINT, typePtr *odbc.SQLSMALLINT, subTypePtr *odbc.SQLSMALLINT, lengthPtr *odbc.SQLLEN, precisionPtr *odbc.SQLSMALLINT, scalePtr *odbc.SQLSMALLINT, nullablePtr *odbc.SQLSMALLINT) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	desc := dm.begin("SQLGetDescRec", odbc.SQL_HANDLE_DESC, descriptorHandle)
+	if desc == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	return desc.fail("HYC00", "Optional feature not implemented: SQLGetDescRec")
+}
+
+func (dm *DriverManager) SQLGetDiagRec(handleType odbc.SQLSMALLINT, inputHandle odbc.SQLHandle, recNumber odbc.SQLSMALLINT, sqlState unsafe.Pointer, nativeErrorPtr *odbc.SQLINTEGER, messageText unsafe.Pointer, bufferLength odbc.SQLSMALLINT, textLengthPtr *odbc.SQLSMALLINT) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	// Reading diagnostics does not clear them
+	dm.calls = append(dm.calls, Call{Function: "SQLGetDiagRec", Handle: inputHandle})
+	h := dm.lookup(inputHandle)
+	if h == nil || h.kind != handleType {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	if recNumber < 1 || bufferLength < 0 {
+		return odbc.SQL_ERROR
+	}
+	if int(recNumber) > len(h.diags) {
+		return odbc.SQL_NO_DATA
+	}
+
+	diag := h.diags[recNumber-1]
+	writeUTF16(sqlState, 12, diag.State)
+	if nativeErrorPtr != nil {
+		*nativeErrorPtr = odbc.SQLINTEGER(diag.NativeError)
+	}
+	length, truncated := writeUTF16(messageText, int(bufferLength)*2, diag.Message)
+	if textLengthPtr != nil {
+		*textLengthPtr = odbc.SQLSMALLINT(length)
+	}
+	if truncated {
+		return odbc.SQL_SUCCESS_WITH_INFO
+	}
+	return odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLColAttribute(statementHandle odbc.SQLHandle, columnNumber odbc.SQLUSMALLINT, fieldIdentifier odbc.SQLColAttributeType, characterAttribute unsafe.Pointer, bufferLength odbc.SQLSMALLINT, stringLengthPtr *odbc.SQLSMALLINT, numericAttributePtr *odbc.SQLLEN) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	stmt := dm.begin("SQLColAttribute", odbc.SQL_HANDLE_STMT, statementHandle)
+	if stmt == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	column, ret := stmt.column(columnNumber)
+	if ret != odbc.SQL_SUCCESS {
+		return ret
+	}
+
+	numeric := func(value int64) odbc.SQLReturn {
+		if numericAttributePtr != nil {
+			*numericAttributePtr = odbc.SQLLEN(value)
+		}
+		return odbc.SQL_SUCCESS
+	}
+	switch fieldIdentifier {
+	case odbc.SQL_COLUMN_TYPE, odbc.SQLColAttributeType(odbc.SQL_DESC_TYPE):
+		return numeric(int64(column.Type))
+	case odbc.SQL_COLUMN_LENGTH, odbc.SQL_COLUMN_PRECISION, odbc.SQLColAttributeType(odbc.SQL_DESC_LENGTH), odbc.SQLColAttributeType(odbc.SQL_DESC_PRECISION):
+		return numeric(int64(column.Precision))
+	case odbc.SQL_COLUMN_SCALE, odbc.SQLColAttributeType(odbc.SQL_DESC_SCALE):
+		return numeric(int64(column.Scale))
+	case odbc.SQL_COLUMN_NULLABLE, odbc.SQLColAttributeType(odbc.SQL_DESC_NULLABLE):
+		if column.Nullable {
+			return numeric(1)
+		}
+		return numeric(0)
+	case odbc.SQLColAttributeType(odbc.SQL_DESC_OCTET_LENGTH):
+		return numeric(int64(octetLength(column)))
+	}
+
+	var text string
+	switch fieldIdentifier {
+	case odbc.SQL_DESC_LABEL, odbc.SQLColAttributeType(odbc.SQL_DESC_NAME):
+		text = column.Name
+	case odbc.SQL_DESC_TYPE_NAME:
+		text = column.TypeName
+		if len(text) == 0 {
+			text = typeNames[column.Type]
+		}
+	case odbc.SQL_DESC_BASE_TABLE_NAME:
+		text = column.BaseTableName
+	case odbc.SQL_DESC_BASE_COLUMN_NAME:
+		text = column.BaseColumnName
+	default:
+		return stmt.fail("HY091", "Invalid descriptor field identifier: %v", fieldIdentifier)
+	}
+	length, truncated := writeUTF16(characterAttribute, int(bufferLength), text)
+	if stringLengthPtr != nil {
+		*stringLengthPtr = odbc.SQLSMALLINT(length * 2)
+	}
+	if truncated {
+		return stmt.warn("01004", "String data, right truncated")
+	}
+	return odbc.SQL_SUCCESS
+}
+
+// Names reported by SQL_DESC_TYPE_NAME for columns without a TypeName
+var typeNames = map[odbc.SQLDataType]string{
+	odbc.SQL_CHAR:           "char",
+	odbc.SQL_VARCHAR:        "varchar",
+	odbc.SQL_LONGVARCHAR:    "text",
+	odbc.SQL_WCHAR:          "nchar",
+	odbc.SQL_WVARCHAR:       "nvarchar",
+	odbc.SQL_WLONGVARCHAR:   "ntext",
+	odbc.SQL_SS_XML:         "xml",
+	odbc.SQL_BINARY:         "binary",
+	odbc.SQL_VARBINARY:      "varbinary",
+	odbc.SQL_LONGVARBINARY:  "image",
+	odbc.SQL_BIT:            "bit",
+	odbc.SQL_TINYINT:        "tinyint",
+	odbc.SQL_SMALLINT:       "smallint",
+	odbc.SQL_INTEGER:        "int",
+	odbc.SQL_BIGINT:         "bigint",
+	odbc.SQL_REAL:           "real",
+	odbc.SQL_FLOAT:          "float",
+	odbc.SQL_DOUBLE:         "float",
+	odbc.SQL_NUMERIC:        "numeric",
+	odbc.SQL_DECIMAL:        "decimal",
+	odbc.SQL_TYPE_DATE:      "date",
+	odbc.SQL_TYPE_TIME:      "time",
+	odbc.SQL_TYPE_TIMESTAMP: "datetime",
+	odbc.SQL_GUID:           "uniqueidentifier",
+
+	odbc.SQL_SS_TIME2:           "time",
+	odbc.SQL_SS_TIMESTAMPOFFSET: "datetimeoffset",
+}
+
+// Bytes needed to transfer a value of the column in its default C type
+func octetLength(column Column) int {
+	switch column.Type {
+	case odbc.SQL_WCHAR, odbc.SQL_WVARCHAR, odbc.SQL_WLONGVARCHAR:
+		return column.Precision * 2
+	case odbc.SQL_CHAR, odbc.SQL_VARCHAR, odbc.SQL_LONGVARCHAR, odbc.SQL_BINARY, odbc.SQL_VARBINARY, odbc.SQL_LONGVARBINARY:
+		return column.Precision
+	case odbc.SQL_NUMERIC, odbc.SQL_DECIMAL:
+		return column.Precision + 2
+	case odbc.SQL_BIT, odbc.SQL_TINYINT:
+		return 1
+	case odbc.SQL_SMALLINT:
+		return 2
+	case odbc.SQL_INTEGER, odbc.SQL_REAL:
+		return 4
+	case odbc.SQL_BIGINT, odbc.SQL_FLOAT, odbc.SQL_DOUBLE:
+		return 8
+	case odbc.SQL_TYPE_DATE, odbc.SQL_TYPE_TIME:
+		return 6
+	case odbc.SQL_TYPE_TIMESTAMP, odbc.SQL_GUID:
+		return 16
+	case odbc.SQL_SS_TIME2:
+		return 12
+	case odbc.SQL_SS_TIMESTAMPOFFSET:
+		return 20
+	}
+	return 0
+}
+
+// Returns the definition of a column in the current result set
+func (h *handle) column(columnNumber odbc.SQLUSMALLINT) (Column, odbc.SQLReturn) {
+	if !h.stmt.executed {
+		return Column{}, h.fail("HY010", "Function sequence error: statement not executed")
+	}
+	rs := h.stmt.resultSet()
+	if rs == nil || len(rs.Columns) == 0 {
+		return Column{}, h.fail("07005", "Prepared statement not a cursor-specification")
+	}
+	if columnNumber < 1 || int(columnNumber) > len(rs.Columns) {
+		return Column{}, h.fail("07009", "Invalid descriptor index: %v", columnNumber)
+	}
+	return rs.Columns[columnNumber-1], odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLNumResultCols(statementHandle odbc.SQLHandle, columnCount *odbc.SQLSMALLINT) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	stmt := dm.begin("SQLNumResultCols", odbc.SQL_HANDLE_STMT, statementHandle)
+	if stmt == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	if !stmt.stmt.executed {
+		return stmt.fail("HY010", "Function sequence error: statement not executed")
+	}
+	*columnCount = 0
+	if rs := stmt.stmt.resultSet(); rs != nil {
+		*columnCount = odbc.SQLSMALLINT(len(rs.Columns))
+	}
+	return odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLGetData(statementHandle odbc.SQLHandle, colNum odbc.SQLUSMALLINT, targetType odbc.CDataType, targetValuePtr unsafe.Pointer, bufferLength odbc.SQLLEN, ind *odbc.SQLLEN) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	stmt := dm.begin("SQLGetData", odbc.SQL_HANDLE_STMT, statementHandle)
+	if stmt == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	column, ret := stmt.column(colNum)
+	if ret != odbc.SQL_SUCCESS {
+		return ret
+	}
+	rs := stmt.stmt.resultSet()
+	if stmt.stmt.rowIndex < 0 || stmt.stmt.rowIndex >= len(rs.Rows) {
+		return stmt.fail("24000", "Invalid cursor state: no current row")
+	}
+	if stmt.rowArraySize() > 1 {
+		return stmt.fail("HYC00", "Optional feature not implemented: SQLGetData with a block cursor")
+	}
+	if _, bound := stmt.stmt.bindings[colNum]; bound {
+		return stmt.fail("07009", "Invalid descriptor index: column %v is bound", colNum)
+	}
+	value := rs.Rows[stmt.stmt.rowIndex][colNum-1]
+
+	// Resolve the C type from the application row descriptor
+	precision, scale := column.Precision, column.Scale
+	if targetType == odbc.SQL_ARD_TYPE {
+		record := stmt.stmt.ard.records[odbc.SQLSMALLINT(colNum)]
+		if record == nil {
+			return stmt.fail("07009", "Invalid descriptor index: ARD record %v not set", colNum)
+		}
+		targetType = odbc.CDataType(record[odbc.SQL_DESC_TYPE])
+		if p, ok := record[odbc.SQL_DESC_PRECISION]; ok {
+			precision = int(p)
+		}
+		if s, ok := record[odbc.SQL_DESC_SCALE]; ok {
+			scale = int(s)
+		}
+	}
+
+	// Values are returned once; variable length data may be returned in parts
+	offset, started := stmt.stmt.getDataOffsets[colNum]
+	if started && offset < 0 {
+		return odbc.SQL_NO_DATA
+	}
+	next, ret := stmt.writeValue(value, targetType, targetValuePtr, bufferLength, ind, offset, precision, scale)
+	if ret != odbc.SQL_ERROR {
+		stmt.stmt.getDataOffsets[colNum] = next
+	}
+	return ret
+}
+
+// Converts value to cType and writes it to p, starting offset characters or bytes
+// into variable length data.  Returns the offset to continue from, or -1 once the
+// whole value has been written.
+func (h *handle) writeValue(value interface{}, cType odbc.CDataType, p unsafe.Pointer, bufferLength odbc.SQLLEN, ind *odbc.SQLLEN, offset int, precision int, scale int) (int, odbc.SQLReturn) {
+	setInd := func(v odbc.SQLLEN) {
+		if ind != nil {
+			*ind = v
+		}
+	}
+	if value == nil {
+		if ind == nil {
+			return 0, h.fail("22002", "Indicator variable required but not supplied")
+		}
+		setInd(odbc.SQL_NULL_DATA)
+		return -1, odbc.SQL_SUCCESS
+	}
+
+	var err error
+	switch cType {
+	case odbc.SQL_C_WCHAR:
+		encoded := utf16Encode(toText(value))[offset:]
+		n := len(encoded)
+		if max := int(bufferLength)/2 - 1; n > max {
+			n = max
+		}
+		if n < 0 {
+			n = 0
+		}
+		if p != nil && bufferLength >= 2 {
+			buffer := unsafe.Slice((*uint16)(p), n+1)
+			copy(buffer, encoded[0:n])
+			buffer[n] = 0
+		}
+		setInd(odbc.SQLLEN(len(encoded) * 2))
+		if n < len(encoded) {
+			return offset + n, h.warn("01004", "String data, right truncated")
+		}
+		return -1, odbc.SQL_SUCCESS
+	case odbc.SQL_C_BINARY, odbc.SQL_C_CHAR:
+		data := toBytes(value)[offset:]
+		max := int(bufferLength)
+		if cType == odbc.SQL_C_CHAR {
+			max--
+		}
+		n := len(data)
+		if n > max {
+			n = max
+		}
+		if n < 0 {
+			n = 0
+		}
+		if p != nil && bufferLength > 0 {
+			buffer := unsafe.Slice((*byte)(p), bufferLength)
+			copy(buffer, data[0:n])
+			if cType == odbc.SQL_C_CHAR {
+				buffer[n] = 0
+			}
+		}
+		setInd(odbc.SQLLEN(len(data)))
+		if n < len(data) {
+			return offset + n, h.warn("01004", "String data, right truncated")
+		}
+		return -1, odbc.SQL_SUCCESS
+	case odbc.SQL_C_BIT:
+		var i int64
+		if i, err = toInt64(value); err == nil {
+			*(*byte)(p) = byte(i & 1)
+			setInd(1)
+		}
+	case odbc.SQL_C_SHORT:
+		var i int64
+		if i, err = toInt64(value); err == nil {
+			*(*int16)(p) = int16(i)
+			setInd(2)
+		}
+	case odbc.SQL_C_LONG:
+		var i int64
+		if i, err = toInt64(value); err == nil {
+			*(*int32)(p) = int32(i)
+			setInd(4)
+		}
+	case odbc.SQL_C_SBIGINT:
+		var i int64
+		if i, err = toInt64(value); err == nil {
+			*(*int64)(p) = i
+			setInd(8)
+		}
+	case odbc.SQL_C_FLOAT:
+		var f float64
+		if f, err = toFloat64(value); err == nil {
+			*(*float32)(p) = float32(f)
+			setInd(4)
+		}
+	case odbc.SQL_C_DOUBLE:
+		var f float64
+		if f, err = toFloat64(value); err == nil {
+			*(*float64)(p) = f
+			setInd(8)
+		}
+	case odbc.SQL_C_NUMERIC:
+		if r, ratErr := toRat(value); ratErr == nil {
+			*(*odbc.SQL_NUMERIC_STRUCT)(p) = toNumericStruct(r, precision, scale)
+			setInd(odbc.SQLLEN(unsafe.Sizeof(odbc.SQL_NUMERIC_STRUCT{})))
+		} else {
+			err = ratErr
+		}
+	case odbc.SQL_C_DATE, odbc.SQL_C_TIME, odbc.SQL_C_TIMESTAMP, odbc.SQL_C_SS_TIME2, odbc.SQL_C_SS_TIMESTAMPOFFSET:
+		var t time.Time
+		if t, err = toTime(value); err == nil {
+			writeTime(cType, p, t)
+			setInd(odbc.SQLLEN(timeStructSize(cType)))
+		}
+	case odbc.SQL_C_GUID:
+		var g odbc.SQLGUID
+		if g, err = toGUID(value); err == nil {
+			*(*odbc.SQLGUID)(p) = g
+			setInd(odbc.SQLLEN(unsafe.Sizeof(g)))
+		}
+	default:
+		return 0, h.fail("HY003", "Program type out of range: %v", cType)
+	}
+	if err != nil {
+		return 0, h.fail("22018", "Invalid character value for cast specification: %v", err)
+	}
+	return -1, odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLGetStmtAttr(statementHandle odbc.SQLHandle, attribute odbc.SQLINTEGER, valuePtr unsafe.Pointer, bufferLength odbc.SQLINTEGER, stringLengthPtr *odbc.SQLINTEGER) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	stmt := dm.begin("SQLGetStmtAttr", odbc.SQL_HANDLE_STMT, statementHandle)
+	if stmt == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	switch attribute {
+	case odbc.SQL_ATTR_APP_PARAM_DESC:
+		*(*odbc.SQLHandle)(valuePtr) = stmt.stmt.apd.id
+	case odbc.SQL_ATTR_APP_ROW_DESC:
+		*(*odbc.SQLHandle)(valuePtr) = stmt.stmt.ard.id
+	case odbc.SQL_ATTR_IMP_PARAM_DESC, odbc.SQL_ATTR_IMP_ROW_DESC:
+		return stmt.fail("HYC00", "Optional feature not implemented: implementation descriptors")
+	default:
+		*(*odbc.SQLPOINTER)(valuePtr) = stmt.attrs[attribute]
+	}
+	return odbc.SQL_SUCCESS
+}
+
+func (dm *DriverManager) SQLSetDescField(descriptorHandle odbc.SQLHandle, recNum odbc.SQLSMALLINT, fieldIdentifier odbc.SQLSMALLINT, valuePtr unsafe.Pointer, bufferLength odbc.SQLINTEGER) odbc.SQLReturn {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	desc := dm.begin("SQLSetDescField", odbc.SQL_HANDLE_DESC, descriptorHandle)
+	if desc == nil {
+		return odbc.SQL_INVALID_HANDLE
+	}
+	if desc.records == nil {
+		desc.records = make(map[odbc.SQLSMALLINT]map[odbc.SQLSMALLINT]uintptr)
+	}
+	if desc.records[recNum] == nil {
+		desc.records[recNum] = make(map[odbc.SQLSMALLINT]uintptr)
+	}
+	// Integer fields are passed by reference -- see odbc.IntegerField
+	value := uintptr(valuePtr)
+	if !odbc.IsPointerDescField(fieldIdentifier) {
+		value = uintptr(*(*odbc.SQLLEN)(valuePtr))
+	}
+	desc.records[recNum][fieldIdentifier] = value
+	return odbc.SQL_SUCCESS
+}
@@ -0,0 +1,378 @@
+package fake
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// Reads a UTF-16 string of length characters, or NUL terminated when length is SQL_NTS
+func readUTF16(p unsafe.Pointer, length int) string {
+	if p == nil {
+		return ""
+	}
+	if length == odbc.SQL_NTS {
+		length = 0
+		for *(*uint16)(unsafe.Add(p, length*2)) != 0 {
+			length++
+		}
+	}
+	return string(utf16.Decode(unsafe.Slice((*uint16)(p), length)))
+}
+
+// Writes s as a NUL terminated UTF-16 string into a buffer of bufferBytes bytes.
+// Returns the length of s in characters and whether it was truncated.
+func writeUTF16(p unsafe.Pointer, bufferBytes int, s string) (int, bool) {
+	encoded := utf16.Encode([]rune(s))
+	if p == nil || bufferBytes < 2 {
+		return len(encoded), len(encoded) > 0
+	}
+	n := len(encoded)
+	truncated := false
+	if max := bufferBytes/2 - 1; n > max {
+		n = max
+		truncated = true
+	}
+	buffer := unsafe.Slice((*uint16)(p), n+1)
+	copy(buffer, encoded[0:n])
+	buffer[n] = 0
+	return len(encoded), truncated
+}
+
+// Returns the address held by a pointer attribute such as SQL_ATTR_ROWS_FETCHED_PTR.
+// The caller keeps the memory alive while the attribute is set.
+func attrPointer(value odbc.SQLPOINTER) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&value))
+}
+
+// Converts a row value to text
+func toText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05.999999999")
+	case *big.Rat:
+		return v.FloatString(ratScale(v))
+	}
+	return fmt.Sprint(value)
+}
+
+// Converts a row value to bytes
+func toBytes(value interface{}) []byte {
+	if b, ok := value.([]byte); ok {
+		return b
+	}
+	return []byte(toText(value))
+}
+
+// Converts a row value to an exact rational number
+func toRat(value interface{}) (*big.Rat, error) {
+	switch v := value.(type) {
+	case *big.Rat:
+		return v, nil
+	case *big.Int:
+		return new(big.Rat).SetInt(v), nil
+	case float64:
+		return new(big.Rat).SetFloat64(v), nil
+	case float32:
+		return new(big.Rat).SetFloat64(float64(v)), nil
+	case bool:
+		if v {
+			return big.NewRat(1, 1), nil
+		}
+		return big.NewRat(0, 1), nil
+	case string:
+		r, ok := new(big.Rat).SetString(v)
+		if !ok {
+			return nil, fmt.Errorf("Invalid character value for cast specification: %v", v)
+		}
+		return r, nil
+	}
+	i, err := toInt64(value)
+	if err != nil {
+		return nil, err
+	}
+	return big.NewRat(i, 1), nil
+}
+
+// Converts a row value to an integer
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint:
+		return int64(v), nil
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint64:
+		return int64(v), nil
+	case float32:
+		return int64(v), nil
+	case float64:
+		return int64(v), nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	case *big.Int:
+		return v.Int64(), nil
+	case *big.Rat:
+		return new(big.Int).Quo(v.Num(), v.Denom()).Int64(), nil
+	}
+	return 0, fmt.Errorf("Restricted data type attribute violation: %T", value)
+}
+
+// Converts a row value to a float
+func toFloat64(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	case *big.Rat:
+		f, _ := v.Float64()
+		return f, nil
+	}
+	i, err := toInt64(value)
+	return float64(i), err
+}
+
+// Number of decimal digits needed to represent r exactly, capped at 38
+func ratScale(r *big.Rat) int {
+	scale := 0
+	denom := new(big.Int).Set(r.Denom())
+	ten := big.NewInt(10)
+	for scale < 38 && denom.Cmp(big.NewInt(1)) != 0 {
+		g := new(big.Int).GCD(nil, nil, denom, ten)
+		if g.Cmp(big.NewInt(1)) == 0 {
+			return 38
+		}
+		denom.Quo(denom, g)
+		scale++
+	}
+	return scale
+}
+
+// Encodes r as a SQL_NUMERIC_STRUCT with the requested precision and scale
+func toNumericStruct(r *big.Rat, precision int, scale int) odbc.SQL_NUMERIC_STRUCT {
+	var value odbc.SQL_NUMERIC_STRUCT
+	value.Precision = odbc.SQLCHAR(precision)
+	value.Scale = odbc.SQLCHAR(scale)
+	value.Sign = 1
+	if r.Sign() < 0 {
+		value.Sign = 0
+	}
+	scaled := new(big.Rat).Abs(r)
+	scaled.Mul(scaled, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)))
+	mantissa := new(big.Int).Quo(scaled.Num(), scaled.Denom())
+	littleEndian := mantissa.Bytes()
+	for i := 0; i < len(littleEndian) && i < len(value.Val); i++ {
+		value.Val[i] = odbc.SQLCHAR(littleEndian[len(littleEndian)-1-i])
+	}
+	return value
+}
+
+// Decodes a SQL_NUMERIC_STRUCT
+func fromNumericStruct(value odbc.SQL_NUMERIC_STRUCT) *big.Rat {
+	bigEndian := make([]byte, len(value.Val))
+	for i, v := range value.Val {
+		bigEndian[len(bigEndian)-1-i] = byte(v)
+	}
+	r := new(big.Rat).SetInt(new(big.Int).SetBytes(bigEndian))
+	r.Quo(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(value.Scale)), nil)))
+	if value.Sign == 0 {
+		r.Neg(r)
+	}
+	return r
+}
+
+// Converts a time.Time row value
+func toTime(value interface{}) (time.Time, error) {
+	if t, ok := value.(time.Time); ok {
+		return t, nil
+	}
+	if s, ok := value.(string); ok {
+		for _, layout := range []string{"2006-01-02 15:04:05.999999999", "2006-01-02", "15:04:05.999999999"} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("Invalid datetime format: %v", value)
+}
+
+// Converts a GUID row value -- 16 bytes in RFC 4122 order, or text with or without hyphens and braces
+func toGUID(value interface{}) (odbc.SQLGUID, error) {
+	b, ok := value.([]byte)
+	if !ok || len(b) != 16 {
+		digits := strings.NewReplacer("-", "", "{", "", "}", "").Replace(toText(value))
+		decoded, err := hex.DecodeString(digits)
+		if err != nil || len(decoded) != 16 {
+			return odbc.SQLGUID{}, fmt.Errorf("Invalid GUID: %v", value)
+		}
+		b = decoded
+	}
+	g := odbc.SQLGUID{Data1: binary.BigEndian.Uint32(b[0:4]), Data2: binary.BigEndian.Uint16(b[4:6]), Data3: binary.BigEndian.Uint16(b[6:8])}
+	copy(g.Data4[:], b[8:])
+	return g, nil
+}
+
+// Reads the value of a bound parameter from its C buffer
+func readParameter(cType odbc.CDataType, p unsafe.Pointer, ind *odbc.SQLLEN) (interface{}, error) {
+	if ind != nil && *ind == odbc.SQL_NULL_DATA {
+		return nil, nil
+	}
+	if p == nil {
+		return nil, nil
+	}
+	switch cType {
+	case odbc.SQL_C_BIT:
+		return *(*byte)(p) != 0, nil
+	case odbc.SQL_C_SBIGINT:
+		return *(*int64)(p), nil
+	case odbc.SQL_C_SHORT:
+		return int64(*(*int16)(p)), nil
+	case odbc.SQL_C_LONG:
+		return int64(*(*int32)(p)), nil
+	case odbc.SQL_C_FLOAT:
+		return float64(*(*float32)(p)), nil
+	case odbc.SQL_C_DOUBLE:
+		return *(*float64)(p), nil
+	case odbc.SQL_C_NUMERIC:
+		return fromNumericStruct(*(*odbc.SQL_NUMERIC_STRUCT)(p)), nil
+	case odbc.SQL_C_WCHAR:
+		length := odbc.SQL_NTS
+		if ind != nil && *ind >= 0 {
+			length = int(*ind) / 2
+		}
+		return readUTF16(p, length), nil
+	case odbc.SQL_C_CHAR:
+		if ind != nil && *ind >= 0 {
+			return string(unsafe.Slice((*byte)(p), int(*ind))), nil
+		}
+		length := 0
+		for *(*byte)(unsafe.Add(p, length)) != 0 {
+			length++
+		}
+		return string(unsafe.Slice((*byte)(p), length)), nil
+	case odbc.SQL_C_BINARY:
+		if ind == nil {
+			return nil, fmt.Errorf("Binary parameter bound without a length")
+		}
+		return append([]byte(nil), unsafe.Slice((*byte)(p), int(*ind))...), nil
+	case odbc.SQL_C_DATE:
+		v := *(*odbc.SQL_DATE_STRUCT)(p)
+		return time.Date(int(v.Year), time.Month(v.Month), int(v.Day), 0, 0, 0, 0, time.UTC), nil
+	case odbc.SQL_C_TIME:
+		v := *(*odbc.SQL_TIME_STRUCT)(p)
+		return time.Date(0, 1, 1, int(v.Hour), int(v.Minute), int(v.Second), 0, time.UTC), nil
+	case odbc.SQL_C_TIMESTAMP:
+		v := *(*odbc.SQL_TIMESTAMP_STRUCT)(p)
+		return time.Date(int(v.Year), time.Month(v.Month), int(v.Day), int(v.Hour), int(v.Minute), int(v.Second), int(v.Fraction), time.UTC), nil
+	case odbc.SQL_C_SS_TIME2:
+		v := *(*odbc.SQL_SS_TIME2_STRUCT)(p)
+		return time.Date(0, 1, 1, int(v.Hour), int(v.Minute), int(v.Second), int(v.Fraction), time.UTC), nil
+	case odbc.SQL_C_SS_TIMESTAMPOFFSET:
+		v := *(*odbc.SQL_SS_TIMESTAMPOFFSET_STRUCT)(p)
+		zone := time.FixedZone("", int(v.TimezoneHour)*3600+int(v.TimezoneMinute)*60)
+		return time.Date(int(v.Year), time.Month(v.Month), int(v.Day), int(v.Hour), int(v.Minute), int(v.Second), int(v.Fraction), zone), nil
+	}
+	return nil, fmt.Errorf("Program type out of range: %v", cType)
+}
+
+// Encodes s as UTF-16 without a terminating NUL
+func utf16Encode(s string) []uint16 {
+	return utf16.Encode([]rune(s))
+}
+
+// Writes t as the date, time, timestamp or SQL Server time2 or timestampoffset structure for cType
+func writeTime(cType odbc.CDataType, p unsafe.Pointer, t time.Time) {
+	switch cType {
+	case odbc.SQL_C_DATE:
+		*(*odbc.SQL_DATE_STRUCT)(p) = odbc.SQL_DATE_STRUCT{Year: odbc.SQLSMALLINT(t.Year()), Month: odbc.SQLUSMALLINT(t.Month()), Day: odbc.SQLUSMALLINT(t.Day())}
+	case odbc.SQL_C_TIME:
+		*(*odbc.SQL_TIME_STRUCT)(p) = odbc.SQL_TIME_STRUCT{Hour: odbc.SQLUSMALLINT(t.Hour()), Minute: odbc.SQLUSMALLINT(t.Minute()), Second: odbc.SQLUSMALLINT(t.Second())}
+	case odbc.SQL_C_TIMESTAMP:
+		*(*odbc.SQL_TIMESTAMP_STRUCT)(p) = odbc.SQL_TIMESTAMP_STRUCT{Year: odbc.SQLSMALLINT(t.Year()), Month: odbc.SQLUSMALLINT(t.Month()), Day: odbc.SQLUSMALLINT(t.Day()),
+			Hour: odbc.SQLUSMALLINT(t.Hour()), Minute: odbc.SQLUSMALLINT(t.Minute()), Second: odbc.SQLUSMALLINT(t.Second()), Fraction: odbc.SQLUINTEGER(t.Nanosecond())}
+	case odbc.SQL_C_SS_TIME2:
+		*(*odbc.SQL_SS_TIME2_STRUCT)(p) = odbc.SQL_SS_TIME2_STRUCT{Hour: odbc.SQLUSMALLINT(t.Hour()), Minute: odbc.SQLUSMALLINT(t.Minute()), Second: odbc.SQLUSMALLINT(t.Second()), Fraction: odbc.SQLUINTEGER(t.Nanosecond())}
+	case odbc.SQL_C_SS_TIMESTAMPOFFSET:
+		_, offset := t.Zone()
+		*(*odbc.SQL_SS_TIMESTAMPOFFSET_STRUCT)(p) = odbc.SQL_SS_TIMESTAMPOFFSET_STRUCT{Year: odbc.SQLSMALLINT(t.Year()), Month: odbc.SQLUSMALLINT(t.Month()), Day: odbc.SQLUSMALLINT(t.Day()),
+			Hour: odbc.SQLUSMALLINT(t.Hour()), Minute: odbc.SQLUSMALLINT(t.Minute()), Second: odbc.SQLUSMALLINT(t.Second()), Fraction: odbc.SQLUINTEGER(t.Nanosecond()),
+			TimezoneHour: odbc.SQLSMALLINT(offset / 3600), TimezoneMinute: odbc.SQLSMALLINT(offset % 3600 / 60)}
+	}
+}
+
+// Size in bytes of the date, time or timestamp structure for cType
+func timeStructSize(cType odbc.CDataType) uintptr {
+	switch cType {
+	case odbc.SQL_C_DATE:
+		return unsafe.Sizeof(odbc.SQL_DATE_STRUCT{})
+	case odbc.SQL_C_TIME:
+		return unsafe.Sizeof(odbc.SQL_TIME_STRUCT{})
+	case odbc.SQL_C_SS_TIME2:
+		return unsafe.Sizeof(odbc.SQL_SS_TIME2_STRUCT{})
+	case odbc.SQL_C_SS_TIMESTAMPOFFSET:
+		return unsafe.Sizeof(odbc.SQL_SS_TIMESTAMPOFFSET_STRUCT{})
+	}
+	return unsafe.Sizeof(odbc.SQL_TIMESTAMP_STRUCT{})
+}
+
+// Counts the ? parameter markers in query that are outside quoted strings,
+// quoted identifiers and comments
+func countParameterMarkers(query string) int {
+	count := 0
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; c {
+		case '?':
+			count++
+		case '\'', '"', '[':
+			end := c
+			if c == '[' {
+				end = ']'
+			}
+			for i++; i < len(query) && query[i] != end; i++ {
+			}
+		case '-':
+			if i+1 < len(query) && query[i+1] == '-' {
+				for ; i < len(query) && query[i] != '\n'; i++ {
+				}
+			}
+		}
+	}
+	return count
+}
@@ -0,0 +1,389 @@
+package fake
+
+import (
+	"fmt"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"sync"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// In-memory driver manager implementing odbc.API
+type DriverManager struct {
+	mu sync.Mutex
+
+	// Allocated handles
+	handles    map[odbc.SQLHandle]*handle
+	nextHandle odbc.SQLHandle
+
+	// Process level environment attributes, set with a null environment handle
+	envAttrs map[odbc.SQLINTEGER]odbc.SQLPOINTER
+
+	// Statement handlers by SQL text
+	handlers       map[string]Handler
+	defaultHandler Handler
+
+	// Called by SQLDriverConnect, returns the errors that fail the connection
+	connectHandler func(connString string) []Diagnostic
+
+	// Log of executions and calls
+	executions []Execution
+	calls      []Call
+}
+
+var _ odbc.API = (*DriverManager)(nil)
+
+// Creates a DriverManager with no registered responses
+func New() *DriverManager {
+	return &DriverManager{
+		handles:  make(map[odbc.SQLHandle]*handle),
+		envAttrs: make(map[odbc.SQLINTEGER]odbc.SQLPOINTER),
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Registers the handler that answers statements with exactly the SQL text query
+func (dm *DriverManager) HandleQuery(query string, h Handler) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	dm.handlers[query] = h
+}
+
+// Registers a fixed response for statements with exactly the SQL text query
+func (dm *DriverManager) SetResponse(query string, r *Response) {
+	dm.HandleQuery(query, func(*Execution) *Response {
+		return r
+	})
+}
+
+// Registers the handler for statements without a query specific handler.
+// Without one, such statements fail with SQLSTATE 42000.
+func (dm *DriverManager) HandleDefault(h Handler) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	dm.defaultHandler = h
+}
+
+// Registers a function that validates connection strings passed to
+// SQLDriverConnect.  Returning diagnostics fails the connection.
+func (dm *DriverManager) HandleConnect(fn func(connString string) []Diagnostic) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	dm.connectHandler = fn
+}
+
+// Simulates the server dropping every open connection, as in a restart or failover.
+// Later statements on them fail with SQLSTATE 08S01 and SQL_ATTR_CONNECTION_DEAD
+// reports SQL_CD_TRUE; new connections are not affected.
+func (dm *DriverManager) DropConnections() {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	for _, h := range dm.handles {
+		if h.kind == odbc.SQL_HANDLE_DBC && h.connected {
+			h.dead = true
+		}
+	}
+}
+
+// Returns the statements executed so far
+func (dm *DriverManager) Executions() []Execution {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	return append([]Execution(nil), dm.executions...)
+}
+
+// Returns the API calls made so far
+func (dm *DriverManager) Calls() []Call {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	return append([]Call(nil), dm.calls...)
+}
+
+// Returns the process level connection pooling attribute, along with the attributes
+// set on the open environment handles
+func (dm *DriverManager) EnvAttrs() map[odbc.SQLINTEGER]odbc.SQLPOINTER {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	attrs := copyAttrs(dm.envAttrs)
+	for _, h := range dm.handles {
+		if h.kind == odbc.SQL_HANDLE_ENV {
+			for k, v := range h.attrs {
+				attrs[k] = v
+			}
+		}
+	}
+	return attrs
+}
+
+// Returns the number of allocated handles of handleType, to detect leaks.
+// Implicitly allocated descriptors are not counted.
+func (dm *DriverManager) OpenHandles(handleType odbc.SQLSMALLINT) int {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	count := 0
+	for _, h := range dm.handles {
+		if h.kind == handleType && !h.implicit {
+			count++
+		}
+	}
+	return count
+}
+
+// State of an allocated handle
+type handle struct {
+	id       odbc.SQLHandle
+	kind     odbc.SQLSMALLINT
+	parent   *handle
+	implicit bool
+
+	// Diagnostics records from the last call on the handle
+	diags []Diagnostic
+
+	// Attributes set on the environment, connection or statement
+	attrs map[odbc.SQLINTEGER]odbc.SQLPOINTER
+
+	// Connection state
+	connected  bool
+	connString string
+	dead       bool
+
+	// Statement state
+	stmt *statement
+
+	// Descriptor fields by record number and field identifier
+	records map[odbc.SQLSMALLINT]map[odbc.SQLSMALLINT]uintptr
+}
+
+// Statement state
+type statement struct {
+	// Implicitly allocated application descriptors
+	apd *handle
+	ard *handle
+
+	// Statement text prepared with SQLPrepare
+	prepared bool
+	query    string
+
+	// Parameters bound with SQLBindParameter
+	params map[odbc.SQLUSMALLINT]*parameter
+
+	// Result sets of the last execution and the position within them.
+	// rowIndex is the first row of the current rowset of rowsetRows rows.
+	executed   bool
+	resultSets []*ResultSet
+	setIndex   int
+	rowIndex   int
+	rowsetRows int
+
+	// Values for the output parameters, written when the last result set is consumed
+	outputParams []interface{}
+
+	// Columns bound with SQLBindCol
+	bindings map[odbc.SQLUSMALLINT]*binding
+
+	// Amount of each column already returned by SQLGetData for the current row
+	getDataOffsets map[odbc.SQLUSMALLINT]int
+
+	// Execution in progress, used by SQLCancel
+	running *Execution
+
+	// Execution waiting for data at execution parameters sent with SQLParamData and SQLPutData
+	dataAtExec *dataAtExec
+}
+
+// Execution waiting for its data at execution parameters
+type dataAtExec struct {
+	query    string
+	prepared bool
+
+	// Parameters still to be sent, the first of them being sent once current is set
+	pending []odbc.SQLUSMALLINT
+	current odbc.SQLUSMALLINT
+
+	// Data sent for each parameter -- nil for NULL
+	values map[odbc.SQLUSMALLINT][]byte
+}
+
+// Returns the numbers of the parameters bound with SQL_DATA_AT_EXEC or SQL_LEN_DATA_AT_EXEC, in order
+func (s *statement) dataAtExecParams() []odbc.SQLUSMALLINT {
+	var numbers []odbc.SQLUSMALLINT
+	for number := odbc.SQLUSMALLINT(1); int(number) <= len(s.params); number++ {
+		param, ok := s.params[number]
+		if !ok || param.ind == nil {
+			continue
+		}
+		if *param.ind == odbc.SQL_DATA_AT_EXEC || *param.ind <= odbc.SQL_LEN_DATA_AT_EXEC_OFFSET {
+			numbers = append(numbers, number)
+		}
+	}
+	return numbers
+}
+
+// Parameter bound with SQLBindParameter
+type parameter struct {
+	ioType  odbc.SQLSMALLINT
+	cType   odbc.CDataType
+	sqlType odbc.SQLDataType
+	size    odbc.SQLULEN
+	digits  odbc.SQLSMALLINT
+	value   unsafe.Pointer
+	bufLen  odbc.SQLLEN
+	ind     *odbc.SQLLEN
+}
+
+// Decodes the data sent for a data at execution parameter with SQLPutData
+func (p *parameter) decode(data []byte) interface{} {
+	if data == nil {
+		return nil
+	}
+	switch p.cType {
+	case odbc.SQL_C_WCHAR:
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = uint16(data[2*i]) | uint16(data[2*i+1])<<8
+		}
+		return string(utf16.Decode(units))
+	case odbc.SQL_C_CHAR:
+		return string(data)
+	}
+	return data
+}
+
+// Reads the value of row of a column-wise bound parameter array
+func (p *parameter) read(row int) (interface{}, error) {
+	if row == 0 {
+		return readParameter(p.cType, p.value, p.ind)
+	}
+	var ind *odbc.SQLLEN
+	if p.ind != nil {
+		ind = (*odbc.SQLLEN)(unsafe.Add(unsafe.Pointer(p.ind), row*int(unsafe.Sizeof(odbc.SQLLEN(0)))))
+	}
+	var value unsafe.Pointer
+	if p.value != nil {
+		value = unsafe.Add(p.value, row*elementSize(p.cType, p.bufLen))
+	}
+	return readParameter(p.cType, value, ind)
+}
+
+// Column bound with SQLBindCol
+type binding struct {
+	cType  odbc.CDataType
+	value  unsafe.Pointer
+	bufLen odbc.SQLLEN
+	ind    *odbc.SQLLEN
+}
+
+// Size of one element of the column-wise bound array
+func (b *binding) elementSize() int {
+	return elementSize(b.cType, b.bufLen)
+}
+
+// Size of one element of a column-wise bound array of cType -- the buffer
+// length for variable length types, the size of the C type otherwise
+func elementSize(cType odbc.CDataType, bufLen odbc.SQLLEN) int {
+	switch cType {
+	case odbc.SQL_C_WCHAR, odbc.SQL_C_CHAR, odbc.SQL_C_BINARY:
+		return int(bufLen)
+	case odbc.SQL_C_BIT:
+		return 1
+	case odbc.SQL_C_SHORT:
+		return 2
+	case odbc.SQL_C_LONG, odbc.SQL_C_FLOAT:
+		return 4
+	case odbc.SQL_C_SBIGINT, odbc.SQL_C_DOUBLE:
+		return 8
+	case odbc.SQL_C_NUMERIC:
+		return int(unsafe.Sizeof(odbc.SQL_NUMERIC_STRUCT{}))
+	case odbc.SQL_C_GUID:
+		return int(unsafe.Sizeof(odbc.SQLGUID{}))
+	case odbc.SQL_C_DATE, odbc.SQL_C_TIME, odbc.SQL_C_TIMESTAMP, odbc.SQL_C_SS_TIME2, odbc.SQL_C_SS_TIMESTAMPOFFSET:
+		return int(timeStructSize(cType))
+	}
+	return 0
+}
+
+// Current result set, or nil when there is none
+func (s *statement) resultSet() *ResultSet {
+	if s.setIndex < len(s.resultSets) {
+		return s.resultSets[s.setIndex]
+	}
+	return nil
+}
+
+// A cursor is open while results remain to be read.  The count of a final
+// statement that does not return rows does not hold a cursor open.
+func (s *statement) cursorOpen() bool {
+	rs := s.resultSet()
+	if rs == nil {
+		return false
+	}
+	return len(rs.Columns) > 0 || s.setIndex < len(s.resultSets)-1
+}
+
+// Discards the results of the last execution
+func (s *statement) closeCursor() {
+	s.resultSets = nil
+	s.setIndex = 0
+	s.rowIndex = -1
+	s.rowsetRows = 0
+	s.getDataOffsets = nil
+}
+
+// Allocates a handle of kind with parent, caller must hold dm.mu
+func (dm *DriverManager) newHandle(kind odbc.SQLSMALLINT, parent *handle) *handle {
+	dm.nextHandle++
+	h := &handle{id: dm.nextHandle, kind: kind, parent: parent, attrs: make(map[odbc.SQLINTEGER]odbc.SQLPOINTER)}
+	dm.handles[h.id] = h
+	return h
+}
+
+// Starts an API call on a handle of kind: logs the call and clears the
+// handle's diagnostics.  Caller must hold dm.mu.  Returns nil if the handle
+// is not a valid handle of that kind.
+func (dm *DriverManager) begin(function string, kind odbc.SQLSMALLINT, id odbc.SQLHandle) *handle {
+	dm.calls = append(dm.calls, Call{Function: function, Handle: id})
+	h := dm.lookup(id)
+	if h == nil || h.kind != kind {
+		return nil
+	}
+	h.diags = nil
+	return h
+}
+
+// Returns the handle with id, or nil.  Caller must hold dm.mu.
+func (dm *DriverManager) lookup(id odbc.SQLHandle) *handle {
+	return dm.handles[id]
+}
+
+// Adds a diagnostics record and returns SQL_ERROR
+func (h *handle) fail(state string, format string, args ...interface{}) odbc.SQLReturn {
+	h.diags = append(h.diags, Diagnostic{State: state, Message: "[lodbc][fake]" + fmt.Sprintf(format, args...)})
+	return odbc.SQL_ERROR
+}
+
+// Adds a warning record and returns SQL_SUCCESS_WITH_INFO
+func (h *handle) warn(state string, format string, args ...interface{}) odbc.SQLReturn {
+	h.diags = append(h.diags, Diagnostic{State: state, Message: "[lodbc][fake]" + fmt.Sprintf(format, args...)})
+	return odbc.SQL_SUCCESS_WITH_INFO
+}
+
+// Values of connection attributes that have not been set
+var defaultConnAttrs = map[odbc.SQLINTEGER]odbc.SQLPOINTER{
+	odbc.SQL_ATTR_AUTOCOMMIT:    odbc.SQLPOINTER(odbc.SQL_AUTOCOMMIT_ON),
+	odbc.SQL_ATTR_ACCESS_MODE:   odbc.SQLPOINTER(odbc.SQL_MODE_READ_WRITE),
+	odbc.SQL_ATTR_TXN_ISOLATION: odbc.SQLPOINTER(odbc.SQL_TXN_READ_COMMITTED),
+}
+
+// Returns true if state reports that the link to the server was lost
+func isLinkFailure(state string) bool {
+	return state == "08S01" || state == "08003" || state == "08007"
+}
+
+// Copies an attribute map
+func copyAttrs(attrs map[odbc.SQLINTEGER]odbc.SQLPOINTER) map[odbc.SQLINTEGER]odbc.SQLPOINTER {
+	c := make(map[odbc.SQLINTEGER]odbc.SQLPOINTER, len(attrs))
+	for k, v := range attrs {
+		c[k] = v
+	}
+	return c
+}
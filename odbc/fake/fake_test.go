@@ -0,0 +1,164 @@
+package fake_test
+
+import (
+	"github.com/LukeMauldin/lodbc/odbc"
+	"github.com/LukeMauldin/lodbc/odbc/fake"
+	"testing"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// Returns s as a NUL terminated UTF-16 string for the SQLCHAR arguments of the API
+func sqlText(s string) *odbc.SQLCHAR {
+	text := append(utf16.Encode([]rune(s)), 0)
+	return (*odbc.SQLCHAR)(unsafe.Pointer(&text[0]))
+}
+
+// Allocates an environment, connection and statement on dm
+func allocStatement(t *testing.T, dm *fake.DriverManager) (env, conn, stmt odbc.SQLHandle) {
+	t.Helper()
+	if ret := dm.SQLAllocHandle(odbc.SQL_HANDLE_ENV, 0, &env); ret != odbc.SQL_SUCCESS {
+		t.Fatalf("SQLAllocHandle(ENV) returned %v", ret)
+	}
+	if ret := dm.SQLAllocHandle(odbc.SQL_HANDLE_DBC, env, &conn); ret != odbc.SQL_ERROR {
+		t.Errorf("SQLAllocHandle(DBC) before SQL_ATTR_ODBC_VERSION returned %v", ret)
+	}
+	if ret := dm.SQLSetEnvAttr(env, odbc.SQL_ATTR_ODBC_VERSION, odbc.SQL_OV_ODBC3, 0); ret != odbc.SQL_SUCCESS {
+		t.Fatalf("SQLSetEnvAttr returned %v", ret)
+	}
+	if ret := dm.SQLAllocHandle(odbc.SQL_HANDLE_DBC, env, &conn); ret != odbc.SQL_SUCCESS {
+		t.Fatalf("SQLAllocHandle(DBC) returned %v", ret)
+	}
+	if ret := dm.SQLDriverConnect(conn, 0, sqlText("DSN=fake"), odbc.SQL_NTS, nil, 0, nil, odbc.SQL_DRIVER_NOPROMPT); ret != odbc.SQL_SUCCESS {
+		t.Fatalf("SQLDriverConnect returned %v", ret)
+	}
+	if ret := dm.SQLAllocHandle(odbc.SQL_HANDLE_STMT, conn, &stmt); ret != odbc.SQL_SUCCESS {
+		t.Fatalf("SQLAllocHandle(STMT) returned %v", ret)
+	}
+	return env, conn, stmt
+}
+
+func TestExecDirectFetchesTheResponse(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("select id", &fake.Response{ResultSets: []*fake.ResultSet{{
+		Columns: []fake.Column{{Name: "id", Type: odbc.SQL_INTEGER}},
+		Rows:    [][]interface{}{{7}, {nil}},
+	}}})
+	env, conn, stmt := allocStatement(t, dm)
+
+	if ret := dm.SQLExecDirect(stmt, sqlText("select id"), odbc.SQL_NTS); ret != odbc.SQL_SUCCESS {
+		t.Fatalf("SQLExecDirect returned %v", ret)
+	}
+	var columns odbc.SQLSMALLINT
+	if ret := dm.SQLNumResultCols(stmt, &columns); ret != odbc.SQL_SUCCESS || columns != 1 {
+		t.Fatalf("SQLNumResultCols returned %v, %v", columns, ret)
+	}
+	want := []struct {
+		value odbc.SQLINTEGER
+		ind   odbc.SQLLEN
+	}{{7, 4}, {0, odbc.SQL_NULL_DATA}}
+	for _, w := range want {
+		if ret := dm.SQLFetch(stmt); ret != odbc.SQL_SUCCESS {
+			t.Fatalf("SQLFetch returned %v", ret)
+		}
+		var value odbc.SQLINTEGER
+		var ind odbc.SQLLEN
+		if ret := dm.SQLGetData(stmt, 1, odbc.SQL_C_LONG, unsafe.Pointer(&value), 4, &ind); ret != odbc.SQL_SUCCESS {
+			t.Fatalf("SQLGetData returned %v", ret)
+		}
+		if value != w.value || ind != w.ind {
+			t.Errorf("SQLGetData read %v with indicator %v, want %v with %v", value, ind, w.value, w.ind)
+		}
+	}
+	if ret := dm.SQLFetch(stmt); ret != odbc.SQL_NO_DATA {
+		t.Errorf("SQLFetch past the last row returned %v", ret)
+	}
+	if executions := dm.Executions(); len(executions) != 1 || executions[0].Query != "select id" || executions[0].Prepared {
+		t.Errorf("executions %+v", executions)
+	}
+
+	//Disconnecting frees the statement along with the connection
+	if ret := dm.SQLDisconnect(conn); ret != odbc.SQL_SUCCESS {
+		t.Fatalf("SQLDisconnect returned %v", ret)
+	}
+	if open := dm.OpenHandles(odbc.SQL_HANDLE_STMT); open != 0 {
+		t.Errorf("%v statement handles open after SQLDisconnect", open)
+	}
+	dm.SQLFreeHandle(odbc.SQL_HANDLE_DBC, conn)
+	dm.SQLFreeHandle(odbc.SQL_HANDLE_ENV, env)
+	for _, handleType := range []odbc.SQLSMALLINT{odbc.SQL_HANDLE_DBC, odbc.SQL_HANDLE_ENV} {
+		if open := dm.OpenHandles(handleType); open != 0 {
+			t.Errorf("%v handles of type %v still open", open, handleType)
+		}
+	}
+}
+
+func TestUnknownStatementsFailWithDiagnostics(t *testing.T) {
+	dm := fake.New()
+	_, _, stmt := allocStatement(t, dm)
+
+	if ret := dm.SQLExecDirect(stmt, sqlText("select unknown"), odbc.SQL_NTS); ret != odbc.SQL_ERROR {
+		t.Fatalf("SQLExecDirect returned %v", ret)
+	}
+	state := make([]uint16, 6)
+	message := make([]uint16, 256)
+	var nativeError odbc.SQLINTEGER
+	var length odbc.SQLSMALLINT
+	ret := dm.SQLGetDiagRec(odbc.SQL_HANDLE_STMT, stmt, 1, unsafe.Pointer(&state[0]), &nativeError, unsafe.Pointer(&message[0]), odbc.SQLSMALLINT(len(message)), &length)
+	if ret != odbc.SQL_SUCCESS {
+		t.Fatalf("SQLGetDiagRec returned %v", ret)
+	}
+	if got := string(utf16.Decode(state[:5])); got != "42000" {
+		t.Errorf("SQLSTATE %v, want 42000", got)
+	}
+	if length == 0 {
+		t.Error("empty diagnostic message")
+	}
+	if ret := dm.SQLGetDiagRec(odbc.SQL_HANDLE_STMT, stmt, 2, unsafe.Pointer(&state[0]), &nativeError, unsafe.Pointer(&message[0]), odbc.SQLSMALLINT(len(message)), &length); ret != odbc.SQL_NO_DATA {
+		t.Errorf("SQLGetDiagRec past the last record returned %v", ret)
+	}
+}
+
+func TestDroppedConnectionsReportCommunicationLinkFailure(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("select 1", &fake.Response{})
+	_, _, stmt := allocStatement(t, dm)
+
+	if ret := dm.SQLExecDirect(stmt, sqlText("select 1"), odbc.SQL_NTS); ret != odbc.SQL_SUCCESS {
+		t.Fatalf("SQLExecDirect returned %v", ret)
+	}
+	dm.DropConnections()
+	if ret := dm.SQLExecDirect(stmt, sqlText("select 1"), odbc.SQL_NTS); ret != odbc.SQL_ERROR {
+		t.Fatalf("SQLExecDirect on a dropped connection returned %v", ret)
+	}
+	state := make([]uint16, 6)
+	var nativeError odbc.SQLINTEGER
+	var length odbc.SQLSMALLINT
+	dm.SQLGetDiagRec(odbc.SQL_HANDLE_STMT, stmt, 1, unsafe.Pointer(&state[0]), &nativeError, nil, 0, &length)
+	if got := string(utf16.Decode(state[:5])); got != "08S01" {
+		t.Errorf("SQLSTATE %v, want 08S01", got)
+	}
+}
+
+func TestBindParameterRejectsInvalidParameterTypes(t *testing.T) {
+	dm := fake.New()
+	_, _, stmt := allocStatement(t, dm)
+
+	//SQL_RETURN_VALUE is a column type reported by SQLProcedureColumns, not a parameter type
+	var value odbc.SQLINTEGER
+	var ind odbc.SQLLEN
+	ret := dm.SQLBindParameter(stmt, 1, odbc.SQL_RETURN_VALUE, odbc.SQL_C_LONG, odbc.SQL_INTEGER, 0, 0, unsafe.Pointer(&value), 0, &ind)
+	if ret != odbc.SQL_ERROR {
+		t.Fatalf("SQLBindParameter with SQL_RETURN_VALUE returned %v", ret)
+	}
+	state := make([]uint16, 6)
+	var nativeError odbc.SQLINTEGER
+	var length odbc.SQLSMALLINT
+	dm.SQLGetDiagRec(odbc.SQL_HANDLE_STMT, stmt, 1, unsafe.Pointer(&state[0]), &nativeError, nil, 0, &length)
+	if got := string(utf16.Decode(state[:5])); got != "HY105" {
+		t.Errorf("SQLSTATE %v, want HY105", got)
+	}
+	if ret := dm.SQLBindParameter(stmt, 1, odbc.SQL_PARAM_OUTPUT, odbc.SQL_C_LONG, odbc.SQL_INTEGER, 0, 0, unsafe.Pointer(&value), 0, &ind); ret != odbc.SQL_SUCCESS {
+		t.Errorf("SQLBindParameter with SQL_PARAM_OUTPUT returned %v", ret)
+	}
+}
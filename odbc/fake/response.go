@@ -0,0 +1,127 @@
+// Package fake implements odbc.API in memory so the lodbc driver can be tested
+// deterministically without a driver manager.  Handles, diagnostics records,
+// result sets, chunked SQLGetData and SQLMoreResults are simulated; the
+// results of each statement come from the Responses registered on a
+// DriverManager.
+package fake
+
+import (
+	"github.com/LukeMauldin/lodbc/odbc"
+)
+
+// Diagnostics record reported through SQLGetDiagRec
+type Diagnostic struct {
+	State       string
+	NativeError int
+	Message     string
+}
+
+// Describes a result column
+type Column struct {
+	Name string
+
+	// SQL data type reported for the column
+	Type odbc.SQLDataType
+
+	// Column size -- characters for string types, digits for numeric types
+	Precision int
+
+	// Decimal digits for numeric types
+	Scale int
+
+	// Whether the column allows NULL
+	Nullable bool
+
+	// Data source type name, such as "nvarchar" -- defaults to the name of Type
+	TypeName string
+
+	// Table and column the result column is read from, empty for expressions
+	BaseTableName  string
+	BaseColumnName string
+}
+
+// Result set returned by a statement.  A ResultSet without Columns is the
+// result of a statement that does not return rows, such as an UPDATE.
+type ResultSet struct {
+	Columns []Column
+
+	// Row values.  Supported types are nil, bool, the integer and float types,
+	// string, []byte, time.Time, *big.Int and *big.Rat; each is converted to
+	// the C type the driver asks for.
+	Rows [][]interface{}
+
+	// Number of rows affected by a statement that does not return rows
+	RowsAffected int64
+
+	// When not empty, SQLMoreResults returns SQL_SUCCESS_WITH_INFO and these
+	// records when it moves to this result set, like PRINT output between
+	// statements.  Use Response.Warnings for the first result set.
+	Warnings []Diagnostic
+}
+
+// Outcome of executing a statement
+type Response struct {
+	// Result sets in the order SQLMoreResults walks them
+	ResultSets []*ResultSet
+
+	// When not empty, execution fails with SQL_ERROR and these records
+	Errors []Diagnostic
+
+	// When not empty, execution returns SQL_SUCCESS_WITH_INFO and these records
+	Warnings []Diagnostic
+
+	// Values written to the output, input/output and return value parameters
+	// when SQLMoreResults moves past the last result set.  Index 0 is
+	// parameter 1; values for input parameters are ignored.
+	OutputParams []interface{}
+}
+
+// Direction, C and SQL types and size of a bound parameter
+type ParamType struct {
+	InputOutputType odbc.SQLSMALLINT
+	CType           odbc.CDataType
+	SQLType         odbc.SQLDataType
+	ColumnSize      int
+	DecimalDigits   int
+}
+
+// Statement execution passed to a Handler
+type Execution struct {
+	// SQL text that was executed, or the name of the catalog function such as "SQLTables"
+	Query string
+
+	// Whether the statement was prepared with SQLPrepare and run with SQLExecute
+	Prepared bool
+
+	// Values of the bound parameters, decoded from their C types.  Index 0 is parameter 1.
+	// Output and return value parameters are nil.
+	Params []interface{}
+
+	// SQL types the parameters were bound with, index 0 is parameter 1.  Empty for catalog functions.
+	ParamTypes []ParamType
+
+	// Statement attributes set with SQLSetStmtAttr
+	StmtAttrs map[odbc.SQLINTEGER]odbc.SQLPOINTER
+
+	// Connection attributes set with SQLSetConnectAttr
+	ConnAttrs map[odbc.SQLINTEGER]odbc.SQLPOINTER
+
+	// Closed when SQLCancel is called for the statement while the handler runs
+	cancel chan struct{}
+}
+
+// Returns a channel that is closed if the statement is cancelled with
+// SQLCancel while the handler is running.  Handlers that simulate long
+// running queries should select on it.
+func (e *Execution) Cancelled() <-chan struct{} {
+	return e.cancel
+}
+
+// Produces the Response for a statement execution
+type Handler func(exec *Execution) *Response
+
+// Record of an API call, in the order the calls were made
+type Call struct {
+	Function string
+	Handle   odbc.SQLHandle
+}
@@ -0,0 +1,141 @@
+//go:build linux && cgo
+
+package odbc
+
+/*
+#cgo LDFLAGS: -ldl
+#include <dlfcn.h>
+#include <stdint.h>
+#include <stdlib.h>
+
+static void *lodbc_dlopen(const char *name) {
+	return dlopen(name, RTLD_NOW | RTLD_GLOBAL);
+}
+
+static void *lodbc_dlsym(void *lib, const char *name) {
+	return dlsym(lib, name);
+}
+
+static const char *lodbc_dlerror(void) {
+	return dlerror();
+}
+
+// Every ODBC argument is an integer or a pointer, so on the supported ABIs
+// (amd64, arm64) any ODBC function can be called through one prototype with
+// the unused trailing arguments ignored by the callee -- the same approach
+// syscall.Syscall15 takes for odbc32.dll on Windows.
+typedef uintptr_t (*lodbc_fn)(uintptr_t, uintptr_t, uintptr_t, uintptr_t, uintptr_t, uintptr_t,
+	uintptr_t, uintptr_t, uintptr_t, uintptr_t, uintptr_t, uintptr_t, uintptr_t, uintptr_t, uintptr_t);
+
+static uintptr_t lodbc_call(void *fn, uintptr_t a1, uintptr_t a2, uintptr_t a3, uintptr_t a4, uintptr_t a5, uintptr_t a6,
+	uintptr_t a7, uintptr_t a8, uintptr_t a9, uintptr_t a10, uintptr_t a11, uintptr_t a12, uintptr_t a13, uintptr_t a14, uintptr_t a15) {
+	return ((lodbc_fn)fn)(a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12, a13, a14, a15);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// Shared library names tried, in order, when loading the driver manager.
+// unixODBC is required because its SQLWCHAR is 2 bytes (UTF-16), matching the
+// encoding used by the W functions on Windows.
+var driverManagerNames = []string{"libodbc.so.2", "libodbc.so.1", "libodbc.so"}
+
+// Loads the unixODBC driver manager and resolves every function the API calls
+func loadDriverManager() error {
+	for _, proc := range mododbc.procs {
+		if err := proc.Find(); err != nil {
+			return err
+		}
+	}
+	return mododbc.Load()
+}
+
+// Lazily loaded shared library -- the Linux counterpart to syscall.LazyDLL
+type lazyLib struct {
+	names  []string
+	procs  []*lazyProc
+	once   sync.Once
+	handle unsafe.Pointer
+	err    error
+}
+
+func newLazyLib(names []string) *lazyLib {
+	return &lazyLib{names: names}
+}
+
+// Loads the library, trying each name until one succeeds
+func (l *lazyLib) Load() error {
+	l.once.Do(func() {
+		messages := make([]string, 0, len(l.names))
+		for _, name := range l.names {
+			cname := C.CString(name)
+			handle := C.lodbc_dlopen(cname)
+			C.free(unsafe.Pointer(cname))
+			if handle != nil {
+				l.handle = handle
+				return
+			}
+			messages = append(messages, C.GoString(C.lodbc_dlerror()))
+		}
+		l.err = fmt.Errorf("Unable to load ODBC driver manager: %v", strings.Join(messages, "; "))
+	})
+	return l.err
+}
+
+func (l *lazyLib) NewProc(name string) *lazyProc {
+	proc := &lazyProc{lib: l, name: name}
+	l.procs = append(l.procs, proc)
+	return proc
+}
+
+// Lazily resolved function in a lazyLib -- the Linux counterpart to syscall.LazyProc
+type lazyProc struct {
+	lib  *lazyLib
+	name string
+	once sync.Once
+	addr unsafe.Pointer
+	err  error
+}
+
+// Resolves the function address
+func (p *lazyProc) Find() error {
+	p.once.Do(func() {
+		if err := p.lib.Load(); err != nil {
+			p.err = err
+			return
+		}
+		cname := C.CString(p.name)
+		defer C.free(unsafe.Pointer(cname))
+		p.addr = C.lodbc_dlsym(p.lib.handle, cname)
+		if p.addr == nil {
+			p.err = fmt.Errorf("Unable to find %v in ODBC driver manager", p.name)
+		}
+	})
+	return p.err
+}
+
+// Calls the function with up to 15 integer or pointer arguments.
+// Panics if the function cannot be found, like syscall.LazyProc.Addr.
+// Like syscall.LazyProc.Call, pointers converted to uintptr in the call's argument list are kept
+// alive and moved to the heap until it returns, so a stack copy cannot leave them stale.
+//
+//go:uintptrescapes
+func (p *lazyProc) Call(args ...uintptr) uintptr {
+	if err := p.Find(); err != nil {
+		panic(err)
+	}
+	var a [15]uintptr
+	if len(args) > len(a) {
+		panic(fmt.Sprintf("Too many arguments to %v: %v", p.name, len(args)))
+	}
+	copy(a[:], args)
+	return uintptr(C.lodbc_call(p.addr, C.uintptr_t(a[0]), C.uintptr_t(a[1]), C.uintptr_t(a[2]), C.uintptr_t(a[3]), C.uintptr_t(a[4]), C.uintptr_t(a[5]),
+		C.uintptr_t(a[6]), C.uintptr_t(a[7]), C.uintptr_t(a[8]), C.uintptr_t(a[9]), C.uintptr_t(a[10]), C.uintptr_t(a[11]),
+		C.uintptr_t(a[12]), C.uintptr_t(a[13]), C.uintptr_t(a[14])))
+}
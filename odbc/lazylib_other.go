@@ -0,0 +1,45 @@
+//go:build !windows && !(linux && cgo)
+
+package odbc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Shared library names of the driver manager, which cannot be loaded on this system
+var driverManagerNames = []string{"libodbc.so.2", "libodbc.so.1", "libodbc.so"}
+
+// Fails -- the driver manager is only loaded on Windows and on Linux with cgo
+func loadDriverManager() error {
+	return mododbc.Load()
+}
+
+// Stand-in for the Linux lazyLib that fails to load, so the package builds on every system
+// and the first connection returns the error
+type lazyLib struct {
+	names []string
+}
+
+func newLazyLib(names []string) *lazyLib {
+	return &lazyLib{names: names}
+}
+
+func (l *lazyLib) Load() error {
+	return errors.New("Unable to load ODBC driver manager: it is only loaded on Windows and on Linux built with cgo")
+}
+
+func (l *lazyLib) NewProc(name string) *lazyProc {
+	return &lazyProc{lib: l, name: name}
+}
+
+// Function of a lazyLib that cannot be loaded
+type lazyProc struct {
+	lib  *lazyLib
+	name string
+}
+
+// Panics like syscall.LazyProc.Call for a function that cannot be found
+func (p *lazyProc) Call(args ...uintptr) uintptr {
+	panic(fmt.Sprintf("Unable to call %v: %v", p.name, p.lib.Load()))
+}
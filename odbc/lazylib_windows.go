@@ -0,0 +1,8 @@
+//go:build windows
+
+package odbc
+
+// Loads odbc32.dll
+func loadDriverManager() error {
+	return mododbc32.Load()
+}
@@ -0,0 +1,278 @@
+package lodbc
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"math/big"
+	"reflect"
+	"time"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// Default precision of decimal output parameters bound without one
+const defaultOutputPrecision = 38
+
+// Output, input/output or return value parameter bound to a buffer.  The driver fills the
+// buffer once every result of the statement has been consumed, then it is copied to dest.
+type outputParameter struct {
+	index int
+	dest  interface{}
+	cType odbc.CDataType
+
+	// Buffer bound with SQLBindParameter and the length or indicator written by the driver
+	data []byte
+	ind  odbc.SQLLEN
+
+	// Location time.Time destinations are read in
+	location *time.Location
+}
+
+// Implements driver.NamedValueChecker -- accepts sql.Out for output parameters, readers for streamed parameters and
+// values of types with a registered ParameterEncoder, and converts every other argument with the default converter
+func (c *connection) CheckNamedValue(nv *driver.NamedValue) error {
+	if _, ok := nv.Value.(sql.Out); ok {
+		return nil
+	}
+	if _, ok := streamParameterOf(nv.Value); ok {
+		return nil
+	}
+	if entry, _ := parameterEncoderFor(nv.Value); entry != nil && !entry.isBuiltin {
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+// Binds an sql.Out argument as an output, input/output or return value parameter
+func (stmt *statement) bindOutput(index int, out sql.Out) error {
+	//Dest may be a *BindParameter carrying the destination with its size and direction
+	parameter := BindParameter{Data: out.Dest}
+	if bp, ok := out.Dest.(*BindParameter); ok {
+		parameter = *bp
+	}
+	direction := OutputParameter
+	if parameter.Direction == ReturnValueParameter {
+		direction = ReturnValueParameter
+	} else if out.In {
+		direction = InputOutputParameter
+	}
+	destValue := reflect.ValueOf(parameter.Data)
+	if destValue.Kind() != reflect.Ptr || destValue.IsNil() {
+		return fmt.Errorf("Error binding parameter number: %v.  Output destination must be a non-nil pointer, not %T", index, parameter.Data)
+	}
+
+	output := &outputParameter{index: index, dest: parameter.Data}
+	var sqlType odbc.SQLDataType
+	var columnSize, decimalDigits int
+	var input interface{}
+	switch dest := parameter.Data.(type) {
+	case *bool:
+		output.cType, sqlType, output.data = odbc.SQL_C_BIT, odbc.SQL_BIT, alignedBuffer(1)
+		if *dest {
+			output.data[0] = 1
+		}
+	case *int:
+		output.cType, sqlType, output.data = odbc.SQL_C_SBIGINT, odbc.SQL_BIGINT, alignedBuffer(8)
+		*(*int64)(unsafe.Pointer(&output.data[0])) = int64(*dest)
+	case *int64:
+		output.cType, sqlType, output.data = odbc.SQL_C_SBIGINT, odbc.SQL_BIGINT, alignedBuffer(8)
+		*(*int64)(unsafe.Pointer(&output.data[0])) = *dest
+	case *int32:
+		output.cType, sqlType, output.data = odbc.SQL_C_LONG, odbc.SQL_INTEGER, alignedBuffer(4)
+		*(*int32)(unsafe.Pointer(&output.data[0])) = *dest
+	case *float64:
+		output.cType, sqlType, output.data = odbc.SQL_C_DOUBLE, odbc.SQL_DOUBLE, alignedBuffer(8)
+		*(*float64)(unsafe.Pointer(&output.data[0])) = *dest
+	case *big.Rat, *Decimal:
+		var value *big.Rat
+		if rat, ok := dest.(*big.Rat); ok {
+			value = rat
+		} else {
+			value = dest.(*Decimal).Rat()
+			if parameter.Scale == 0 {
+				parameter.Scale = dest.(*Decimal).Scale()
+			}
+		}
+		columnSize, decimalDigits = parameter.Precision, parameter.Scale
+		if columnSize == 0 {
+			columnSize = defaultOutputPrecision
+		}
+		output.cType, sqlType = odbc.SQL_C_NUMERIC, odbc.SQL_DECIMAL
+		output.data = alignedBuffer(int(unsafe.Sizeof(odbc.SQL_NUMERIC_STRUCT{})))
+		if direction == InputOutputParameter {
+			numeric, err := ratToNumeric(value, columnSize, decimalDigits)
+			if err != nil {
+				return fmt.Errorf("Bind index: %v, %v", index, err)
+			}
+			*(*odbc.SQL_NUMERIC_STRUCT)(unsafe.Pointer(&output.data[0])) = numeric
+		}
+	case *time.Time:
+		binding := timeParameterBinding(parameter)
+		output.cType, sqlType, columnSize, decimalDigits = binding.cType, binding.sqlType, binding.columnSize, binding.decimalDigits
+		output.data = alignedBuffer(timeStructSize(binding.cType))
+		output.location = stmt.conn.location
+		binding.write(unsafe.Pointer(&output.data[0]), *dest, output.location)
+	case *[]byte:
+		columnSize = parameter.Length
+		if columnSize == 0 {
+			columnSize = maxBoundBinaryLength
+		}
+		if direction == InputOutputParameter && len(*dest) > columnSize {
+			columnSize = len(*dest)
+		}
+		output.cType, sqlType, output.data = odbc.SQL_C_BINARY, odbc.SQL_VARBINARY, alignedBuffer(columnSize)
+		if columnSize > maxBoundBinaryLength {
+			sqlType = odbc.SQL_LONGVARBINARY
+		}
+		if *dest != nil {
+			input = *dest
+		}
+	case *string:
+		input = *dest
+	default:
+		//Other scanners, such as *sql.NullString, receive the value as a string
+		if _, ok := parameter.Data.(sql.Scanner); !ok {
+			return fmt.Errorf("Error binding parameter number: %v.  Output parameter type not supported: %T", index, parameter.Data)
+		}
+		input = nil
+		if valuer, ok := parameter.Data.(driver.Valuer); ok && direction == InputOutputParameter {
+			value, err := valuer.Value()
+			if err != nil {
+				return err
+			}
+			if value != nil {
+				input = fmt.Sprint(value)
+			}
+		}
+	}
+
+	//Strings are bound as SQL_C_WCHAR, sized for the input value if it is longer than Length
+	if output.cType == 0 {
+		columnSize = parameter.Length
+		if columnSize == 0 {
+			columnSize = maxBoundStringLength
+		}
+		var encoded []uint16
+		if value, ok := input.(string); ok && direction == InputOutputParameter {
+			encoded = utf16.Encode([]rune(value))
+			if len(encoded) > columnSize {
+				columnSize = len(encoded)
+			}
+			input = encoded
+		} else {
+			input = nil
+		}
+		output.cType, sqlType, output.data = odbc.SQL_C_WCHAR, odbc.SQL_VARCHAR, alignedBuffer((columnSize+1)*2)
+		if columnSize > maxBoundStringLength {
+			sqlType = odbc.SQL_LONGVARCHAR
+		}
+		copy(unsafe.Slice((*uint16)(unsafe.Pointer(&output.data[0])), columnSize), encoded)
+	}
+
+	//Set the indicator for the input value
+	switch {
+	case direction != InputOutputParameter:
+		output.ind = 0
+	case input == nil && (output.cType == odbc.SQL_C_WCHAR || output.cType == odbc.SQL_C_BINARY):
+		output.ind = odbc.SQL_NULL_DATA
+	case output.cType == odbc.SQL_C_WCHAR:
+		output.ind = odbc.SQLLEN(len(input.([]uint16)) * 2)
+	case output.cType == odbc.SQL_C_BINARY:
+		output.ind = odbc.SQLLEN(len(input.([]byte)))
+		copy(output.data, input.([]byte))
+	default:
+		output.ind = odbc.SQLLEN(len(output.data))
+	}
+
+	stmt.bindValues[index] = output
+	stmt.outputParams = append(stmt.outputParams, output)
+	dataPtr := unsafe.Pointer(&output.data[0])
+	ret := stmt.api.SQLBindParameter(stmt.handle, odbc.SQLUSMALLINT(index), direction.SQLBindParameterType(), output.cType, sqlType, odbc.SQLULEN(columnSize), odbc.SQLSMALLINT(decimalDigits), dataPtr, odbc.SQLLEN(len(output.data)), &output.ind)
+	if isError(ret) {
+		return errorStatement(stmt.api, stmt.handle, fmt.Sprintf("Bind index: %v, Output: %T", index, parameter.Data))
+	}
+
+	if output.cType == odbc.SQL_C_NUMERIC {
+		return setNumericDescriptor(stmt.api, stmt.stmtDescHandle, index, columnSize, decimalDigits, dataPtr, fmt.Sprintf("Bind index: %v, Output: %T", index, parameter.Data))
+	}
+	return nil
+}
+
+// Copies the values the driver wrote to the output parameters to their destinations.
+// ODBC only returns them after SQLMoreResults has returned SQL_NO_DATA.
+func writeOutputParameters(outputs []*outputParameter) error {
+	for _, output := range outputs {
+		err := output.write()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Copies the value of the output parameter to its destination -- NULL sets the zero value
+func (output *outputParameter) write() error {
+	isNull := output.ind == odbc.SQL_NULL_DATA
+	p := unsafe.Pointer(&output.data[0])
+	if !isNull && (output.cType == odbc.SQL_C_WCHAR && int(output.ind) > len(output.data)-2 || output.cType == odbc.SQL_C_BINARY && int(output.ind) > len(output.data) || output.ind < 0) {
+		return fmt.Errorf("Output parameter %v was truncated: %v bytes do not fit the %v byte buffer -- set a larger BindParameter.Length", output.index, output.ind, len(output.data))
+	}
+
+	switch dest := output.dest.(type) {
+	case *bool:
+		*dest = !isNull && output.data[0] != 0
+	case *int:
+		*dest = 0
+		if !isNull {
+			*dest = int(*(*int64)(p))
+		}
+	case *int64:
+		*dest = 0
+		if !isNull {
+			*dest = *(*int64)(p)
+		}
+	case *int32:
+		*dest = 0
+		if !isNull {
+			*dest = *(*int32)(p)
+		}
+	case *float64:
+		*dest = 0
+		if !isNull {
+			*dest = *(*float64)(p)
+		}
+	case *big.Rat:
+		dest.SetInt64(0)
+		if !isNull {
+			dest.Set(numericToRat(*(*odbc.SQL_NUMERIC_STRUCT)(p)))
+		}
+	case *Decimal:
+		*dest = Decimal{}
+		if !isNull {
+			return dest.Scan(numericToString(*(*odbc.SQL_NUMERIC_STRUCT)(p)))
+		}
+	case *time.Time:
+		*dest = time.Time{}
+		if !isNull {
+			*dest = readTime(output.cType, p, output.location)
+		}
+	case *[]byte:
+		*dest = nil
+		if !isNull {
+			*dest = append([]byte{}, output.data[:output.ind]...)
+		}
+	case *string:
+		*dest = ""
+		if !isNull {
+			*dest = string(utf16.Decode(unsafe.Slice((*uint16)(p), int(output.ind)/2)))
+		}
+	case sql.Scanner:
+		if isNull {
+			return dest.Scan(nil)
+		}
+		return dest.Scan(string(utf16.Decode(unsafe.Slice((*uint16)(p), int(output.ind)/2))))
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package lodbc_test
+
+import (
+	"bytes"
+	"database/sql"
+	"github.com/LukeMauldin/lodbc"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"github.com/LukeMauldin/lodbc/odbc/fake"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutputParametersReceiveTheirValues(t *testing.T) {
+	created := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+	dm := fake.New()
+	dm.SetResponse("{call get_order(?, ?, ?, ?, ?, ?, ?, ?, ?)}", &fake.Response{
+		OutputParams: []interface{}{int64(42), "shipped", true, 12.5, created, []byte{1, 2}, big.NewRat(1234, 100), "note", nil},
+	})
+	db := openDB(t, dm, nil)
+
+	var id int64
+	var status string
+	var paid bool
+	var weight float64
+	var when time.Time
+	var data []byte
+	var total lodbc.Decimal
+	var note sql.NullString
+	var missing string
+	_, err := db.Exec("{call get_order(?, ?, ?, ?, ?, ?, ?, ?, ?)}", sql.Out{Dest: &id}, sql.Out{Dest: &status}, sql.Out{Dest: &paid}, sql.Out{Dest: &weight},
+		sql.Out{Dest: &when}, sql.Out{Dest: &data}, sql.Out{Dest: &lodbc.BindParameter{Data: &total, Precision: 10, Scale: 2}}, sql.Out{Dest: &note}, sql.Out{Dest: &missing})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 42 || status != "shipped" || !paid || weight != 12.5 || !when.Equal(created) || !bytes.Equal(data, []byte{1, 2}) {
+		t.Errorf("output parameters %v, %q, %v, %v, %v, %v", id, status, paid, weight, when, data)
+	}
+	if total.String() != "12.34" || !note.Valid || note.String != "note" || missing != "" {
+		t.Errorf("output parameters %v, %+v, %q", total, note, missing)
+	}
+	if params := dm.Executions()[0].Params; len(params) != 9 || params[0] != nil || params[1] != nil {
+		t.Errorf("output parameters sent the values %v", params)
+	}
+}
+
+func TestNullOutputParametersWriteTheZeroValue(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("{call nothing(?, ?, ?, ?, ?, ?)}", &fake.Response{OutputParams: []interface{}{nil, nil, nil, nil, nil, nil}})
+	db := openDB(t, dm, nil)
+
+	id := int64(7)
+	status := "old"
+	paid := true
+	when := time.Now()
+	data := []byte{1}
+	total := big.NewRat(5, 1)
+	_, err := db.Exec("{call nothing(?, ?, ?, ?, ?, ?)}", sql.Out{Dest: &id}, sql.Out{Dest: &status}, sql.Out{Dest: &paid}, sql.Out{Dest: &when},
+		sql.Out{Dest: &data}, sql.Out{Dest: total})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 0 || status != "" || paid || !when.IsZero() || data != nil || total.Sign() != 0 {
+		t.Errorf("NULL output parameters wrote %v, %q, %v, %v, %v, %v", id, status, paid, when, data, total)
+	}
+}
+
+func TestInputOutputParametersSendAndReceive(t *testing.T) {
+	dm := fake.New()
+	var sent []interface{}
+	dm.HandleQuery("{call update_order(?, ?, ?)}", func(e *fake.Execution) *fake.Response {
+		sent = e.Params
+		return &fake.Response{OutputParams: []interface{}{int64(8), strings.ToUpper(e.Params[1].(string)), big.NewRat(1, 8)}}
+	})
+	db := openDB(t, dm, nil)
+
+	//The string is longer than Length, so its buffer grows to hold it; the decimal is bound with its own scale
+	count := int64(7)
+	name := "a name longer than its length"
+	total, err := lodbc.ParseDecimal("12.345")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec("{call update_order(?, ?, ?)}", sql.Out{Dest: &count, In: true}, sql.Out{Dest: &lodbc.BindParameter{Data: &name, Length: 5}, In: true},
+		sql.Out{Dest: &total, In: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 3 || sent[0] != int64(7) || sent[1] != "a name longer than its length" || sent[2].(*big.Rat).Cmp(big.NewRat(12345, 1000)) != 0 {
+		t.Errorf("input/output parameters sent %v", sent)
+	}
+	if count != 8 || name != "A NAME LONGER THAN ITS LENGTH" || total.String() != "0.125" {
+		t.Errorf("input/output parameters received %v, %q, %v", count, name, total)
+	}
+}
+
+func TestReturnValueParameter(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("{? = call close_order(?)}", &fake.Response{OutputParams: []interface{}{int64(3)}})
+	db := openDB(t, dm, nil)
+
+	var status int32
+	_, err := db.Exec("{? = call close_order(?)}", sql.Out{Dest: &lodbc.BindParameter{Data: &status, Direction: lodbc.ReturnValueParameter}}, int64(11))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != 3 {
+		t.Errorf("return value %v, want 3", status)
+	}
+	execution := dm.Executions()[0]
+	if params := execution.Params; len(params) != 2 || params[0] != nil || params[1] != int64(11) {
+		t.Errorf("parameters %v", params)
+	}
+
+	//SQLBindParameter only accepts input, input/output and output, so the return value is bound as an output
+	if types := execution.ParamTypes; types[0].InputOutputType != odbc.SQL_PARAM_OUTPUT || types[1].InputOutputType != odbc.SQL_PARAM_INPUT {
+		t.Errorf("parameters bound as %+v", types)
+	}
+}
+
+func TestTruncatedOutputParametersFail(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("{call long_values(?, ?)}", &fake.Response{OutputParams: []interface{}{"more than five characters", []byte("ok")}})
+	dm.SetResponse("{call long_bytes(?)}", &fake.Response{OutputParams: []interface{}{[]byte("more than five bytes")}})
+	db := openDB(t, dm, nil)
+
+	var text string
+	var data []byte
+	_, err := db.Exec("{call long_values(?, ?)}", sql.Out{Dest: &lodbc.BindParameter{Data: &text, Length: 5}}, sql.Out{Dest: &data})
+	if err == nil || !strings.Contains(err.Error(), "Output parameter 1 was truncated") {
+		t.Errorf("got %v, want a truncation error for parameter 1", err)
+	}
+	_, err = db.Exec("{call long_bytes(?)}", sql.Out{Dest: &lodbc.BindParameter{Data: &data, Length: 5}})
+	if err == nil || !strings.Contains(err.Error(), "Output parameter 1 was truncated") {
+		t.Errorf("got %v, want a truncation error for parameter 1", err)
+	}
+}
@@ -12,6 +12,15 @@ type QueryOptionKey int
 const (
 	//Result set number to read when queries return multiple result sets
 	ResultSetNum QueryOptionKey = iota
+
+	//DecimalFormat to return NUMERIC and DECIMAL columns in, overriding the connection's format
+	DecimalResultFormat
+
+	//Number of rows to fetch per round trip, overriding the connection's rowset size
+	RowsetSize
+
+	//Bytes read per SQLGetData call when streaming LOB columns as *LOB, overriding the connection's LOB chunk size
+	LOBChunkSize
 )
 
 // Identifier to add in SQL query to indiciate start/end of options
@@ -0,0 +1,108 @@
+package lodbc
+
+import (
+	"fmt"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"unsafe"
+)
+
+// Implements type database/sql/driver Result interface
+type result struct {
+	// Rows affected, summed over every result of the statement that does not return rows
+	rowsAffected int64
+
+	// Value returned by the last insert id query run in the same batch
+	lastInsertId      int64
+	hasLastInsertId   bool
+	lastInsertIdQuery string
+
+	// Informational messages returned by the statement
+	messages []StatusRecord
+}
+
+// Returns the identity returned by the query set with SetLastInsertIdQuery or Config.LastInsertIdQuery
+func (r *result) LastInsertId() (int64, error) {
+	if !r.hasLastInsertId {
+		if len(r.lastInsertIdQuery) == 0 {
+			return 0, fmt.Errorf("LastInsertId is not available -- it requires an INSERT statement and a query set with SetLastInsertIdQuery or Config.LastInsertIdQuery")
+		}
+		return 0, fmt.Errorf("LastInsertId is not available -- %v returned no value", r.lastInsertIdQuery)
+	}
+	return r.lastInsertId, nil
+}
+
+// Returns the number of rows affected by the statement
+func (r *result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+// Walks every result of the executed statement with SQLMoreResults, summing the row counts
+func (stmt *statement) rowsAffected() (int64, error) {
+	total, _, _, err := stmt.readResults(false)
+	return total, err
+}
+
+// Walks every result of the executed statement with SQLMoreResults, summing the row counts.  With readLastInsertId,
+// also returns the first value of the last result with columns, which is the result of the last insert id query
+// appended to the statement, and false if it returned no value.
+func (stmt *statement) readResults(readLastInsertId bool) (int64, int64, bool, error) {
+	var total, lastInsertId int64
+	hasLastInsertId := false
+	for {
+		var rowCount odbc.SQLLEN
+		ret := stmt.api.SQLRowCount(stmt.handle, &rowCount)
+		if isError(ret) {
+			return 0, 0, false, errorStatement(stmt.api, stmt.handle, stmt.sqlStmt)
+		}
+
+		//Drivers report -1 for results that return rows
+		if rowCount > 0 {
+			total += int64(rowCount)
+		}
+
+		if readLastInsertId {
+			var numColumns odbc.SQLSMALLINT
+			ret = stmt.api.SQLNumResultCols(stmt.handle, &numColumns)
+			if isError(ret) {
+				return 0, 0, false, errorStatement(stmt.api, stmt.handle, stmt.sqlStmt)
+			}
+			if numColumns > 0 {
+				var err error
+				lastInsertId, hasLastInsertId, err = stmt.readLastInsertId()
+				if err != nil {
+					return 0, 0, false, err
+				}
+			}
+		}
+
+		ret = stmt.api.SQLMoreResults(stmt.handle)
+		if ret == odbc.SQL_NO_DATA {
+			return total, lastInsertId, hasLastInsertId, nil
+		}
+		if isError(ret) {
+			return 0, 0, false, errorStatement(stmt.api, stmt.handle, stmt.sqlStmt)
+		}
+		stmt.collectMessages(ret)
+	}
+}
+
+// Reads the first column of the first row of the current result as an integer, returning false if it has no rows or is NULL
+func (stmt *statement) readLastInsertId() (int64, bool, error) {
+	ret := stmt.api.SQLFetch(stmt.handle)
+	if ret == odbc.SQL_NO_DATA {
+		return 0, false, nil
+	} else if isError(ret) {
+		return 0, false, errorStatement(stmt.api, stmt.handle, stmt.sqlStmt)
+	}
+
+	var value int64
+	var ind odbc.SQLLEN
+	ret = stmt.api.SQLGetData(stmt.handle, 1, odbc.SQL_C_SBIGINT, unsafe.Pointer(&value), 0, &ind)
+	if isError(ret) {
+		return 0, false, errorStatement(stmt.api, stmt.handle, fmt.Sprintf("%v\nLast insert id query: %v", stmt.sqlStmt, stmt.conn.lastInsertIdQuery))
+	}
+	if ind == odbc.SQL_NULL_DATA {
+		return 0, false, nil
+	}
+	return value, true, nil
+}
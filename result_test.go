@@ -0,0 +1,136 @@
+package lodbc_test
+
+import (
+	"github.com/LukeMauldin/lodbc"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"github.com/LukeMauldin/lodbc/odbc/fake"
+	"testing"
+)
+
+func TestLastInsertIdRunsInTheSameBatch(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("insert into t (name) values (?);\nSELECT SCOPE_IDENTITY()", &fake.Response{ResultSets: []*fake.ResultSet{
+		{RowsAffected: 1},
+		{Columns: []fake.Column{{Name: "", Type: odbc.SQL_NUMERIC, Precision: 38}}, Rows: [][]interface{}{{"42"}}},
+	}})
+	dm.SetResponse("update t set name = ?", &fake.Response{ResultSets: []*fake.ResultSet{{RowsAffected: 2}}})
+	db := openDB(t, dm, func(config *lodbc.Config) {
+		config.LastInsertIdQuery = "SELECT SCOPE_IDENTITY()"
+	})
+
+	//Every execution of a prepared INSERT runs the query with SQLExecute
+	stmt, err := db.Prepare("insert into t (name) values (?)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+	for i := 0; i < 2; i++ {
+		res, err := stmt.Exec("name")
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil || id != 42 {
+			t.Errorf("LastInsertId returned %v, %v", id, err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil || affected != 1 {
+			t.Errorf("RowsAffected returned %v, %v", affected, err)
+		}
+	}
+	executions := dm.Executions()
+	if len(executions) != 2 || !executions[0].Prepared || !executions[1].Prepared || executions[1].Params[0] != "name" {
+		t.Errorf("executions %+v", executions)
+	}
+
+	//Other statements run without the query
+	res, err := db.Exec("update t set name = ?", "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := res.LastInsertId(); err == nil {
+		t.Error("LastInsertId of an UPDATE succeeded")
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected != 2 {
+		t.Errorf("RowsAffected returned %v, %v", affected, err)
+	}
+}
+
+func TestPreparedInsertsAreNotPreparedAgainForQueryAndExec(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("insert into t output inserted.id values (?)", &fake.Response{ResultSets: []*fake.ResultSet{
+		{Columns: []fake.Column{{Name: "id", Type: odbc.SQL_INTEGER}}, Rows: [][]interface{}{{7}}},
+	}})
+	dm.SetResponse("insert into t output inserted.id values (?);\nSELECT SCOPE_IDENTITY()", &fake.Response{ResultSets: []*fake.ResultSet{
+		{Columns: []fake.Column{{Name: "id", Type: odbc.SQL_INTEGER}}, Rows: [][]interface{}{{7}}},
+		{Columns: []fake.Column{{Name: "", Type: odbc.SQL_INTEGER}}, Rows: [][]interface{}{{7}}},
+	}})
+	db := openDB(t, dm, func(config *lodbc.Config) {
+		config.LastInsertIdQuery = "SELECT SCOPE_IDENTITY()"
+	})
+	db.SetMaxOpenConns(1)
+
+	//Query runs the statement's own text and Exec the text with the query appended, each prepared once
+	stmt, err := db.Prepare("insert into t output inserted.id values (?)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+	for i := 0; i < 3; i++ {
+		var id int
+		if err := stmt.QueryRow("name").Scan(&id); err != nil || id != 7 {
+			t.Fatalf("QueryRow returned %v, %v", id, err)
+		}
+		res, err := stmt.Exec("name")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id, err := res.LastInsertId(); err != nil || id != 7 {
+			t.Errorf("LastInsertId returned %v, %v", id, err)
+		}
+	}
+	if prepares := countCalls(dm, "SQLPrepare"); prepares != 2 {
+		t.Errorf("%v SQLPrepare calls, want one for each text", prepares)
+	}
+	for _, execution := range dm.Executions() {
+		if !execution.Prepared {
+			t.Errorf("%q was not executed with SQLExecute", execution.Query)
+		}
+	}
+
+	//Closing the statement frees both handles
+	stmt.Close()
+	if open := dm.OpenHandles(odbc.SQL_HANDLE_STMT); open != 0 {
+		t.Errorf("%v statement handles open after Close", open)
+	}
+}
+
+func TestLastInsertIdSkipsLeadingComments(t *testing.T) {
+	const insert = "-- add a row\n/* audited */ insert into t (name) values (?)"
+	dm := fake.New()
+	dm.SetResponse(insert+";\nSELECT SCOPE_IDENTITY()", &fake.Response{ResultSets: []*fake.ResultSet{
+		{RowsAffected: 1},
+		{Columns: []fake.Column{{Name: "", Type: odbc.SQL_INTEGER}}, Rows: [][]interface{}{{5}}},
+	}})
+	dm.SetResponse("SET NOCOUNT ON; insert into t (name) values (?)", &fake.Response{ResultSets: []*fake.ResultSet{{RowsAffected: 1}}})
+	db := openDB(t, dm, func(config *lodbc.Config) {
+		config.LastInsertIdQuery = "SELECT SCOPE_IDENTITY()"
+	})
+
+	res, err := db.Exec(insert, "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := res.LastInsertId(); err != nil || id != 5 {
+		t.Errorf("LastInsertId returned %v, %v", id, err)
+	}
+
+	//An INSERT after another statement is not recognized, and LastInsertId says so rather than returning 0
+	res, err = db.Exec("SET NOCOUNT ON; insert into t (name) values (?)", "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := res.LastInsertId(); err == nil {
+		t.Error("LastInsertId succeeded without running the query")
+	}
+}
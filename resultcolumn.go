@@ -2,7 +2,6 @@ package lodbc
 
 import (
 	"github.com/LukeMauldin/lodbc/odbc"
-	"syscall"
 	"unsafe"
 )
 
@@ -12,34 +11,45 @@ type resultColumnDef struct {
 	DataType  odbc.SQLDataType
 	Precision odbc.SQLLEN
 	Scale     odbc.SQLLEN
+	Length    odbc.SQLLEN
 	Name      string
+
+	// Data source type name, octet length and nullability (SQL_NO_NULLS, SQL_NULLABLE or SQL_NULLABLE_UNKNOWN)
+	TypeName    string
+	OctetLength odbc.SQLLEN
+	Nullable    odbc.SQLLEN
+
+	// Table and column the result column is read from -- empty for expressions or if the driver does not report them
+	BaseTableName  string
+	BaseColumnName string
 }
 
-// Build metadata for each result column
-func buildResultColumnDefinitions(stmtHandle odbc.SQLHandle, sqlStmt string) ([]resultColumnDef, odbc.SQLReturn) {
+// Build metadata for each result column.  Returns the first failing SQLReturn of the required attributes;
+// the optional ones keep their defaults when the driver does not report them.
+func buildResultColumnDefinitions(api odbc.API, stmtHandle odbc.SQLHandle) ([]resultColumnDef, odbc.SQLReturn) {
 
 	//Get number of result columns
 	var numColumns odbc.SQLSMALLINT
-	ret := odbc.SQLNumResultCols(stmtHandle, &numColumns)
+	ret := api.SQLNumResultCols(stmtHandle, &numColumns)
 	if isError(ret) {
-		errorStatement(stmtHandle, sqlStmt)
+		return nil, ret
 	}
 
 	resultColumnDefs := make([]resultColumnDef, 0, numColumns)
 	for colNum, lNumColumns := odbc.SQLSMALLINT(1), numColumns; colNum <= lNumColumns; colNum++ {
 		//Get odbc.SQL type
 		var sqlType odbc.SQLLEN
-		ret := odbc.SQLColAttribute(stmtHandle, odbc.SQLUSMALLINT(colNum), odbc.SQL_COLUMN_TYPE, 0, 0, nil, &sqlType)
+		ret := api.SQLColAttribute(stmtHandle, odbc.SQLUSMALLINT(colNum), odbc.SQL_COLUMN_TYPE, nil, 0, nil, &sqlType)
 		if isError(ret) {
-			errorStatement(stmtHandle, sqlStmt)
+			return nil, ret
 		}
 
 		/* Disabled because it is no longer needed
 		//Get length
 		var length odbc.SQLLEN
-		ret = odbc.SQLColAttribute(stmtHandle, odbc.SQLUSMALLINT(colNum), odbc.SQL_COLUMN_LENGTH, 0, 0, nil, &length)
+		ret = api.SQLColAttribute(stmtHandle, odbc.SQLUSMALLINT(colNum), odbc.SQL_COLUMN_LENGTH, nil, 0, nil, &length)
 		if isError(ret) {
-			errorStatement(stmtHandle, sqlStmt)
+			errorStatement(api, stmtHandle, sqlStmt)
 		}
 
 		//If the type is a CHAR or VARCHAR, add 4 to the length
@@ -48,35 +58,67 @@ func buildResultColumnDefinitions(stmtHandle odbc.SQLHandle, sqlStmt string) ([]
 		} */
 
 		//Get name
-		const namelength = 1000
-		nameArr := make([]uint16, namelength)
-		ret = odbc.SQLColAttribute(stmtHandle, odbc.SQLUSMALLINT(colNum), odbc.SQL_DESC_LABEL, uintptr(unsafe.Pointer(&nameArr[0])), namelength, nil, nil)
+		name, ret := colAttributeString(api, stmtHandle, colNum, odbc.SQL_DESC_LABEL)
 		if isError(ret) {
-			errorStatement(stmtHandle, sqlStmt)
+			return nil, ret
 		}
-		name := syscall.UTF16ToString(nameArr)
 
 		//For numeric and decimal types, get the precision
 		var precision odbc.SQLLEN
 		if odbc.SQLDataType(sqlType) == odbc.SQL_NUMERIC || odbc.SQLDataType(sqlType) == odbc.SQL_DECIMAL {
-			ret = odbc.SQLColAttribute(stmtHandle, odbc.SQLUSMALLINT(colNum), odbc.SQL_COLUMN_PRECISION, 0, 0, nil, &precision)
+			ret = api.SQLColAttribute(stmtHandle, odbc.SQLUSMALLINT(colNum), odbc.SQL_COLUMN_PRECISION, nil, 0, nil, &precision)
 			if isError(ret) {
-				errorStatement(stmtHandle, sqlStmt)
+				return nil, ret
 			}
 		}
 
 		//For numeric and decimal types, get the scale
 		var scale odbc.SQLLEN
 		if odbc.SQLDataType(sqlType) == odbc.SQL_NUMERIC || odbc.SQLDataType(sqlType) == odbc.SQL_DECIMAL {
-			ret = odbc.SQLColAttribute(stmtHandle, odbc.SQLUSMALLINT(colNum), odbc.SQL_COLUMN_SCALE, 0, 0, nil, &scale)
+			ret = api.SQLColAttribute(stmtHandle, odbc.SQLUSMALLINT(colNum), odbc.SQL_COLUMN_SCALE, nil, 0, nil, &scale)
+			if isError(ret) {
+				return nil, ret
+			}
+		}
+
+		//For string and binary types, get the length in characters or bytes -- 0 for unbounded (max) columns
+		var length odbc.SQLLEN
+		switch odbc.SQLDataType(sqlType) {
+		case odbc.SQL_CHAR, odbc.SQL_VARCHAR, odbc.SQL_WCHAR, odbc.SQL_WVARCHAR, odbc.SQL_BINARY, odbc.SQL_VARBINARY,
+			odbc.SQL_LONGVARCHAR, odbc.SQL_WLONGVARCHAR, odbc.SQL_LONGVARBINARY, odbc.SQL_SS_XML:
+			ret = api.SQLColAttribute(stmtHandle, odbc.SQLUSMALLINT(colNum), odbc.SQLColAttributeType(odbc.SQL_DESC_LENGTH), nil, 0, nil, &length)
 			if isError(ret) {
-				errorStatement(stmtHandle, sqlStmt)
+				return nil, ret
 			}
 		}
 
-		col := resultColumnDef{RecNum: colNum, DataType: odbc.SQLDataType(sqlType), Name: name, Precision: precision, Scale: scale}
+		//Get the optional metadata -- not every driver reports it, so failures leave the defaults
+		col := resultColumnDef{RecNum: colNum, DataType: odbc.SQLDataType(sqlType), Name: name, Precision: precision, Scale: scale, Length: length, Nullable: odbc.SQL_NULLABLE_UNKNOWN}
+		if typeName, ret := colAttributeString(api, stmtHandle, colNum, odbc.SQL_DESC_TYPE_NAME); !isError(ret) {
+			col.TypeName = typeName
+		}
+		if ret := api.SQLColAttribute(stmtHandle, odbc.SQLUSMALLINT(colNum), odbc.SQLColAttributeType(odbc.SQL_DESC_OCTET_LENGTH), nil, 0, nil, &col.OctetLength); isError(ret) {
+			col.OctetLength = 0
+		}
+		if ret := api.SQLColAttribute(stmtHandle, odbc.SQLUSMALLINT(colNum), odbc.SQLColAttributeType(odbc.SQL_DESC_NULLABLE), nil, 0, nil, &col.Nullable); isError(ret) {
+			col.Nullable = odbc.SQL_NULLABLE_UNKNOWN
+		}
+		if tableName, ret := colAttributeString(api, stmtHandle, colNum, odbc.SQL_DESC_BASE_TABLE_NAME); !isError(ret) {
+			col.BaseTableName = tableName
+		}
+		if columnName, ret := colAttributeString(api, stmtHandle, colNum, odbc.SQL_DESC_BASE_COLUMN_NAME); !isError(ret) {
+			col.BaseColumnName = columnName
+		}
 		resultColumnDefs = append(resultColumnDefs, col)
 	}
 
 	return resultColumnDefs, odbc.SQL_SUCCESS
 }
+
+// Returns a string attribute of a result column
+func colAttributeString(api odbc.API, stmtHandle odbc.SQLHandle, colNum odbc.SQLSMALLINT, field odbc.SQLColAttributeType) (string, odbc.SQLReturn) {
+	const namelength = 1000
+	nameArr := make([]uint16, namelength)
+	ret := api.SQLColAttribute(stmtHandle, odbc.SQLUSMALLINT(colNum), field, unsafe.Pointer(&nameArr[0]), namelength, nil, nil)
+	return utf16ToString(nameArr), ret
+}
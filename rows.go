@@ -6,14 +6,16 @@ import (
 	"github.com/LukeMauldin/lodbc/odbc"
 	"io"
 	"runtime"
-	"strings"
-	"syscall"
+	"sync"
 	"time"
 	"unsafe"
 )
 
 // Implements type database/sql/driver Rows interface
 type rows struct {
+	// ODBC API used by the rows
+	api odbc.API
+
 	// Statement handle
 	handle odbc.SQLHandle
 
@@ -37,6 +39,54 @@ type rows struct {
 
 	// Result column names
 	resultColumnNames []string
+
+	// Format NUMERIC and DECIMAL columns are returned in
+	decimalFormat DecimalFormat
+
+	// Location DATE, TIME and TIMESTAMP values are returned in
+	location *time.Location
+
+	// Number of rows to fetch per round trip, 0 to read every value with SQLGetData
+	rowsetSize int
+
+	// Columns fetched into bound buffers, nil for columns read with SQLGetData
+	boundColumns []*boundColumn
+
+	// Decoders of the columns of the current result set, nil for columns of unknown types
+	columnDecoders []*ColumnDecoder
+
+	// Bytes read per SQLGetData call for LOB columns, and the C type the last column of the
+	// current result set is streamed with as a *LOB -- 0 if it is read whole
+	lobChunkSize  int
+	lobColumnType odbc.CDataType
+
+	// Counts the rows and result sets moved to, so a LOB can tell whether its row is still current
+	rowNumber int64
+
+	// Rowset size in use, rows in the current rowset, their status and the position within them
+	arraySize   int
+	rowsFetched odbc.SQLULEN
+	rowStatus   []odbc.SQLUSMALLINT
+	rowsetPos   int
+
+	// Statement closed along with the rows -- set for queries run directly on the connection
+	ownedStmt *statement
+
+	// Output parameters written back when the rows are closed
+	outputParams []*outputParameter
+
+	// Every result of the statement has been read -- there is no cursor to fetch from or close
+	isExhausted bool
+
+	// Row counts of the results without columns skipped to reach the current result set
+	rowsAffected []int64
+
+	// Owning connection, which receives informational messages, and the messages returned so far
+	conn     *connection
+	messages []StatusRecord
+
+	// Held while fetching and closing, so Close from a finalizer or another goroutine waits for a fetch in progress
+	mu sync.Mutex
 }
 
 // Returns the names of the columns
@@ -46,15 +96,41 @@ func (rows *rows) Columns() []string {
 
 // Next is called to populate the next row of data into the provided slice
 func (rows *rows) Next(dest []driver.Value) error {
+	rows.mu.Lock()
+	defer rows.mu.Unlock()
+	if rows.isClosed {
+		return io.EOF
+	}
+	return rows.conn.checkLink(rows.next(dest))
+}
+
+// Fetches the next row into dest
+func (rows *rows) next(dest []driver.Value) error {
+	rows.rowNumber++
+
+	//Results without columns have no rows
+	if rows.isExhausted || len(rows.resultColumnDefs) == 0 {
+		return io.EOF
+	}
+
 	//If this is the first time rows has been read, setup necessary field level information
 	if rows.isBeforeFirst {
+		//Bind the columns that can be fetched a rowset at a time
+		err := rows.bindColumns()
+		if err != nil {
+			return err
+		}
+
 		for index, resultColumnDef := range rows.resultColumnDefs {
-			//Set precision and scale for numeric fields
-			if resultColumnDef.DataType == odbc.SQL_NUMERIC || resultColumnDef.DataType == odbc.SQL_DECIMAL {
-				colIndex := odbc.SQLSMALLINT(index + 1)
-				odbc.SQLSetDescField(rows.descHandle, colIndex, odbc.SQL_DESC_TYPE, uintptr(odbc.SQL_C_NUMERIC), 0)
-				odbc.SQLSetDescField(rows.descHandle, colIndex, odbc.SQL_DESC_PRECISION, uintptr(resultColumnDef.Precision), 0)
-				odbc.SQLSetDescField(rows.descHandle, colIndex, odbc.SQL_DESC_SCALE, uintptr(resultColumnDef.Scale), 0)
+			//Set precision and scale for numeric fields read with SQLGetData
+			if rows.boundColumns != nil && rows.boundColumns[index] != nil {
+				continue
+			}
+			if decoder := rows.columnDecoders[index]; decoder != nil && decoder.CType == odbc.SQL_C_NUMERIC {
+				err := setNumericDescriptor(rows.api, rows.descHandle, index+1, int(resultColumnDef.Precision), int(resultColumnDef.Scale), nil, fmt.Sprintf("%v\nColumn %v", rows.sqlStmt, resultColumnDef.Name))
+				if err != nil {
+					return err
+				}
 			}
 		}
 
@@ -62,14 +138,26 @@ func (rows *rows) Next(dest []driver.Value) error {
 		rows.isBeforeFirst = false
 	}
 
+	//Fetch from the bound rowset
+	if rows.boundColumns != nil {
+		found, err := rows.nextBoundRow()
+		if err != nil {
+			return err
+		} else if !found {
+			return io.EOF
+		}
+		return rows.getRow(dest)
+	}
+
 	//Fetch a row of data
-	ret := odbc.SQLFetch(rows.handle)
+	ret := rows.api.SQLFetch(rows.handle)
 	if ret == odbc.SQL_NO_DATA {
 		//No more data to read
 		return io.EOF
 	} else if isError(ret) {
-		return errorStatement(rows.handle, rows.sqlStmt)
+		return errorStatement(rows.api, rows.handle, rows.sqlStmt)
 	}
+	rows.collectMessages(ret)
 
 	//Get a row of data
 	err := rows.getRow(dest)
@@ -80,18 +168,63 @@ func (rows *rows) Next(dest []driver.Value) error {
 	return nil
 }
 
-// Close closes the rows iterator
+// Close closes the rows iterator, and the statement owned by the rows
 func (rows *rows) Close() error {
+	//Keep the connection from disconnecting while the cursor is closed -- once it has, the cursor was freed with it
+	var err error
+	rows.conn.handleMu.RLock()
+	if rows.conn.handle == 0 {
+		rows.abandon()
+	} else {
+		err = rows.closeRows()
+	}
+	rows.conn.handleMu.RUnlock()
+
+	//Close the statement owned by the rows
+	rows.mu.Lock()
+	ownedStmt := rows.ownedStmt
+	rows.ownedStmt = nil
+	rows.mu.Unlock()
+	if ownedStmt != nil {
+		if closeErr := ownedStmt.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	// Return any error
+	if err != nil {
+		return rows.conn.checkLink(err)
+	}
+
+	return nil
+}
+
+// Closes the cursor and releases the bound buffers, leaving the statement open
+func (rows *rows) closeRows() error {
+	rows.mu.Lock()
+	defer rows.mu.Unlock()
+
 	//Verify that rows has not already been closed
 	if rows.isClosed {
 		return nil
 	}
 
-	//Close the cursor
+	//Close the cursor -- with output parameters, consume the remaining results so the driver returns them
 	var err error
-	ret := odbc.SQLCloseCursor(rows.handle)
-	if isError(ret) {
-		err = errorStatement(rows.handle, rows.sqlStmt)
+	if rows.isExhausted {
+		//Output parameters were written when the last result was reached
+	} else if len(rows.outputParams) > 0 {
+		err = rows.readOutputParameters()
+	} else {
+		ret := rows.api.SQLCloseCursor(rows.handle)
+		if isError(ret) {
+			err = errorStatement(rows.api, rows.handle, rows.sqlStmt)
+		}
+	}
+
+	//Release the bound buffers
+	if unbindErr := rows.unbindColumns(); err == nil {
+		err = unbindErr
 	}
 
 	//Clear the finalizer
@@ -100,132 +233,301 @@ func (rows *rows) Close() error {
 	//Mark the rows as closed
 	rows.isClosed = true
 
-	// Return any error
+	return err
+}
+
+// Marks the rows closed without closing the cursor, which was freed when the connection disconnected
+func (rows *rows) abandon() {
+	rows.mu.Lock()
+	defer rows.mu.Unlock()
+	rows.boundColumns = nil
+	rows.isClosed = true
+	runtime.SetFinalizer(rows, nil)
+}
+
+// Implements driver.RowsNextResultSet -- ODBC cannot tell whether another result follows
+// without moving to it, so this is true until NextResultSet reaches the end
+func (rows *rows) HasNextResultSet() bool {
+	rows.mu.Lock()
+	defer rows.mu.Unlock()
+	return !rows.isExhausted && !rows.isClosed
+}
+
+// Implements driver.RowsNextResultSet -- moves to the next result with columns using SQLMoreResults.
+// The row counts of the results without columns skipped on the way are available from RowsAffected.
+func (rows *rows) NextResultSet() error {
+	rows.mu.Lock()
+	defer rows.mu.Unlock()
+	if rows.isClosed {
+		return io.EOF
+	}
+	return rows.conn.checkLink(rows.nextResultSet())
+}
+
+// Moves to the next result set with columns
+func (rows *rows) nextResultSet() error {
+	rows.rowNumber++
+	if rows.isExhausted {
+		return io.EOF
+	}
+
+	//The bound buffers only fit the columns of the current result set
+	err := rows.unbindColumns()
+	if err != nil {
+		return err
+	}
+	rows.rowsAffected = nil
+
+	ret := rows.api.SQLMoreResults(rows.handle)
+	if ret == odbc.SQL_NO_DATA {
+		err = rows.finishResults()
+		if err != nil {
+			return err
+		}
+		return io.EOF
+	} else if isError(ret) {
+		return errorStatement(rows.api, rows.handle, rows.sqlStmt)
+	}
+	rows.collectMessages(ret)
+
+	found, err := rows.skipResultsWithoutColumns()
 	if err != nil {
 		return err
+	} else if !found {
+		err = rows.finishResults()
+		if err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	return rows.loadResultSet()
+}
+
+// Returns the row counts of the results without columns, such as INSERT and UPDATE statements, that were
+// skipped to reach the current result set -- or after the last result set, the ones that followed it.
+// Available on the driver's rows, for example through sql.Conn.Raw.
+func (rows *rows) RowsAffected() []int64 {
+	rows.mu.Lock()
+	defer rows.mu.Unlock()
+	return rows.rowsAffected
+}
+
+// Skips results without columns, starting with the current one, recording their row counts.
+// Returns false if there are no more results.
+func (rows *rows) skipResultsWithoutColumns() (bool, error) {
+	for {
+		var numColumns odbc.SQLSMALLINT
+		ret := rows.api.SQLNumResultCols(rows.handle, &numColumns)
+		if isError(ret) {
+			return false, errorStatement(rows.api, rows.handle, rows.sqlStmt)
+		}
+		if numColumns > 0 {
+			return true, nil
+		}
+
+		var rowCount odbc.SQLLEN
+		ret = rows.api.SQLRowCount(rows.handle, &rowCount)
+		if !isError(ret) && rowCount >= 0 {
+			rows.rowsAffected = append(rows.rowsAffected, int64(rowCount))
+		}
+
+		ret = rows.api.SQLMoreResults(rows.handle)
+		if ret == odbc.SQL_NO_DATA {
+			return false, nil
+		} else if isError(ret) {
+			return false, errorStatement(rows.api, rows.handle, rows.sqlStmt)
+		}
+		rows.collectMessages(ret)
+	}
+}
+
+// Marks every result as read and writes the output parameters, which ODBC returns after the last result
+func (rows *rows) finishResults() error {
+	rows.isExhausted = true
+	rows.resultColumnDefs = nil
+	rows.columnDecoders = nil
+	rows.resultColumnNames = []string{}
+	return writeOutputParameters(rows.outputParams)
+}
+
+// Reads the column definitions of the current result set and resets the fetch state for it
+func (rows *rows) loadResultSet() error {
+	resultColumnDefs, ret := buildResultColumnDefinitions(rows.api, rows.handle)
+	if isError(ret) {
+		return errorStatement(rows.api, rows.handle, rows.sqlStmt)
 	}
 
+	//Make a slice of the column names
+	columnNames := make([]string, len(resultColumnDefs))
+	for index, resultCol := range resultColumnDefs {
+		columnNames[index] = fmt.Sprint(resultCol.Name)
+	}
+
+	rows.resultColumnDefs = resultColumnDefs
+	rows.resultColumnNames = columnNames
+	rows.columnDecoders = resultColumnDecoders(resultColumnDefs)
+	rows.lobColumnType = rows.lobColumn()
+	rows.isBeforeFirst = true
+	rows.rowsFetched = 0
+	rows.rowsetPos = -1
 	return nil
 }
 
+// Skips the remaining result sets with SQLMoreResults, then writes the output parameters
+func (rows *rows) readOutputParameters() error {
+	for {
+		ret := rows.api.SQLMoreResults(rows.handle)
+		if ret == odbc.SQL_NO_DATA {
+			break
+		} else if isError(ret) {
+			rows.api.SQLFreeStmt(rows.handle, odbc.SQL_CLOSE)
+			return errorStatement(rows.api, rows.handle, rows.sqlStmt)
+		}
+		rows.collectMessages(ret)
+	}
+	return writeOutputParameters(rows.outputParams)
+}
+
 // Get a single row of data by calling getField for each column
 func (rows *rows) getRow(dest []driver.Value) error {
 	for index, _ := range rows.resultColumnDefs {
-		fieldValue, ret := rows.getField(index + 1)
-		if isError(ret) {
-			return errorStatement(rows.handle, rows.sqlStmt)
+		//Decode bound columns from the rowset buffers
+		if rows.boundColumns != nil && rows.boundColumns[index] != nil {
+			fieldValue, err := rows.boundColumns[index].value(rows.rowsetPos, rows.convertContext())
+			if err != nil {
+				return err
+			}
+			dest[index] = fieldValue
+			continue
+		}
+
+		//Stream a LOB in the last column
+		if rows.lobColumnType != 0 && index == len(rows.resultColumnDefs)-1 {
+			fieldValue, err := rows.newLOB(index+1, rows.lobColumnType)
+			if err != nil {
+				return err
+			}
+			dest[index] = fieldValue
+			continue
+		}
+
+		fieldValue, err := rows.getField(index + 1)
+		if err != nil {
+			return err
 		}
 		dest[index] = fieldValue
 	}
 	return nil
 }
 
-// Return a single column of data
-func (rows *rows) getField(index int) (v interface{}, ret odbc.SQLReturn) {
-	columnDef := rows.resultColumnDefs[index-1]
-	var fieldInd odbc.SQLLEN
-	switch columnDef.DataType {
-	case odbc.SQL_BIT:
-		var value bool
-		valuePtr := uintptr(unsafe.Pointer(&value))
-		ret = odbc.SQLGetData(rows.handle, odbc.SQLUSMALLINT(index), odbc.SQL_C_BIT, valuePtr, 0, &fieldInd)
-		return formatGetFieldReturn(value, fieldInd, ret)
-	case odbc.SQL_INTEGER, odbc.SQL_SMALLINT, odbc.SQL_TINYINT:
-		var value int
-		valuePtr := uintptr(unsafe.Pointer(&value))
-		ret = odbc.SQLGetData(rows.handle, odbc.SQLUSMALLINT(index), odbc.SQL_C_LONG, valuePtr, 0, &fieldInd)
-		return formatGetFieldReturn(value, fieldInd, ret)
-	case odbc.SQL_BIGINT:
-		var value int64
-		valuePtr := uintptr(unsafe.Pointer(&value))
-		ret = odbc.SQLGetData(rows.handle, odbc.SQLUSMALLINT(index), odbc.SQL_C_LONG, valuePtr, 0, &fieldInd)
-		return formatGetFieldReturn(value, fieldInd, ret)
-	case odbc.SQL_FLOAT:
-		var value float64
-		valuePtr := uintptr(unsafe.Pointer(&value))
-		ret = odbc.SQLGetData(rows.handle, odbc.SQLUSMALLINT(index), odbc.SQL_C_FLOAT, valuePtr, 0, &fieldInd)
-		return formatGetFieldReturn(value, fieldInd, ret)
-	case odbc.SQL_DOUBLE, odbc.SQL_REAL:
-		var value float64
-		valuePtr := uintptr(unsafe.Pointer(&value))
-		ret = odbc.SQLGetData(rows.handle, odbc.SQLUSMALLINT(index), odbc.SQL_C_DOUBLE, valuePtr, 0, &fieldInd)
-		return formatGetFieldReturn(value, fieldInd, ret)
-	case odbc.SQL_NUMERIC, odbc.SQL_DECIMAL:
-		var value odbc.SQL_NUMERIC_STRUCT
-		valuePtr := uintptr(unsafe.Pointer(&value))
-		ret = odbc.SQLGetData(rows.handle, odbc.SQLUSMALLINT(index), odbc.SQL_ARD_TYPE, valuePtr, 0, &fieldInd)
-		return formatGetFieldReturn(numericToFloat(value), fieldInd, ret)
-	case odbc.SQL_CHAR, odbc.SQL_VARCHAR, odbc.SQL_LONGVARCHAR, odbc.SQL_WCHAR, odbc.SQL_WVARCHAR, odbc.SQL_SS_XML:
-		//Must read string in chunks
-		stringParts := make([]string, 0)
-		for {
-			chunkSize := 4096
-			valueChunk := make([]uint16, chunkSize*2)
-			valueChunkPtr := uintptr(unsafe.Pointer(&valueChunk[0]))
-			ret = odbc.SQLGetData(rows.handle, odbc.SQLUSMALLINT(index), odbc.SQL_C_WCHAR, valueChunkPtr, odbc.SQLLEN(chunkSize*2*2), &fieldInd)
-			if isError(ret) || odbc.SQLLEN(ret) == odbc.SQL_NULL_DATA {
-				return formatGetFieldReturn(nil, fieldInd, ret)
-			} else if ret == odbc.SQL_NO_DATA {
-				//All data has been retrieved
-				break
-			} else if ret == odbc.SQL_SUCCESS {
-				stringParts = append(stringParts, syscall.UTF16ToString(valueChunk))
-				break
-			}
-			stringParts = append(stringParts, syscall.UTF16ToString(valueChunk))
-		}
-		return formatGetFieldReturn(strings.Join(stringParts, ""), odbc.SQLLEN(0), odbc.SQL_SUCCESS)
-	case odbc.SQL_VARBINARY:
-		var binaryData []byte
-		chunkSize := 4096
-		valueChunk := make([]byte, chunkSize)
-		valueChunkPtr := uintptr(unsafe.Pointer(&valueChunk[0]))
-		for {
-			ret = odbc.SQLGetData(rows.handle, odbc.SQLUSMALLINT(index), odbc.SQL_C_BINARY, valueChunkPtr, odbc.SQLLEN(chunkSize), &fieldInd)
-			if isError(ret) || odbc.SQLLEN(ret) == odbc.SQL_NULL_DATA {
-				return formatGetFieldReturn(nil, fieldInd, ret)
-			} else if ret == odbc.SQL_NO_DATA {
-				//All data has been retrieved
-				break
-			} else if ret == odbc.SQL_SUCCESS {
-				partSize := int(fieldInd) % chunkSize
-				if partSize == 0 {
-					partSize = chunkSize
-				}
-				binaryData = append(binaryData, valueChunk[0:partSize]...)
-				break
-			}
-			binaryData = append(binaryData, valueChunk...)
-		}
-		return formatGetFieldReturn(binaryData, odbc.SQLLEN(0), odbc.SQL_SUCCESS)
-	case odbc.SQL_TYPE_DATE:
-		var value odbc.SQL_DATE_STRUCT
-		valuePtr := uintptr(unsafe.Pointer(&value))
-		ret = odbc.SQLGetData(rows.handle, odbc.SQLUSMALLINT(index), odbc.SQL_C_DATE, valuePtr, 0, &fieldInd)
-		time := time.Date(int(value.Year), time.Month(value.Month), int(value.Day), 0, 0, 0, 0, time.UTC)
-		return formatGetFieldReturn(time, fieldInd, ret)
-	case odbc.SQL_TYPE_TIMESTAMP:
-		var value odbc.SQL_TIMESTAMP_STRUCT
-		valuePtr := uintptr(unsafe.Pointer(&value))
-		ret = odbc.SQLGetData(rows.handle, odbc.SQLUSMALLINT(index), odbc.SQL_C_TIMESTAMP, valuePtr, 0, &fieldInd)
-		time := time.Date(int(value.Year), time.Month(value.Month), int(value.Day), int(value.Hour), int(value.Minute), int(value.Second), int(value.Faction), time.UTC)
-		return formatGetFieldReturn(time, fieldInd, ret)
-	default:
-		panic(fmt.Sprintf("ODBC type not supported: {%v}. Column name: %v", columnDef.DataType, columnDef.Name))
-	}
-
-	return nil, odbc.SQL_SUCCESS
-
-}
-
-// Utility function to format return value
-func formatGetFieldReturn(value interface{}, fieldInd odbc.SQLLEN, getDataRet odbc.SQLReturn) (interface{}, odbc.SQLReturn) {
-	if isError(getDataRet) {
-		return nil, getDataRet
-	} else if fieldInd == odbc.SQL_NULL_DATA {
-		return nil, odbc.SQL_SUCCESS
+// Return a single column of data with its decoder.  Columns of types without one are read as text,
+// or as bytes if the driver cannot convert them to text.
+func (rows *rows) getField(index int) (interface{}, error) {
+	decoder := rows.columnDecoders[index-1]
+	if decoder == nil {
+		return rows.getUnknownField(index)
+	}
+
+	//Read fixed size C types in one call -- SQL_C_NUMERIC through the precision and scale set on the descriptor
+	var data []byte
+	var ret odbc.SQLReturn
+	if decoder.Size > 0 {
+		cType := decoder.CType
+		if cType == odbc.SQL_C_NUMERIC {
+			cType = odbc.SQL_ARD_TYPE
+		}
+		var fieldInd odbc.SQLLEN
+		data = alignedBuffer(decoder.Size)
+		ret = rows.api.SQLGetData(rows.handle, odbc.SQLUSMALLINT(index), cType, unsafe.Pointer(&data[0]), odbc.SQLLEN(len(data)), &fieldInd)
+		if fieldInd == odbc.SQL_NULL_DATA {
+			data = nil
+		}
 	} else {
-		return value, odbc.SQL_SUCCESS
+		data, ret = rows.getData(index, decoder.CType)
+	}
+	if isError(ret) {
+		return nil, errorStatement(rows.api, rows.handle, rows.sqlStmt)
+	}
+	if data == nil {
+		return nil, nil
+	}
+	if decoder.CType == odbc.SQL_C_WCHAR {
+		data = []byte(utf16BytesToString(data))
+	}
+	return decoder.Decode(data, rows.convertContext())
+}
+
+// Reads a column of a type without built-in decoding -- as text, or as bytes for SQL Server CLR types
+// and other types the driver cannot convert to text
+func (rows *rows) getUnknownField(index int) (interface{}, error) {
+	columnDef := rows.resultColumnDefs[index-1]
+	cType := odbc.SQL_C_WCHAR
+	if columnDef.DataType == odbc.SQL_SS_UDT {
+		cType = odbc.SQL_C_BINARY
+	}
+	data, ret := rows.getData(index, cType)
+	if isError(ret) && cType == odbc.SQL_C_WCHAR {
+		err := errorStatement(rows.api, rows.handle, rows.sqlStmt)
+		if sqlState(err) != "07006" {
+			return nil, err
+		}
+		//Restricted data type attribute violation -- the value has no text form
+		cType = odbc.SQL_C_BINARY
+		data, ret = rows.getData(index, cType)
+	}
+	if isError(ret) {
+		return nil, errorStatement(rows.api, rows.handle, fmt.Sprintf("%v\nColumn %v of unsupported type %v", rows.sqlStmt, columnDef.Name, columnDef.DataType))
+	}
+	if data == nil {
+		return nil, nil
+	} else if cType == odbc.SQL_C_WCHAR {
+		return utf16BytesToString(data), nil
+	}
+	return data, nil
+}
+
+// Returns the settings column values are decoded with
+func (rows *rows) convertContext() ConvertContext {
+	return ConvertContext{Location: rows.location, DecimalFormat: rows.decimalFormat}
+}
+
+// Size of the buffer values are read into by getData
+const getDataChunkSize = 8192
+
+// Reads the whole value of a column as cType with SQLGetData, calling it again while the value does not fit the buffer.
+// Text is returned without its NUL terminator, as UTF-16 for SQL_C_WCHAR.  Returns nil for NULL values.
+func (rows *rows) getData(index int, cType odbc.CDataType) ([]byte, odbc.SQLReturn) {
+	chunk := alignedBuffer(getDataChunkSize)
+	terminator := 0
+	switch cType {
+	case odbc.SQL_C_WCHAR:
+		terminator = 2
+	case odbc.SQL_C_CHAR:
+		terminator = 1
+	}
+	data := make([]byte, 0)
+	for {
+		var fieldInd odbc.SQLLEN
+		ret := rows.api.SQLGetData(rows.handle, odbc.SQLUSMALLINT(index), cType, unsafe.Pointer(&chunk[0]), odbc.SQLLEN(len(chunk)), &fieldInd)
+		if ret == odbc.SQL_NO_DATA {
+			//All data has been retrieved
+			return data, odbc.SQL_SUCCESS
+		} else if isError(ret) {
+			return nil, ret
+		} else if fieldInd == odbc.SQL_NULL_DATA {
+			return nil, odbc.SQL_SUCCESS
+		}
+
+		//A full chunk holds the buffer less the NUL terminator -- the last one holds what remained
+		n := len(chunk) - terminator
+		isLast := ret == odbc.SQL_SUCCESS
+		if fieldInd != odbc.SQL_NO_TOTAL && int(fieldInd) <= n {
+			n = int(fieldInd)
+			isLast = true
+		}
+		data = append(data, chunk[:n]...)
+		if isLast {
+			return data, odbc.SQL_SUCCESS
+		}
 	}
-	return nil, odbc.SQL_SUCCESS
 }
@@ -0,0 +1,169 @@
+package lodbc_test
+
+import (
+	"bytes"
+	"database/sql"
+	"github.com/LukeMauldin/lodbc"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"github.com/LukeMauldin/lodbc/odbc/fake"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestQueryFetchesEveryType(t *testing.T) {
+	for _, rowsetSize := range []int{0, 1, 2, 100} {
+		dm := fake.New()
+		dm.SetResponse("select * from t", &fake.Response{ResultSets: []*fake.ResultSet{typesResultSet}})
+		db := openDB(t, dm, func(config *lodbc.Config) {
+			config.RowsetSize = rowsetSize
+			config.DecimalFormat = lodbc.DecimalString
+		})
+
+		rows, err := db.Query("select * from t")
+		if err != nil {
+			t.Fatalf("rowset %v: %v", rowsetSize, err)
+		}
+		var got []typesRow
+		for rows.Next() {
+			var r typesRow
+			if err := rows.Scan(&r.id, &r.name, &r.amount, &r.created, &r.data, &r.active); err != nil {
+				t.Fatalf("rowset %v: %v", rowsetSize, err)
+			}
+			got = append(got, r)
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatalf("rowset %v: %v", rowsetSize, err)
+		}
+		rows.Close()
+
+		if len(got) != 3 {
+			t.Fatalf("rowset %v: got %v rows, want 3", rowsetSize, len(got))
+		}
+		first := got[0]
+		if first.id.Int64 != 1 || first.name.String != "one" || first.amount.String != "12.34" || !first.active.Bool {
+			t.Errorf("rowset %v: first row %+v", rowsetSize, first)
+		}
+		if !first.created.Time.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)) || !bytes.Equal(first.data, []byte{1, 2, 3}) {
+			t.Errorf("rowset %v: first row %+v", rowsetSize, first)
+		}
+		if got[1].amount.String != "-0.50" || got[1].data != nil || got[1].active.Bool {
+			t.Errorf("rowset %v: second row %+v", rowsetSize, got[1])
+		}
+		last := got[2]
+		if last.id.Valid || last.name.Valid || last.amount.Valid || last.created.Valid || last.data != nil || last.active.Valid {
+			t.Errorf("rowset %v: NULL row %+v", rowsetSize, last)
+		}
+	}
+}
+
+func TestMultipleResultSets(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("batch", &fake.Response{ResultSets: []*fake.ResultSet{
+		{Columns: []fake.Column{{Name: "a", Type: odbc.SQL_INTEGER}}, Rows: [][]interface{}{{1}, {2}}},
+		{RowsAffected: 1},
+		{Columns: []fake.Column{{Name: "b", Type: odbc.SQL_WVARCHAR, Precision: 5}}, Rows: [][]interface{}{{"x"}}},
+	}})
+	db := openDB(t, dm, nil)
+
+	rows, err := db.Query("batch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var ints []int
+	for rows.Next() {
+		var a int
+		if err := rows.Scan(&a); err != nil {
+			t.Fatal(err)
+		}
+		ints = append(ints, a)
+	}
+	if len(ints) != 2 || !rows.NextResultSet() || !rows.Next() {
+		t.Fatalf("read %v from the first result set, then %v", ints, rows.Err())
+	}
+	var b string
+	if err := rows.Scan(&b); err != nil || b != "x" {
+		t.Errorf("second result set returned %q, %v", b, err)
+	}
+	if rows.Next() || rows.NextResultSet() {
+		t.Error("read past the last result set")
+	}
+}
+
+func TestBoundTextHoldsCharactersOutsideTheBMP(t *testing.T) {
+	text := "😀😀"
+	dm := fake.New()
+	dm.SetResponse("select emoji", &fake.Response{ResultSets: []*fake.ResultSet{{
+		Columns: []fake.Column{{Name: "text", Type: odbc.SQL_WVARCHAR, Precision: 2}},
+		Rows:    [][]interface{}{{text}, {text}},
+	}}})
+	db := openDB(t, dm, nil)
+
+	rows, err := db.Query("select emoji")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var got string
+		if err := rows.Scan(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got != text {
+			t.Errorf("got %q, want %q", got, text)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Driver manager failing SQLColAttribute for one field
+type colAttributeFailure struct {
+	*fake.DriverManager
+	field odbc.SQLColAttributeType
+}
+
+func (dm colAttributeFailure) SQLColAttribute(statementHandle odbc.SQLHandle, columnNumber odbc.SQLUSMALLINT, fieldIdentifier odbc.SQLColAttributeType, characterAttribute unsafe.Pointer, bufferLength odbc.SQLSMALLINT, stringLengthPtr *odbc.SQLSMALLINT, numericAttributePtr *odbc.SQLLEN) odbc.SQLReturn {
+	if fieldIdentifier == dm.field {
+		return odbc.SQL_ERROR
+	}
+	return dm.DriverManager.SQLColAttribute(statementHandle, columnNumber, fieldIdentifier, characterAttribute, bufferLength, stringLengthPtr, numericAttributePtr)
+}
+
+func TestFailedColumnAttributesFailTheQuery(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("select text", &fake.Response{ResultSets: []*fake.ResultSet{{
+		Columns: []fake.Column{{Name: "text", Type: odbc.SQL_WVARCHAR, Precision: 10}},
+		Rows:    [][]interface{}{{"value"}},
+	}}})
+	openFailing := func(field odbc.SQLColAttributeType) *sql.DB {
+		d, err := lodbc.NewDriver(colAttributeFailure{DriverManager: dm, field: field})
+		if err != nil {
+			t.Fatal(err)
+		}
+		connector, err := lodbc.NewDriverConnector(d, lodbc.NewConfig("DSN=fake"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		db := sql.OpenDB(connector)
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+
+	//The length decides whether the column is bound, so a failure is not taken for an unbounded column
+	for _, field := range []odbc.SQLColAttributeType{odbc.SQL_COLUMN_TYPE, odbc.SQL_DESC_LABEL, odbc.SQLColAttributeType(odbc.SQL_DESC_LENGTH)} {
+		rows, err := openFailing(field).Query("select text")
+		if err == nil {
+			rows.Close()
+			t.Errorf("query succeeded with SQLColAttribute failing for field %v", field)
+		}
+	}
+
+	//The optional attributes keep their defaults
+	var text string
+	if err := openFailing(odbc.SQL_DESC_TYPE_NAME).QueryRow("select text").Scan(&text); err != nil || text != "value" {
+		t.Errorf("read %q, %v without the type name", text, err)
+	}
+}
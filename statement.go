@@ -2,19 +2,22 @@ package lodbc
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
 	"database/sql/driver"
 	"encoding/gob"
 	"fmt"
 	"github.com/LukeMauldin/lodbc/odbc"
-	"reflect"
 	"runtime"
 	"strings"
-	"syscall"
-	"time"
+	"sync"
 	"unsafe"
 )
 
 type statement struct {
+	//ODBC API used by the statement
+	api odbc.API
+
 	//Statement handle
 	handle odbc.SQLHandle
 
@@ -25,7 +28,7 @@ type statement struct {
 	sqlStmt string
 
 	//Active query for the statement
-	rows driver.Rows
+	rows *rows
 
 	//Owning connection
 	conn *connection
@@ -38,125 +41,62 @@ type statement struct {
 
 	//SQL statement options
 	queryOptions []QueryOption
-}
 
-func (stmt *statement) bindInt(index int, value int, direction ParameterDirection) error {
-	stmt.bindValues[index] = &value
-	ret := odbc.SQLBindParameter(stmt.handle, odbc.SQLUSMALLINT(index), direction.SQLBindParameterType(), odbc.SQL_C_LONG, odbc.SQL_INTEGER, 0, 0, odbc.SQLPOINTER(unsafe.Pointer(stmt.bindValues[index].(*int))), 0, nil)
-	if isError(ret) {
-		return errorStatement(stmt.handle, fmt.Sprintf("Bind index: %v, Value: %v", index, value))
-	}
-	return nil
-}
+	//Number of parameter markers reported by the driver, -1 if unknown
+	numInput int
 
-func (stmt *statement) bindInt64(index int, value int64, direction ParameterDirection) error {
-	stmt.bindValues[index] = &value
-	ret := odbc.SQLBindParameter(stmt.handle, odbc.SQLUSMALLINT(index), direction.SQLBindParameterType(), odbc.SQL_C_LONG, odbc.SQL_BIGINT, 0, 0, odbc.SQLPOINTER(unsafe.Pointer(stmt.bindValues[index].(*int64))), 0, nil)
-	if isError(ret) {
-		return errorStatement(stmt.handle, fmt.Sprintf("Bind index: %v, Value: %v", index, value))
-	}
-	return nil
-}
+	//Was the statement prepared -- if not, it is executed with SQLExecDirect
+	isPrepared bool
 
-func (stmt *statement) bindBool(index int, value bool, direction ParameterDirection) error {
-	stmt.bindValues[index] = &value
-	ret := odbc.SQLBindParameter(stmt.handle, odbc.SQLUSMALLINT(index), direction.SQLBindParameterType(), odbc.SQL_C_BIT, odbc.SQL_BIT, 0, 0, odbc.SQLPOINTER(unsafe.Pointer(stmt.bindValues[index].(*bool))), 0, nil)
-	if isError(ret) {
-		return errorStatement(stmt.handle, fmt.Sprintf("Bind index: %v, Value: %v", index, value))
-	}
-	return nil
-}
+	//SQL text prepared on the handle, sqlStmt or sqlStmt followed by the last insert id query
+	preparedText string
 
-func (stmt *statement) bindNumeric(index int, value float64, precision int, scale int, direction ParameterDirection) error {
-	stmt.bindValues[index] = &value
-	ret := odbc.SQLBindParameter(stmt.handle, odbc.SQLUSMALLINT(index), direction.SQLBindParameterType(), odbc.SQL_C_DOUBLE, odbc.SQL_DOUBLE, 0, 0, odbc.SQLPOINTER(unsafe.Pointer(stmt.bindValues[index].(*float64))), 0, nil)
-	/* Must convert to SQL_NUMERIC_STRUCT for decimal to work - http://support.microsoft.com/kb/181254
-	 ret := odbc.SQLBindParameter(stmt.handle, uint16(index), direction.SQLBindParameterType(), odbc.SQL_C_NUMERIC, odbc.SQL_DECIMAL, uint64(precision), int16(scale), uintptr(unsafe.Pointer(&bindVal)), 0, nil)
-	odbc.SQLSetDescField(stmt.stmtDescHandle, odbc.SQLSMALLINT(index), odbc.SQL_DESC_TYPE, odbc.SQL_NUMERIC, 0)
-	odbc.SQLSetDescField(stmt.stmtDescHandle, odbc.SQLSMALLINT(index), odbc.SQL_DESC_PRECISION, int32(precision), 0)
-	odbc.SQLSetDescField(stmt.stmtDescHandle, odbc.SQLSMALLINT(index), odbc.SQL_DESC_SCALE, int32(scale), 0) */
-	if isError(ret) {
-		return errorStatement(stmt.handle, fmt.Sprintf("Bind index: %v, Value: %v", index, value))
-	}
-	return nil
-}
+	//Statement with its own handle prepared with the last insert id query appended, for Exec of a prepared INSERT,
+	//so the statement keeps its own prepared text for Query -- nil until the first such Exec
+	lastInsertIdStmt *statement
 
-func (stmt *statement) bindDate(index int, value time.Time, direction ParameterDirection) error {
-	var bindVal odbc.SQL_DATE_STRUCT
-	bindVal.Year = odbc.SQLSMALLINT(value.Year())
-	bindVal.Month = odbc.SQLUSMALLINT(value.Month())
-	bindVal.Day = odbc.SQLUSMALLINT(value.Day())
+	//Catalog function such as SQLTables run in place of the SQL statement, nil for SQL statements
+	catalogCall func() odbc.SQLReturn
 
-	stmt.bindValues[index] = &bindVal
-	ret := odbc.SQLBindParameter(stmt.handle, odbc.SQLUSMALLINT(index), direction.SQLBindParameterType(), odbc.SQL_C_DATE, odbc.SQL_DATE, 10, 0, odbc.SQLPOINTER(unsafe.Pointer(stmt.bindValues[index].(*odbc.SQL_DATE_STRUCT))), 6, nil)
-	if isError(ret) {
-		return errorStatement(stmt.handle, fmt.Sprintf("Bind index: %v, Value: %v", index, bindVal))
-	}
-	return nil
-}
+	//Current SQL_ATTR_QUERY_TIMEOUT in seconds
+	queryTimeout int
 
-func (stmt *statement) bindDateTime(index int, value time.Time, direction ParameterDirection) error {
-	var bindVal odbc.SQL_TIMESTAMP_STRUCT
-	bindVal.Year = odbc.SQLSMALLINT(value.Year())
-	bindVal.Month = odbc.SQLUSMALLINT(value.Month())
-	bindVal.Day = odbc.SQLUSMALLINT(value.Day())
-	bindVal.Hour = odbc.SQLUSMALLINT(value.Hour())
-	bindVal.Minute = odbc.SQLUSMALLINT(value.Minute())
-	bindVal.Second = odbc.SQLUSMALLINT(value.Second())
-
-	stmt.bindValues[index] = &bindVal
-	ret := odbc.SQLBindParameter(stmt.handle, odbc.SQLUSMALLINT(index), direction.SQLBindParameterType(), odbc.SQL_C_TIMESTAMP, odbc.SQL_TIMESTAMP, 23, 0, odbc.SQLPOINTER(unsafe.Pointer(stmt.bindValues[index].(*odbc.SQL_TIMESTAMP_STRUCT))), 16, nil)
-	if isError(ret) {
-		return errorStatement(stmt.handle, fmt.Sprintf("Bind index: %v, Value: %v", index, bindVal))
-	}
-	return nil
+	//Output parameters of the current execution, written back once its results are consumed
+	outputParams []*outputParameter
+
+	//Informational messages returned by the current execution
+	messages []StatusRecord
+
+	//Held for the whole of Exec, Query and Close, so Close from a finalizer or another goroutine waits for an execution in progress
+	mu sync.Mutex
 }
 
-func (stmt *statement) bindString(index int, value string, length int, direction ParameterDirection) error {
-	if length == 0 {
-		length = len(value)
-	}
-	stmt.bindValues[index] = syscall.StringToUTF16(value)
-	var sqlType odbc.SQLDataType
-	if length < 4000 {
-		sqlType = odbc.SQL_VARCHAR
-	} else {
-		sqlType = odbc.SQL_LONGVARCHAR
-	}
-	ret := odbc.SQLBindParameter(stmt.handle, odbc.SQLUSMALLINT(index), direction.SQLBindParameterType(), odbc.SQL_C_WCHAR, sqlType, odbc.SQLULEN(length), 0, odbc.SQLPOINTER(unsafe.Pointer(&stmt.bindValues[index].([]uint16)[0])), 0, nil)
-	if isError(ret) {
-		return errorStatement(stmt.handle, fmt.Sprintf("Bind index: %v, Value: %v", index, value))
-	}
-	return nil
+// Parameter bound from the buffer an encoder returned
+type boundParameter struct {
+	value interface{}
+	data  []byte
+	ind   odbc.SQLLEN
 }
 
-func (stmt *statement) bindByteArray(index int, value []byte, direction ParameterDirection) error {
-	// Store both value and lenght, because we need a pointer to the lenght in
-	// the last parameter of SQLBindParamter. Otherwise the data is assumed to
-	// be a null terminated string.
-	bindVal := &struct {
-		value  []byte
-		length int
-	}{
-		value,
-		len(value),
-	}
-	sqlType := odbc.SQL_VARBINARY
-	if bindVal.length > 4000 {
-		sqlType = odbc.SQL_LONGVARBINARY
-	}
+// Binds a parameter converted by its ParameterEncoder
+func (stmt *statement) bindEncoded(index int, value interface{}, encoded EncodedParameter, direction ParameterDirection) error {
+	bindVal := &boundParameter{value: value, data: encoded.Data, ind: odbc.SQLLEN(len(encoded.Data))}
 
-	// Protect against index out of range on &bindVal.value[0] when value is zero-length.
-	// We can't pass NULL to SQLBindParameter so this is needed, it will still
-	// write a zero length value to the database since the length parameter is
-	// zero.
-	if bindVal.length == 0 {
-		bindVal.value = []byte{'\x00'}
+	// We can't pass NULL to SQLBindParameter for a zero length value, so point it at a
+	// buffer of its own -- the zero length indicator still writes an empty value.
+	if len(bindVal.data) == 0 {
+		bindVal.data = alignedBuffer(2)
 	}
+	stmt.bindValues[index] = bindVal
 
-	ret := odbc.SQLBindParameter(stmt.handle, odbc.SQLUSMALLINT(index), direction.SQLBindParameterType(), odbc.SQL_C_BINARY, sqlType, odbc.SQLULEN(bindVal.length), 0, odbc.SQLPOINTER(unsafe.Pointer(&bindVal.value[0])), 0, (*odbc.SQLLEN)(unsafe.Pointer(&bindVal.length)))
+	valuePtr := unsafe.Pointer(&bindVal.data[0])
+	ret := stmt.api.SQLBindParameter(stmt.handle, odbc.SQLUSMALLINT(index), direction.SQLBindParameterType(), encoded.CType, encoded.SQLType, odbc.SQLULEN(encoded.ColumnSize), odbc.SQLSMALLINT(encoded.DecimalDigits), valuePtr, odbc.SQLLEN(len(bindVal.data)), &bindVal.ind)
 	if isError(ret) {
-		return errorStatement(stmt.handle, fmt.Sprintf("Bind index: %v, Value: %v", index, value))
+		return errorStatement(stmt.api, stmt.handle, fmt.Sprintf("Bind index: %v, Value: %v", index, value))
+	}
+
+	if encoded.CType == odbc.SQL_C_NUMERIC {
+		return setNumericDescriptor(stmt.api, stmt.stmtDescHandle, index, encoded.ColumnSize, encoded.DecimalDigits, valuePtr, fmt.Sprintf("Bind index: %v, Value: %v", index, value))
 	}
 	return nil
 }
@@ -168,39 +108,57 @@ func (stmt *statement) bindNull(index int, direction ParameterDirection) error {
 func (stmt *statement) bindNullParam(index int, paramType odbc.SQLDataType, direction ParameterDirection) error {
 	nullDataInd := odbc.SQL_NULL_DATA
 	stmt.bindValues[index] = &nullDataInd
-	ret := odbc.SQLBindParameter(stmt.handle, odbc.SQLUSMALLINT(index), direction.SQLBindParameterType(), odbc.SQL_C_DEFAULT, paramType, 1, 0, 0, 0, &nullDataInd)
+	ret := stmt.api.SQLBindParameter(stmt.handle, odbc.SQLUSMALLINT(index), direction.SQLBindParameterType(), odbc.SQL_C_DEFAULT, paramType, 1, 0, nil, 0, &nullDataInd)
 	if isError(ret) {
-		return errorStatement(stmt.handle, fmt.Sprintf("Bind index: %v, Value: nil", index))
+		return errorStatement(stmt.api, stmt.handle, fmt.Sprintf("Bind index: %v, Value: nil", index))
 	}
 	return nil
 }
 
 func (stmt *statement) Close() error {
+	stmt.mu.Lock()
+	defer stmt.mu.Unlock()
 
-	//Verify that stmtHandle is valid
-	if stmt.handle == 0 {
+	//Verify that stmtHandle is valid and the statement has not already been closed
+	if stmt.handle == 0 || stmt.isClosed {
 		return nil
 	}
 
-	//Verify that statement has not already been closed
-	if stmt.isClosed {
-		return nil
-	}
+	//Mark the statement as closed first, so rows that own it do not close it again
+	stmt.isClosed = true
 
 	var err error
 
+	//Close the statement prepared with the last insert id query, before holding the connection's handle lock it also takes
+	if stmt.lastInsertIdStmt != nil {
+		err = stmt.lastInsertIdStmt.Close()
+		stmt.lastInsertIdStmt = nil
+	}
+
+	//Keep the connection from disconnecting while the handle is used -- once it has, the handle was freed with it
+	stmt.conn.handleMu.RLock()
+	defer stmt.conn.handleMu.RUnlock()
+	isFreed := stmt.conn.handle == 0
+
 	//Close any open rows
 	if stmt.rows != nil {
-		err = stmt.rows.Close()
+		if isFreed {
+			stmt.rows.abandon()
+		} else {
+			err = stmt.rows.closeRows()
+		}
+		stmt.rows = nil
 	}
 
 	//Clear any bind values
 	stmt.bindValues = nil
 
 	//Free the statement handle
-	ret := odbc.SQLFreeHandle(odbc.SQL_HANDLE_STMT, stmt.handle)
-	if isError(ret) {
-		err = errorStatement(stmt.handle, stmt.sqlStmt)
+	if !isFreed {
+		ret := stmt.api.SQLFreeHandle(odbc.SQL_HANDLE_STMT, stmt.handle)
+		if isError(ret) {
+			err = errorStatement(stmt.api, stmt.handle, stmt.sqlStmt)
+		}
 	}
 
 	//Mark the statement as closed with the connection
@@ -213,9 +171,6 @@ func (stmt *statement) Close() error {
 	//Clear the finalizer
 	runtime.SetFinalizer(stmt, nil)
 
-	//Mark the rows as closed
-	stmt.isClosed = true
-
 	//Return any error
 	if err != nil {
 		return err
@@ -225,78 +180,93 @@ func (stmt *statement) Close() error {
 }
 
 func (stmt *statement) Query(args []driver.Value) (driver.Rows, error) {
-	//Clear any existing bind values
-	stmt.bindValues = make([]interface{}, len(args)+1)
+	stmt.mu.Lock()
+	defer stmt.mu.Unlock()
+	driverRows, err := stmt.query(context.Background(), args)
+	return driverRows, stmt.conn.checkLink(err)
+}
 
-	//Bind the parameters
-	bindParameters, err := stmt.convertToBindParameters(args)
+// QueryContext executes the query, cancelling it with SQLCancel if ctx is done first
+func (stmt *statement) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	stmt.mu.Lock()
+	defer stmt.mu.Unlock()
+	values, err := namedValuesToValues(args)
 	if err != nil {
 		return nil, err
 	}
-	stmt.bindParameters(bindParameters)
-
-	//If rows is not nil, close rows and set to nil
-	if stmt.rows != nil {
-		stmt.rows.Close()
-		stmt.rows = nil
-	}
+	driverRows, err := stmt.query(ctx, values)
+	return driverRows, stmt.conn.checkLink(err)
+}
 
+func (stmt *statement) query(ctx context.Context, args []driver.Value) (driver.Rows, error) {
 	//Execute SQL statement
-	sqlStmtSqlPtr := (*odbc.SQLCHAR)(unsafe.Pointer(syscall.StringToUTF16Ptr(stmt.sqlStmt)))
-	ret := odbc.SQLExecDirect(stmt.handle, sqlStmtSqlPtr, odbc.SQL_NTS)
-	if isError(ret) {
-		return nil, errorStatement(stmt.handle, fmt.Sprintf("SQL Stmt: %v\nBind Values: %v", stmt.sqlStmt, stmt.formatBindValues()))
+	err := stmt.execute(ctx, args, "")
+	if err != nil {
+		return nil, err
 	}
 
 	//Get row descriptor handle
 	var descRowHandle odbc.SQLHandle
-	ret = odbc.SQLGetStmtAttr(stmt.handle, odbc.SQL_ATTR_APP_ROW_DESC, uintptr(unsafe.Pointer(&descRowHandle)), 0, nil)
+	ret := stmt.api.SQLGetStmtAttr(stmt.handle, odbc.SQL_ATTR_APP_ROW_DESC, unsafe.Pointer(&descRowHandle), 0, nil)
 	if isError(ret) {
-		return nil, errorStatement(stmt.handle, fmt.Sprintf("SQL Stmt: %v\nBind Values: %v", stmt.sqlStmt, stmt.formatBindValues()))
+		return nil, errorStatement(stmt.api, stmt.handle, fmt.Sprintf("SQL Stmt: %v\nBind Values: %v", stmt.sqlStmt, stmt.formatBindValues()))
+	}
+
+	//Use the decimal format of the query option if it was passed, otherwise the connection's
+	format := stmt.conn.decimalFormat
+	if optionValue, optionFound := getOptionValue(stmt.queryOptions, DecimalResultFormat); optionFound {
+		format = DecimalFormat(optionValue.(float64))
 	}
 
+	//Use the rowset size of the query option if it was passed, otherwise the connection's
+	fetchSize := stmt.conn.rowsetSize
+	if optionValue, optionFound := getOptionValue(stmt.queryOptions, RowsetSize); optionFound {
+		fetchSize = int(optionValue.(float64))
+	}
+
+	//Use the LOB chunk size of the query option if it was passed, otherwise the connection's
+	chunkSize := stmt.conn.lobChunkSize
+	if optionValue, optionFound := getOptionValue(stmt.queryOptions, LOBChunkSize); optionFound {
+		chunkSize = int(optionValue.(float64))
+	}
+
+	//Create rows
+	newRows := &rows{api: stmt.api, handle: stmt.handle, descHandle: descRowHandle, sqlStmt: stmt.sqlStmt, decimalFormat: format, location: stmt.conn.location, rowsetSize: fetchSize, lobChunkSize: chunkSize, outputParams: stmt.outputParams, conn: stmt.conn, messages: stmt.messages}
+
 	//Check to see if the query option ResultSetNum was passed and if so, iterate through result sets
 	optionValue, optionFound := getOptionValue(stmt.queryOptions, ResultSetNum)
 	if optionFound {
 		for counter, resultSetNum := 0, int(optionValue.(float64)); counter < resultSetNum; counter++ {
-			ret := odbc.SQLMoreResults(stmt.handle)
-			if isError(ret) {
-				return nil, errorStatement(stmt.handle, fmt.Sprintf("SQL Stmt: %v", stmt.sqlStmt))
+			ret := stmt.api.SQLMoreResults(stmt.handle)
+			if ret == odbc.SQL_NO_DATA {
+				return nil, fmt.Errorf("Result set %v was not returned by SQL Stmt: %v", resultSetNum, stmt.sqlStmt)
+			} else if isError(ret) {
+				return nil, errorStatement(stmt.api, stmt.handle, fmt.Sprintf("SQL Stmt: %v", stmt.sqlStmt))
 			}
+			newRows.collectMessages(ret)
 		}
 	} else {
-		//If query option ResultSetNum was not passed, iterate through result sets until at least one column is found
-		for {
-			var numColumns odbc.SQLSMALLINT
-			ret := odbc.SQLNumResultCols(stmt.handle, &numColumns)
-			if isError(ret) {
-				return nil, errorStatement(stmt.handle, fmt.Sprintf("SQL Stmt: %v", stmt.sqlStmt))
-			}
-			if numColumns > 0 {
-				break
-			} else {
-				ret := odbc.SQLMoreResults(stmt.handle)
-				if isError(ret) {
-					return nil, errorStatement(stmt.handle, fmt.Sprintf("SQL Stmt: %v", stmt.sqlStmt))
-				}
+		//If query option ResultSetNum was not passed, skip results until one with columns is found
+		found, err := newRows.skipResultsWithoutColumns()
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			err = newRows.finishResults()
+			if err != nil {
+				return nil, err
 			}
 		}
 	}
 
 	//Get definition of result columns
-	resultColumnDefs, ret := buildResultColumnDefinitions(stmt.handle, stmt.sqlStmt)
-	if isError(ret) {
-		return nil, errorStatement(stmt.handle, fmt.Sprintf("SQL Stmt: %v\nBind Values: %v", stmt.sqlStmt, stmt.formatBindValues()))
-	}
-
-	//Make a slice of the column names
-	columnNames := make([]string, len(resultColumnDefs))
-	for index, resultCol := range resultColumnDefs {
-		columnNames[index] = fmt.Sprint(resultCol.Name)
+	if !newRows.isExhausted {
+		err = newRows.loadResultSet()
+		if err != nil {
+			return nil, err
+		}
 	}
-
-	//Create rows
-	stmt.rows = &rows{handle: stmt.handle, descHandle: descRowHandle, isBeforeFirst: true, resultColumnDefs: resultColumnDefs, resultColumnNames: columnNames, sqlStmt: stmt.sqlStmt}
+	stmt.rows = newRows
 
 	//Add a finalizer
 	runtime.SetFinalizer(stmt.rows, (*rows).Close)
@@ -305,34 +275,244 @@ func (stmt *statement) Query(args []driver.Value) (driver.Rows, error) {
 }
 
 func (stmt *statement) Exec(args []driver.Value) (driver.Result, error) {
+	stmt.mu.Lock()
+	defer stmt.mu.Unlock()
+	res, err := stmt.exec(context.Background(), args)
+	return res, stmt.conn.checkLink(err)
+}
+
+// ExecContext executes the statement, cancelling it with SQLCancel if ctx is done first
+func (stmt *statement) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	stmt.mu.Lock()
+	defer stmt.mu.Unlock()
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	res, err := stmt.exec(ctx, values)
+	return res, stmt.conn.checkLink(err)
+}
+
+func (stmt *statement) exec(ctx context.Context, args []driver.Value) (driver.Result, error) {
+	//Execute SQL statement, with the last insert id query in the same batch if one is set and it is an INSERT
+	res := &result{}
+	if isInsert(stmt.sqlStmt) {
+		res.lastInsertIdQuery = stmt.conn.lastInsertIdQuery
+	}
+
+	//A prepared INSERT runs on the statement prepared with the query appended, so neither text is prepared again
+	target := stmt
+	if len(res.lastInsertIdQuery) > 0 && stmt.isPrepared {
+		if stmt.rows != nil {
+			stmt.rows.closeRows()
+			stmt.rows = nil
+		}
+		var err error
+		target, err = stmt.lastInsertIdStatement(res.lastInsertIdQuery)
+		if err != nil {
+			return nil, err
+		}
+		target.mu.Lock()
+		defer target.mu.Unlock()
+	}
+	err := target.execute(ctx, args, res.lastInsertIdQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	//Count the rows affected by every statement in the batch
+	res.rowsAffected, res.lastInsertId, res.hasLastInsertId, err = target.readResults(len(res.lastInsertIdQuery) > 0)
+	if err != nil {
+		return nil, err
+	}
+
+	//Output parameters are available once every result has been consumed
+	err = writeOutputParameters(target.outputParams)
+	if err != nil {
+		return nil, err
+	}
+
+	res.messages = target.messages
+	return res, nil
+}
+
+// Returns the statement prepared with query appended to the SQL text, allocating and preparing it on first use
+func (stmt *statement) lastInsertIdStatement(query string) (*statement, error) {
+	if stmt.lastInsertIdStmt != nil {
+		return stmt.lastInsertIdStmt, nil
+	}
+	idStmt, err := stmt.conn.newStatement(stmt.sqlStmt)
+	if err != nil {
+		return nil, err
+	}
+	idStmt.queryOptions = stmt.queryOptions
+	err = idStmt.prepareText(stmt.sqlStmt + ";\n" + query)
+	if err != nil {
+		idStmt.Close()
+		return nil, err
+	}
+	stmt.lastInsertIdStmt = idStmt
+	return idStmt, nil
+}
+
+// Binds args and executes the statement -- with SQLExecute if it was prepared, otherwise SQLExecDirect.
+// The query timeout is taken from the ctx deadline and the statement is cancelled if ctx is done first.
+// A non-empty appendQuery is run after the statement in the same batch, so it shares the statement's scope.
+func (stmt *statement) execute(ctx context.Context, args []driver.Value, appendQuery string) error {
+	//Do not start executing if the context is already done
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	//Clear any existing bind values
 	stmt.bindValues = make([]interface{}, len(args)+1)
+	stmt.outputParams = nil
+	stmt.messages = nil
 
 	//Bind the parameters
 	bindParameters, err := stmt.convertToBindParameters(args)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	err = stmt.bindParameters(bindParameters)
+	if err != nil {
+		return err
 	}
-	stmt.bindParameters(bindParameters)
 
 	//If rows is not nil, close rows and set to nil
 	if stmt.rows != nil {
-		stmt.rows.Close()
+		stmt.rows.closeRows()
 		stmt.rows = nil
 	}
 
-	//Execute SQL statement
-	sqlStmtSqlPtr := (*odbc.SQLCHAR)(unsafe.Pointer(syscall.StringToUTF16Ptr(stmt.sqlStmt)))
-	ret := odbc.SQLExecDirect(stmt.handle, sqlStmtSqlPtr, odbc.SQL_NTS)
+	//Set the query timeout for the context deadline
+	err = stmt.setQueryTimeout(contextQueryTimeout(ctx, stmt.conn.queryTimeout))
+	if err != nil {
+		return err
+	}
+
+	//Execute SQL statement, watching for the context to be cancelled
+	stopWatch := watchContext(ctx, stmt.api, stmt.handle)
+	var ret odbc.SQLReturn
+	if stmt.catalogCall != nil {
+		ret = stmt.catalogCall()
+	} else {
+		sqlText := stmt.sqlStmt
+		if len(appendQuery) > 0 {
+			sqlText += ";\n" + appendQuery
+		}
+		if stmt.isPrepared {
+			//A prepared INSERT executes the statement prepared with the appended query, so the text only
+			//differs if the statement was prepared without it
+			if sqlText != stmt.preparedText {
+				err = stmt.prepareText(sqlText)
+				if err != nil {
+					stopWatch()
+					return err
+				}
+			}
+			ret = stmt.api.SQLExecute(stmt.handle)
+		} else {
+			sqlStmtSqlPtr := (*odbc.SQLCHAR)(unsafe.Pointer(stringToUTF16Ptr(sqlText)))
+			ret = stmt.api.SQLExecDirect(stmt.handle, sqlStmtSqlPtr, odbc.SQL_NTS)
+		}
+	}
+
+	//Send the data of streamed parameters
+	var putDataErr error
+	if ret == odbc.SQL_NEED_DATA {
+		ret, putDataErr = stmt.putData()
+	}
+	cancelled := stopWatch()
+	if putDataErr != nil {
+		if cancelled || ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return putDataErr
+	}
 	if isError(ret) {
-		return nil, errorStatement(stmt.handle, fmt.Sprintf("SQL Stmt: %v\n Bind Values: %v", stmt.sqlStmt, stmt.formatBindValues()))
+		//Report cancellation and deadlines as the context error rather than the driver's HY008/HYT00
+		if cancelled || ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if stmt.catalogCall != nil {
+			return errorStatement(stmt.api, stmt.handle, fmt.Sprintf("Catalog function: %v", stmt.sqlStmt))
+		}
+		return errorStatement(stmt.api, stmt.handle, fmt.Sprintf("SQL Stmt: %v\nBind Values: %v", stmt.sqlStmt, stmt.formatBindValues()))
 	}
+	stmt.collectMessages(ret)
 
-	return driver.ResultNoRows, nil
+	return nil
+}
+
+// Sets SQL_ATTR_QUERY_TIMEOUT if it differs from the statement's current timeout
+func (stmt *statement) setQueryTimeout(seconds int) error {
+	if stmt.queryTimeout == seconds {
+		return nil
+	}
+	ret := stmt.api.SQLSetStmtAttr(stmt.handle, odbc.SQL_ATTR_QUERY_TIMEOUT, odbc.SQLPOINTER(seconds), odbc.SQL_IS_INTEGER)
+	if isError(ret) {
+		return errorStatement(stmt.api, stmt.handle, stmt.sqlStmt)
+	}
+	stmt.queryTimeout = seconds
+	return nil
 }
 
 func (stmt *statement) NumInput() int {
-	return -1 //No checking by the driver
+	return stmt.numInput
+}
+
+// Prepares the SQL statement with SQLPrepare so Query and Exec only need SQLExecute
+func (stmt *statement) prepare() error {
+	return stmt.prepareText(stmt.sqlStmt)
+}
+
+// Prepares sqlText, the statement's SQL text with any query appended to it
+func (stmt *statement) prepareText(sqlText string) error {
+	sqlStmtSqlPtr := (*odbc.SQLCHAR)(unsafe.Pointer(stringToUTF16Ptr(sqlText)))
+	ret := stmt.api.SQLPrepare(stmt.handle, sqlStmtSqlPtr, odbc.SQL_NTS)
+	if isError(ret) {
+		stmt.isPrepared = false
+		return errorStatement(stmt.api, stmt.handle, fmt.Sprintf("SQL Stmt: %v", sqlText))
+	}
+
+	//Get the number of parameters -- not every driver can describe them, so fall back to no checking
+	var numParams odbc.SQLSMALLINT
+	ret = stmt.api.SQLNumParams(stmt.handle, &numParams)
+	if isError(ret) {
+		stmt.numInput = -1
+	} else {
+		stmt.numInput = int(numParams)
+	}
+
+	stmt.isPrepared = true
+	stmt.preparedText = sqlText
+	return nil
+}
+
+// Reports whether sqlStmt is an INSERT statement, the only kind the last insert id query is appended to.
+// Leading comments, spaces and parentheses are skipped, but statements that insert after another clause
+// or statement, such as WITH ... INSERT or SET NOCOUNT ON; INSERT, are not recognized.
+func isInsert(sqlStmt string) bool {
+	for {
+		sqlStmt = strings.TrimLeft(sqlStmt, " \t\r\n(")
+		switch {
+		case strings.HasPrefix(sqlStmt, "--"):
+			end := strings.IndexByte(sqlStmt, '\n')
+			if end < 0 {
+				return false
+			}
+			sqlStmt = sqlStmt[end+1:]
+		case strings.HasPrefix(sqlStmt, "/*"):
+			end := strings.Index(sqlStmt[2:], "*/")
+			if end < 0 {
+				return false
+			}
+			sqlStmt = sqlStmt[end+4:]
+		default:
+			return len(sqlStmt) >= 6 && strings.EqualFold(sqlStmt[:6], "INSERT")
+		}
+	}
 }
 
 func (stmt *statement) convertToBindParameters(args []driver.Value) ([]BindParameter, error) {
@@ -359,69 +539,50 @@ func (stmt *statement) convertToBindParameters(args []driver.Value) ([]BindParam
 func (stmt *statement) bindParameters(parameters []BindParameter) error {
 	//Call bind statements based on the type of the parameter
 	for index, parameter := range parameters {
-		//Bind a null parameter
-		if isNil(parameter.Data) {
-			err := stmt.bindNull(index+1, parameter.Direction)
+		//Bind output parameters passed as sql.Out
+		if out, ok := parameter.Data.(sql.Out); ok {
+			err := stmt.bindOutput(index+1, out)
 			if err != nil {
 				return err
 			}
 			continue
 		}
+		if parameter.Direction != 0 && parameter.Direction != InputParameter {
+			return fmt.Errorf("Error binding parameter number: %v.  Output parameters must be passed as sql.Out", index+1)
+		}
 
-		//Flatten out pointers
-		elemValue := reflect.Indirect(reflect.ValueOf(parameter.Data)).Interface()
-
-		switch value := elemValue.(type) {
-		case nil:
+		//Bind a null parameter
+		if isNil(parameter.Data) {
 			err := stmt.bindNull(index+1, parameter.Direction)
 			if err != nil {
 				return err
 			}
-		case bool:
-			err := stmt.bindBool(index+1, value, parameter.Direction)
-			if err != nil {
-				return err
-			}
-		case int:
-			err := stmt.bindInt(index+1, value, parameter.Direction)
-			if err != nil {
-				return err
-			}
-		case int64:
-			err := stmt.bindInt64(index+1, value, parameter.Direction)
-			if err != nil {
-				return err
-			}
-		case float64:
-			err := stmt.bindNumeric(index+1, value, parameter.Precision, parameter.Scale, parameter.Direction)
-			if err != nil {
-				return err
-			}
-		case string:
-			err := stmt.bindString(index+1, value, parameter.Length, parameter.Direction)
-			if err != nil {
-				return err
-			}
-		case []byte:
-			err := stmt.bindByteArray(index+1, value, parameter.Direction)
+			continue
+		}
+
+		//Bind readers to be streamed with SQLPutData
+		if stream, ok := streamParameterOf(parameter.Data); ok {
+			err := stmt.bindStream(index+1, stream)
 			if err != nil {
 				return err
 			}
-		case time.Time:
-			if parameter.DateOnly {
-				err := stmt.bindDate(index+1, value, parameter.Direction)
-				if err != nil {
-					return err
-				}
-			} else {
-				err := stmt.bindDateTime(index+1, value, parameter.Direction)
-				if err != nil {
-					return err
-				}
-			}
-		default:
+			continue
+		}
+
+		//Convert the value with the encoder registered for its type
+		entry, value := parameterEncoderFor(parameter.Data)
+		if entry == nil {
 			return fmt.Errorf("Error binding parameter number: %v.  Parameter type not supported: %T", index+1, parameter.Data)
 		}
+		parameter.Data = value
+		encoded, err := entry.encode(parameter, stmt.conn.convertContext())
+		if err != nil {
+			return fmt.Errorf("Error binding parameter number: %v.  %v", index+1, err)
+		}
+		err = stmt.bindEncoded(index+1, value, encoded, parameter.Direction)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -438,20 +599,16 @@ func (stmt *statement) formatBindValues() string {
 			strValues = append(strValues, fmt.Sprintf("%v: <nil>", index))
 		} else {
 			switch val := bvalue.(type) {
-			case *int, *int64, *bool, *float64, *odbc.SQL_DATE_STRUCT, *odbc.SQL_TIMESTAMP_STRUCT:
-				refValue := reflect.ValueOf(val)
-				interfaceValue := reflect.Indirect(refValue).Interface()
-				name := reflect.TypeOf(interfaceValue).Name()
-				strValues = append(strValues, fmt.Sprintf("%v: <%v> {%v}", index, name, interfaceValue))
-			case []uint16:
-				str := syscall.UTF16ToString(val)
-				strValues = append(strValues, fmt.Sprintf("%v: <string> {%v}", index, str))
+			case *boundParameter:
+				strValues = append(strValues, fmt.Sprintf("%v: <%T> {%v}", index, val.value, val.value))
 			case *odbc.SQLLEN:
 				if *val == odbc.SQL_NULL_DATA {
 					strValues = append(strValues, fmt.Sprintf("%v: {NULL}", index))
 				} else {
 					strValues = append(strValues, fmt.Sprintf("%v: <SQLLEN> %v", index, val))
 				}
+			case *putDataParameter:
+				strValues = append(strValues, fmt.Sprintf("%v: <stream>", index))
 			default:
 				strValues = append(strValues, fmt.Sprintf("%v: Unknown type: <%t>", index, val))
 			}
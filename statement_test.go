@@ -0,0 +1,57 @@
+package lodbc_test
+
+import (
+	"github.com/LukeMauldin/lodbc/odbc"
+	"github.com/LukeMauldin/lodbc/odbc/fake"
+	"testing"
+)
+
+func TestExecBindsParameters(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("update t set name = ? where id = ?", &fake.Response{ResultSets: []*fake.ResultSet{{RowsAffected: 3}}})
+	db := openDB(t, dm, nil)
+
+	res, err := db.Exec("update t set name = ? where id = ?", "name", int64(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil || affected != 3 {
+		t.Errorf("RowsAffected returned %v, %v", affected, err)
+	}
+	executions := dm.Executions()
+	if len(executions) != 1 {
+		t.Fatalf("%v executions", len(executions))
+	}
+	params := executions[0].Params
+	if len(params) != 2 || params[0] != "name" || params[1] != int64(7) {
+		t.Errorf("bound parameters %#v", params)
+	}
+}
+
+func TestHandlesAreFreed(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("select * from t", &fake.Response{ResultSets: []*fake.ResultSet{typesResultSet}})
+	db := openDB(t, dm, nil)
+
+	for i := 0; i < 3; i++ {
+		stmt, err := db.Prepare("select * from t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows, err := stmt.Query()
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows.Close()
+		stmt.Close()
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, handleType := range []odbc.SQLSMALLINT{odbc.SQL_HANDLE_STMT, odbc.SQL_HANDLE_DBC} {
+		if open := dm.OpenHandles(handleType); open != 0 {
+			t.Errorf("%v handles of type %v still open", open, handleType)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package lodbc
+
+import (
+	"fmt"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// Bytes read from a streamed parameter's reader for each SQLPutData call
+const putDataChunkSize = 64 * 1024
+
+// Parameter read from Reader while the statement executes and sent to the driver in chunks with
+// SQLPutData, so large values are never held in memory whole.  Passing an io.Reader as an argument
+// is the same as passing a binary StreamParameter of unknown size.
+type StreamParameter struct {
+	Reader io.Reader
+
+	// Length in bytes of the data read from Reader, for drivers that need it before the data -- 0 if unknown.
+	// Text is sent as UTF-16 of a length only known once it is read, so for text Size only bounds the
+	// number of characters, which is bound as the column size.
+	Size int64
+
+	// Sends the UTF-8 text read from Reader as a string, bound as SQL_WLONGVARCHAR, instead of as binary data
+	Text bool
+}
+
+// Data at execution parameter bound with SQL_DATA_AT_EXEC.  Its address is the token SQLParamData
+// returns when the driver asks for its data.
+type putDataParameter struct {
+	reader io.Reader
+	text   bool
+	ind    odbc.SQLLEN
+}
+
+// Returns the StreamParameter for an argument that is streamed, or false
+func streamParameterOf(data interface{}) (StreamParameter, bool) {
+	switch value := data.(type) {
+	case StreamParameter:
+		return value, true
+	case *StreamParameter:
+		return *value, true
+	case io.Reader:
+		return StreamParameter{Reader: value}, true
+	}
+	return StreamParameter{}, false
+}
+
+// Binds a parameter whose data is sent with SQLPutData when the execution returns SQL_NEED_DATA
+func (stmt *statement) bindStream(index int, value StreamParameter) error {
+	if value.Reader == nil {
+		return stmt.bindNull(index, InputParameter)
+	}
+	bindVal := &putDataParameter{reader: value.Reader, text: value.Text, ind: odbc.SQL_DATA_AT_EXEC}
+	cType, sqlType := odbc.SQL_C_BINARY, odbc.SQL_LONGVARBINARY
+	var columnSize odbc.SQLULEN
+	if value.Size > 0 {
+		columnSize = odbc.SQLULEN(value.Size)
+	}
+	if value.Text {
+		cType, sqlType = odbc.SQL_C_WCHAR, odbc.SQL_WLONGVARCHAR
+	} else if value.Size > 0 {
+		bindVal.ind = odbc.SQL_LEN_DATA_AT_EXEC_OFFSET - odbc.SQLLEN(value.Size)
+	}
+	stmt.bindValues[index] = bindVal
+
+	ret := stmt.api.SQLBindParameter(stmt.handle, odbc.SQLUSMALLINT(index), odbc.SQL_PARAM_INPUT, cType, sqlType, columnSize, 0, unsafe.Pointer(bindVal), 0, &bindVal.ind)
+	if isError(ret) {
+		return errorStatement(stmt.api, stmt.handle, fmt.Sprintf("Bind index: %v, Value: <stream>", index))
+	}
+	return nil
+}
+
+// Sends the data at execution parameters the driver asks for with SQLParamData until it runs the
+// statement, and returns the result of the execution.  If a reader or SQLPutData fails, the
+// execution is cancelled and the error returned.
+func (stmt *statement) putData() (odbc.SQLReturn, error) {
+	buf := make([]byte, putDataChunkSize)
+	for {
+		var token odbc.SQLPOINTER
+		ret := stmt.api.SQLParamData(stmt.handle, &token)
+		if ret != odbc.SQL_NEED_DATA {
+			return ret, nil
+		}
+
+		//Find the parameter the token belongs to
+		var param *putDataParameter
+		for _, bindValue := range stmt.bindValues {
+			if p, ok := bindValue.(*putDataParameter); ok && odbc.SQLPOINTER(unsafe.Pointer(p)) == token {
+				param = p
+				break
+			}
+		}
+		if param == nil {
+			stmt.api.SQLCancel(stmt.handle)
+			return odbc.SQL_ERROR, fmt.Errorf("Driver asked for the data of an unknown parameter.  SQL Stmt: %v", stmt.sqlStmt)
+		}
+
+		err := param.send(stmt.api, stmt.handle, buf)
+		if err != nil {
+			stmt.api.SQLCancel(stmt.handle)
+			return odbc.SQL_ERROR, err
+		}
+	}
+}
+
+// Reads the parameter's reader to the end, passing each chunk to SQLPutData.  Text is converted to UTF-16,
+// holding back a UTF-8 sequence split between two reads until the rest of it arrives.
+func (p *putDataParameter) send(api odbc.API, handle odbc.SQLHandle, buf []byte) error {
+	var partial []byte
+	isSent := false
+	for {
+		n, readErr := p.reader.Read(buf)
+		if n > 0 || (readErr == io.EOF && len(partial) > 0) {
+			data := buf[:n]
+			if p.text {
+				data = append(partial, data...)
+				partial = nil
+				if readErr != io.EOF {
+					data, partial = splitPartialRune(data)
+				}
+			}
+			if len(data) > 0 {
+				err := putChunk(api, handle, data, p.text)
+				if err != nil {
+					return err
+				}
+				isSent = true
+			}
+		}
+		if readErr == io.EOF {
+			break
+		} else if readErr != nil {
+			return readErr
+		}
+	}
+
+	//An empty value is sent with a single zero length call
+	if !isSent {
+		ret := api.SQLPutData(handle, unsafe.Pointer(&buf[0]), 0)
+		if isError(ret) {
+			return errorStatement(api, handle, "SQLPutData")
+		}
+	}
+	return nil
+}
+
+// Passes data to SQLPutData, as UTF-16 if text is true
+func putChunk(api odbc.API, handle odbc.SQLHandle, data []byte, text bool) error {
+	ptr, length := unsafe.Pointer(&data[0]), len(data)
+	if text {
+		units := utf16.Encode([]rune(string(data)))
+		ptr, length = unsafe.Pointer(&units[0]), len(units)*2
+	}
+	ret := api.SQLPutData(handle, ptr, odbc.SQLLEN(length))
+	if isError(ret) {
+		return errorStatement(api, handle, "SQLPutData")
+	}
+	return nil
+}
+
+// Splits an incomplete UTF-8 sequence off the end of data
+func splitPartialRune(data []byte) ([]byte, []byte) {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return data[:i], append([]byte(nil), data[i:]...)
+			}
+			break
+		}
+	}
+	return data, nil
+}
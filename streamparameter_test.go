@@ -0,0 +1,108 @@
+package lodbc_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/LukeMauldin/lodbc"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"github.com/LukeMauldin/lodbc/odbc/fake"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// Returns the number of calls to function made on dm
+func countCalls(dm *fake.DriverManager, function string) int {
+	count := 0
+	for _, call := range dm.Calls() {
+		if call.Function == function {
+			count++
+		}
+	}
+	return count
+}
+
+func TestStreamParametersAreSentInChunks(t *testing.T) {
+	data := bytes.Repeat([]byte{1, 2, 3, 4, 5}, 30000)
+	text := strings.Repeat("Grüße 😀 ", 1000)
+	dm := fake.New()
+	dm.HandleDefault(func(e *fake.Execution) *fake.Response { return &fake.Response{} })
+	db := openDB(t, dm, nil)
+
+	//Binary data larger than a chunk is sent whole, and text read a byte at a time is reassembled into whole characters
+	_, err := db.Exec("insert into t values (?, ?, ?, ?)", int64(1), bytes.NewReader(data),
+		lodbc.StreamParameter{Reader: iotest.OneByteReader(strings.NewReader(text)), Text: true, Size: 9000}, lodbc.StreamParameter{Reader: strings.NewReader("")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	execution := dm.Executions()[0]
+	if len(execution.Params) != 4 || execution.Params[0] != int64(1) || !bytes.Equal(execution.Params[1].([]byte), data) {
+		t.Fatalf("parameters %.40v", execution.Params)
+	}
+	if execution.Params[2] != text || len(execution.Params[3].([]byte)) != 0 {
+		t.Errorf("text and empty parameters %.40q and %v", execution.Params[2], execution.Params[3])
+	}
+	if calls := countCalls(dm, "SQLParamData"); calls != 4 {
+		t.Errorf("%v SQLParamData calls, want one for each of the 3 streams and one to run the statement", calls)
+	}
+
+	//Text is bound as SQL_WLONGVARCHAR with Size as its column size, and binary as SQL_LONGVARBINARY
+	types := execution.ParamTypes
+	if types[1].SQLType != odbc.SQL_LONGVARBINARY || types[1].ColumnSize != 0 {
+		t.Errorf("binary stream bound as %+v", types[1])
+	}
+	if types[2].SQLType != odbc.SQL_WLONGVARCHAR || types[2].ColumnSize != 9000 {
+		t.Errorf("text stream bound as %+v", types[2])
+	}
+	putData := countCalls(dm, "SQLPutData")
+
+	//A binary stream of known size is bound with it
+	_, err = db.Exec("insert into t values (?)", lodbc.StreamParameter{Reader: bytes.NewReader(data[:100]), Size: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	execution = dm.Executions()[1]
+	if !bytes.Equal(execution.Params[0].([]byte), data[:100]) || execution.ParamTypes[0].SQLType != odbc.SQL_LONGVARBINARY || execution.ParamTypes[0].ColumnSize != 100 {
+		t.Errorf("sized binary stream sent %v bound as %+v", execution.Params[0], execution.ParamTypes[0])
+	}
+	if calls := countCalls(dm, "SQLPutData") - putData; calls != 1 {
+		t.Errorf("%v SQLPutData calls for 100 bytes", calls)
+	}
+
+	//A nil reader is bound as NULL
+	if _, err := db.Exec("insert into t values (?)", lodbc.StreamParameter{}); err != nil {
+		t.Fatal(err)
+	}
+	if value := dm.Executions()[2].Params[0]; value != nil {
+		t.Errorf("nil reader sent %v", value)
+	}
+}
+
+func TestStreamParameterReaderErrorsCancelTheExecution(t *testing.T) {
+	readErr := errors.New("disk on fire")
+	dm := fake.New()
+	dm.HandleDefault(func(e *fake.Execution) *fake.Response { return &fake.Response{} })
+	db := openDB(t, dm, nil)
+	db.SetMaxOpenConns(1)
+
+	failing := io.MultiReader(strings.NewReader("some data"), iotest.ErrReader(readErr))
+	_, err := db.Exec("insert into t values (?)", failing)
+	if !errors.Is(err, readErr) {
+		t.Errorf("got %v, want the reader's error", err)
+	}
+	if countCalls(dm, "SQLCancel") == 0 {
+		t.Error("the execution was not cancelled")
+	}
+	if len(dm.Executions()) != 0 {
+		t.Errorf("the statement ran with %v", dm.Executions()[0].Params)
+	}
+
+	//The connection is still usable
+	if _, err := db.Exec("insert into t values (?)", strings.NewReader("data")); err != nil {
+		t.Fatal(err)
+	}
+	if value := dm.Executions()[0].Params[0]; string(value.([]byte)) != "data" {
+		t.Errorf("sent %v", value)
+	}
+}
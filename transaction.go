@@ -1,37 +1,105 @@
 package lodbc
 
 import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
 	"github.com/LukeMauldin/lodbc/odbc"
 )
 
 // Implements type database/sql/driver TX interface
 type transaction struct {
 	conn *connection
+
+	// Isolation level to restore when the transaction completes
+	previousIsolation odbc.SQLINTEGER
+	restoreIsolation  bool
+
+	// Access mode to restore when the transaction completes
+	previousAccessMode odbc.SQLINTEGER
+	restoreAccessMode  bool
 }
 
 // Commit transaction
 func (tx *transaction) Commit() error {
-	return tx.completeTransaction(odbc.SQL_COMMIT)
+	return tx.conn.checkLink(tx.completeTransaction(odbc.SQL_COMMIT))
 }
 
 // Rollback transaction
 func (tx *transaction) Rollback() error {
-	return tx.completeTransaction(odbc.SQL_ROLLBACK)
+	return tx.conn.checkLink(tx.completeTransaction(odbc.SQL_ROLLBACK))
 }
 
 // Commit or rollback transaction in consistent manner
 func (tx *transaction) completeTransaction(completeType odbc.SQLSMALLINT) error {
 	//Complete transaction by either committing or rolling back
-	ret := odbc.SQLEndTran(odbc.SQL_HANDLE_DBC, tx.conn.handle, completeType)
+	var err error
+	ret := tx.conn.api.SQLEndTran(odbc.SQL_HANDLE_DBC, tx.conn.handle, completeType)
 	if isError(ret) {
-		return errorConnection(tx.conn.handle)
+		err = errorConnection(tx.conn.api, tx.conn.handle)
+
+		//The transaction may still be open, and turning auto commit back on would commit it -- roll it back,
+		//or discard the connection if that fails too
+		if completeType == odbc.SQL_ROLLBACK || isError(tx.conn.api.SQLEndTran(odbc.SQL_HANDLE_DBC, tx.conn.handle, odbc.SQL_ROLLBACK)) {
+			tx.conn.isTransactionActive = false
+			tx.conn.markBad()
+			return err
+		}
+	} else {
+		tx.conn.infoMessages(ret, odbc.SQL_HANDLE_DBC, tx.conn.handle, "")
 	}
 
-	//Make transaction as finished and turn auto commit back on
+	//Make transaction as finished and turn auto commit back on, unless the connection has it off
 	tx.conn.isTransactionActive = false
-	ret = odbc.SQLSetConnectAttr(tx.conn.handle, odbc.SQL_ATTR_AUTOCOMMIT, odbc.SQLPOINTER(odbc.SQL_AUTOCOMMIT_ON), 0, nil)
+	ret = tx.conn.api.SQLSetConnectAttr(tx.conn.handle, odbc.SQL_ATTR_AUTOCOMMIT, odbc.SQLPOINTER(tx.conn.autocommit), 0, nil)
 	if isError(ret) {
-		return errorConnection(tx.conn.handle)
+		autocommitErr := errorConnection(tx.conn.api, tx.conn.handle)
+		if err == nil {
+			err = autocommitErr
+		}
+		tx.conn.markBad()
+	}
+
+	//Restore the isolation level and access mode the connection had before the transaction, discarding
+	//the connection if they cannot be, so the pool does not hand them to the next user
+	if restoreErr := tx.restoreSettings(); restoreErr != nil {
+		if err == nil {
+			err = restoreErr
+		}
+		tx.conn.markBad()
+	}
+	return err
+}
+
+// Restores the connection settings changed by BeginTx, returning the first error
+func (tx *transaction) restoreSettings() error {
+	var err error
+	if tx.restoreAccessMode {
+		err = tx.conn.setConnectAttr(odbc.SQL_ATTR_ACCESS_MODE, tx.previousAccessMode)
+		tx.restoreAccessMode = false
+	}
+	if tx.restoreIsolation {
+		if isolationErr := tx.conn.setConnectAttr(odbc.SQL_ATTR_TXN_ISOLATION, tx.previousIsolation); err == nil {
+			err = isolationErr
+		}
+		tx.restoreIsolation = false
+	}
+	return err
+}
+
+// Maps a database/sql isolation level to the SQL_ATTR_TXN_ISOLATION value
+func txnIsolation(level driver.IsolationLevel) (odbc.SQLINTEGER, error) {
+	switch sql.IsolationLevel(level) {
+	case sql.LevelReadUncommitted:
+		return odbc.SQL_TXN_READ_UNCOMMITTED, nil
+	case sql.LevelReadCommitted:
+		return odbc.SQL_TXN_READ_COMMITTED, nil
+	case sql.LevelRepeatableRead:
+		return odbc.SQL_TXN_REPEATABLE_READ, nil
+	case sql.LevelSnapshot:
+		return odbc.SQL_TXN_SS_SNAPSHOT, nil
+	case sql.LevelSerializable:
+		return odbc.SQL_TXN_SERIALIZABLE, nil
 	}
-	return nil
+	return 0, fmt.Errorf("Unsupported transaction isolation level: %v", sql.IsolationLevel(level))
 }
@@ -0,0 +1,112 @@
+package lodbc_test
+
+import (
+	"context"
+	"database/sql"
+	"github.com/LukeMauldin/lodbc/odbc"
+	"github.com/LukeMauldin/lodbc/odbc/fake"
+	"testing"
+)
+
+// Checks the transaction settings of the connection the last statement executed on
+func checkTxnAttrs(t *testing.T, dm *fake.DriverManager, isolation, accessMode, autocommit odbc.SQLPOINTER) {
+	t.Helper()
+	executions := dm.Executions()
+	attrs := executions[len(executions)-1].ConnAttrs
+	if attrs[odbc.SQL_ATTR_TXN_ISOLATION] != isolation {
+		t.Errorf("SQL_ATTR_TXN_ISOLATION %v, want %v", attrs[odbc.SQL_ATTR_TXN_ISOLATION], isolation)
+	}
+	if attrs[odbc.SQL_ATTR_ACCESS_MODE] != accessMode {
+		t.Errorf("SQL_ATTR_ACCESS_MODE %v, want %v", attrs[odbc.SQL_ATTR_ACCESS_MODE], accessMode)
+	}
+	if attrs[odbc.SQL_ATTR_AUTOCOMMIT] != autocommit {
+		t.Errorf("SQL_ATTR_AUTOCOMMIT %v, want %v", attrs[odbc.SQL_ATTR_AUTOCOMMIT], autocommit)
+	}
+}
+
+func TestTransactionsRestoreIsolationAndAccessMode(t *testing.T) {
+	for _, commit := range []bool{true, false} {
+		dm := fake.New()
+		dm.SetResponse("update t", &fake.Response{})
+		db := openDB(t, dm, nil)
+		db.SetMaxOpenConns(1)
+
+		tx, err := db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tx.Exec("update t"); err != nil {
+			t.Fatal(err)
+		}
+		checkTxnAttrs(t, dm, odbc.SQLPOINTER(odbc.SQL_TXN_SERIALIZABLE), odbc.SQLPOINTER(odbc.SQL_MODE_READ_ONLY), odbc.SQLPOINTER(odbc.SQL_AUTOCOMMIT_OFF))
+
+		if commit {
+			err = tx.Commit()
+		} else {
+			err = tx.Rollback()
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		//The next statement runs on the same connection, with the settings it had before the transaction
+		if _, err := db.Exec("update t"); err != nil {
+			t.Fatal(err)
+		}
+		checkTxnAttrs(t, dm, odbc.SQLPOINTER(odbc.SQL_TXN_READ_COMMITTED), odbc.SQLPOINTER(odbc.SQL_MODE_READ_WRITE), odbc.SQLPOINTER(odbc.SQL_AUTOCOMMIT_ON))
+		if open := dm.OpenHandles(odbc.SQL_HANDLE_DBC); open != 1 {
+			t.Errorf("%v connections open, want 1", open)
+		}
+	}
+}
+
+func TestTransactionsUseTheDefaultIsolationOfTheConnection(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("update t", &fake.Response{})
+	db := openDB(t, dm, nil)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("update t"); err != nil {
+		t.Fatal(err)
+	}
+	executions := dm.Executions()
+	attrs := executions[len(executions)-1].ConnAttrs
+	if _, ok := attrs[odbc.SQL_ATTR_TXN_ISOLATION]; ok {
+		t.Error("SQL_ATTR_TXN_ISOLATION set for a transaction with the default isolation level")
+	}
+	if _, ok := attrs[odbc.SQL_ATTR_ACCESS_MODE]; ok {
+		t.Error("SQL_ATTR_ACCESS_MODE set for a read write transaction")
+	}
+}
+
+func TestFailedCommitDiscardsTheConnection(t *testing.T) {
+	dm := fake.New()
+	dm.SetResponse("update t", &fake.Response{})
+	db := openDB(t, dm, nil)
+	db.SetMaxOpenConns(1)
+
+	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelSnapshot})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("update t"); err != nil {
+		t.Fatal(err)
+	}
+	dm.DropConnections()
+	if err := tx.Commit(); err == nil {
+		t.Fatal("Commit on a dropped connection succeeded")
+	}
+
+	//The connection is discarded rather than handed out again with the snapshot isolation level
+	if _, err := db.Exec("update t"); err != nil {
+		t.Fatal(err)
+	}
+	executions := dm.Executions()
+	if _, ok := executions[len(executions)-1].ConnAttrs[odbc.SQL_ATTR_TXN_ISOLATION]; ok {
+		t.Error("statement executed on the connection of the failed transaction")
+	}
+}
@@ -2,8 +2,12 @@ package lodbc
 
 import (
 	"database/sql"
+	"fmt"
 	"github.com/LukeMauldin/lodbc/odbc"
+	"math/big"
 	"reflect"
+	"unicode/utf16"
+	"unsafe"
 )
 
 // Utility function to quickly return rows from the database
@@ -29,51 +33,128 @@ func FetchRows(db *sql.DB, query string, tmplt interface{}) ([]interface{}, erro
 	return result, nil
 }
 
-//Converts SQL_NUMERIC_STRUCT to float
-func numericToFloat(inputValue odbc.SQL_NUMERIC_STRUCT) float64 {
-	//Convert numeric data to float
-	theData := make([]byte, 0, 16)
-	for _, v := range inputValue.Val {
-		theData = append(theData, byte(v))
-	}
-	outputVal := byteToHextOval(theData)
-
-	//Take into account the scale - converts to a decimal if necessary
-	divisor := float64(1)
-	if inputValue.Scale > 0 {
-		for i := odbc.SQLCHAR(0); i < inputValue.Scale; i++ {
-			divisor = divisor * 10
-		}
+//Converts SQL_NUMERIC_STRUCT to an exact rational number
+func numericToRat(inputValue odbc.SQL_NUMERIC_STRUCT) *big.Rat {
+	//Val holds the unscaled value as a little endian integer
+	bigEndian := make([]byte, len(inputValue.Val))
+	for i, v := range inputValue.Val {
+		bigEndian[len(bigEndian)-1-i] = byte(v)
 	}
-
-	finalVal := float64(outputVal) / divisor
+	unscaled := new(big.Int).SetBytes(bigEndian)
 
 	//Take into account the sign - if it is 0, convert to a negative
 	if inputValue.Sign == 0 {
-		finalVal = finalVal * -1
+		unscaled.Neg(unscaled)
 	}
 
+	//Take into account the scale, which is signed
+	scale := int(int8(inputValue.Scale))
+	if scale < 0 {
+		return new(big.Rat).SetInt(unscaled.Mul(unscaled, pow10(-scale)))
+	}
+	return new(big.Rat).SetFrac(unscaled, pow10(scale))
+}
+
+//Converts SQL_NUMERIC_STRUCT to float
+func numericToFloat(inputValue odbc.SQL_NUMERIC_STRUCT) float64 {
+	finalVal, _ := numericToRat(inputValue).Float64()
 	return finalVal
 }
 
-//Helper function for numericToFloat
-func byteToHextOval(inputVal []byte) int64 {
-	var value int64
-	last := int64(1)
-	var current int64
-	var a, b int64
+//Converts SQL_NUMERIC_STRUCT to its decimal text, with exactly Scale digits after the decimal point
+func numericToString(inputValue odbc.SQL_NUMERIC_STRUCT) string {
+	scale := int(int8(inputValue.Scale))
+	if scale < 0 {
+		scale = 0
+	}
+	return numericToRat(inputValue).FloatString(scale)
+}
+
+//Converts value to SQL_NUMERIC_STRUCT, rounding half away from zero to scale digits.
+//Returns an error if the value needs more than precision digits.
+func ratToNumeric(value *big.Rat, precision int, scale int) (odbc.SQL_NUMERIC_STRUCT, error) {
+	var numeric odbc.SQL_NUMERIC_STRUCT
+	if precision < 1 || precision > 38 || scale < 0 || scale > precision {
+		return numeric, fmt.Errorf("Invalid decimal precision %v and scale %v", precision, scale)
+	}
 
-	for i := 0; i <= 15; i++ {
-		current = int64(inputVal[i])
-		a = current % 16
-		b = current / 16
+	//Scale and round the value
+	unscaled := new(big.Rat).Abs(value)
+	unscaled.Mul(unscaled, new(big.Rat).SetInt(pow10(scale)))
+	quotient, remainder := new(big.Int).QuoRem(unscaled.Num(), unscaled.Denom(), new(big.Int))
+	if new(big.Int).Mul(remainder, big.NewInt(2)).Cmp(unscaled.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if quotient.Cmp(pow10(precision)) >= 0 {
+		return numeric, fmt.Errorf("Decimal value %v does not fit precision %v and scale %v", value.FloatString(scale), precision, scale)
+	}
 
-		value += last * a
-		last = last * 16
-		value += last * b
-		last = last * 16
+	numeric.Precision = odbc.SQLCHAR(precision)
+	numeric.Scale = odbc.SQLCHAR(scale)
+	numeric.Sign = 1
+	if value.Sign() < 0 && quotient.Sign() != 0 {
+		numeric.Sign = 0
+	}
+	bigEndian := quotient.Bytes()
+	for i := 0; i < len(bigEndian); i++ {
+		numeric.Val[i] = odbc.SQLCHAR(bigEndian[len(bigEndian)-1-i])
 	}
-	return value
+	return numeric, nil
+}
+
+//Returns the number of digits needed for value at scale, for binding decimals without a precision
+func decimalPrecision(value *big.Rat, scale int) int {
+	unscaled := new(big.Rat).Abs(value)
+	unscaled.Mul(unscaled, new(big.Rat).SetInt(pow10(scale)))
+	digits := len(new(big.Int).Quo(unscaled.Num(), unscaled.Denom()).String())
+	if digits < scale {
+		digits = scale
+	}
+	if digits < 1 {
+		digits = 1
+	}
+	return digits
+}
+
+//Returns the number of decimal digits after the decimal point needed to represent value exactly, capped at 38
+func decimalScale(value *big.Rat) int {
+	denom := value.Denom()
+	for scale := 0; scale < 38; scale++ {
+		if new(big.Int).Rem(pow10(scale), denom).Sign() == 0 {
+			return scale
+		}
+	}
+	return 38
+}
+
+//Sets the precision and scale of a SQL_C_NUMERIC record on an application row or parameter descriptor, which
+//SQL_C_NUMERIC requires to work - http://support.microsoft.com/kb/181254.  Setting SQL_DESC_TYPE clears the
+//data pointer of a bound record, so dataPtr is set again last unless it is nil.
+func setNumericDescriptor(api odbc.API, descHandle odbc.SQLHandle, recNum int, precision int, scale int, dataPtr unsafe.Pointer, driverInfo string) error {
+	descFields := []struct {
+		field odbc.SQLSMALLINT
+		value unsafe.Pointer
+	}{
+		{odbc.SQL_DESC_TYPE, odbc.IntegerField(int(odbc.SQL_C_NUMERIC))},
+		{odbc.SQL_DESC_PRECISION, odbc.IntegerField(precision)},
+		{odbc.SQL_DESC_SCALE, odbc.IntegerField(scale)},
+		{odbc.SQL_DESC_DATA_PTR, dataPtr},
+	}
+	if dataPtr == nil {
+		descFields = descFields[:3]
+	}
+	for _, descField := range descFields {
+		ret := api.SQLSetDescField(descHandle, odbc.SQLSMALLINT(recNum), descField.field, descField.value, 0)
+		if isError(ret) {
+			return handleError(api, odbc.SQL_HANDLE_DESC, descHandle, driverInfo)
+		}
+	}
+	return nil
+}
+
+//Returns 10^n
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
 }
 
 //Checks the type v for nil
@@ -89,3 +170,32 @@ func isNil(v interface{}) bool {
 	}
 	return false
 }
+
+// Converts a string to a NUL terminated UTF-16 slice (SQLWCHAR) for the ODBC W functions
+func stringToUTF16(s string) []uint16 {
+	return utf16.Encode([]rune(s + "\x00"))
+}
+
+// Returns a pointer to a NUL terminated UTF-16 copy of s
+func stringToUTF16Ptr(s string) *uint16 {
+	return &stringToUTF16(s)[0]
+}
+
+// Converts a UTF-16 (SQLWCHAR) buffer to a string, stopping at the first NUL
+func utf16ToString(s []uint16) string {
+	for i, v := range s {
+		if v == 0 {
+			s = s[0:i]
+			break
+		}
+	}
+	return string(utf16.Decode(s))
+}
+
+// Converts UTF-16 (SQLWCHAR) data in native byte order to a string
+func utf16BytesToString(data []byte) string {
+	if len(data) < 2 {
+		return ""
+	}
+	return string(utf16.Decode(unsafe.Slice((*uint16)(unsafe.Pointer(&data[0])), len(data)/2)))
+}